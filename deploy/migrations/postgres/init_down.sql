@@ -1 +1,25 @@
-DROP TABLE refresh_tokens;
\ No newline at end of file
+DROP TABLE risk_assessments;
+DROP TABLE step_up_challenges;
+DROP TABLE security_actions;
+DROP TABLE notification_alerts;
+DROP TABLE known_networks;
+DROP TABLE notification_preferences;
+DROP TABLE email_messages;
+DROP TABLE webhook_deliveries;
+DROP TABLE outbox_events;
+DROP TABLE audit_events;
+DROP FUNCTION audit_events_append_only;
+DROP TABLE account_lockouts;
+DROP TABLE rate_limit_buckets;
+DROP TABLE api_keys;
+DROP TABLE impersonations;
+DROP TABLE device_codes;
+DROP TABLE authorization_codes;
+DROP TABLE client_assertions;
+DROP TABLE oauth_clients;
+DROP TABLE user_roles;
+DROP TABLE role_permissions;
+DROP TABLE permissions;
+DROP TABLE roles;
+DROP TABLE users;
+DROP TABLE refresh_tokens;
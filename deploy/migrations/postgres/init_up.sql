@@ -1,5 +1,293 @@
 CREATE TABLE refresh_tokens (
     id uuid DEFAULT gen_random_uuid() PRIMARY KEY,    
+    family_id uuid NOT NULL,
+    user_id uuid NOT NULL,
     hash BYTEA NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    scopes TEXT[],
+    audience TEXT[],
+    auth_time TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    amr TEXT[],
+    accept_language TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    location JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+CREATE TABLE users (
+    id uuid PRIMARY KEY,
+    email TEXT NOT NULL,
+    email_verified BOOLEAN NOT NULL DEFAULT false,
+    name TEXT NOT NULL DEFAULT '',
+    given_name TEXT NOT NULL DEFAULT '',
+    family_name TEXT NOT NULL DEFAULT '',
+    locale TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX users_email_idx ON users (lower(email));
+
+CREATE TABLE roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE TABLE permissions (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE TABLE role_permissions (
+    role_name TEXT NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission_name TEXT NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+    PRIMARY KEY (role_name, permission_name)
+);
+
+CREATE TABLE user_roles (
+    user_id uuid NOT NULL,
+    role_name TEXT NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, role_name)
+);
+
+CREATE TABLE oauth_clients (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    grant_types TEXT[] NOT NULL DEFAULT '{authorization_code}',
+    token_endpoint_auth_method TEXT NOT NULL DEFAULT 'none',
+    secret_hash BYTEA,
+    jwks JSONB,
+    exchange_audiences TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE TABLE client_assertions (
+    client_id TEXT NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    jti TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (client_id, jti)
+);
+
+CREATE TABLE authorization_codes (
+    code_hash BYTEA PRIMARY KEY,
+    client_id TEXT NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    user_id uuid NOT NULL,
+    redirect_uri TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    code_challenge TEXT NOT NULL,
+    code_challenge_method TEXT NOT NULL,
+    nonce TEXT NOT NULL DEFAULT '',
+    auth_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    amr TEXT[],
     expires_at TIMESTAMP WITH TIME ZONE NOT NULL
-);
\ No newline at end of file
+);
+
+CREATE TABLE device_codes (
+    device_code_hash BYTEA PRIMARY KEY,
+    user_code TEXT NOT NULL UNIQUE,
+    client_id TEXT NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    user_id uuid,
+    auth_time TIMESTAMP WITH TIME ZONE,
+    amr TEXT[],
+    poll_interval INTEGER NOT NULL,
+    last_polled_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE impersonations (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    admin_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    reason TEXT NOT NULL,
+    admin_ip TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX impersonations_user_id_idx ON impersonations (user_id, created_at);
+
+CREATE TABLE api_keys (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    prefix TEXT NOT NULL UNIQUE,
+    secret_hash BYTEA NOT NULL,
+    name TEXT NOT NULL,
+    user_id uuid,
+    client_id TEXT REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    CHECK ((user_id IS NULL) <> (client_id IS NULL))
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
+
+CREATE TABLE rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    full_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX rate_limit_buckets_full_at_idx ON rate_limit_buckets (full_at);
+
+CREATE TABLE account_lockouts (
+    user_id uuid PRIMARY KEY,
+    failures INT NOT NULL,
+    last_failure_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    unlock_token_hash BYTEA UNIQUE
+);
+
+CREATE TABLE audit_events (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    type TEXT NOT NULL,
+    user_id uuid NOT NULL,
+    ip TEXT NOT NULL,
+    details JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    prev_hash BYTEA NOT NULL,
+    hash BYTEA NOT NULL
+);
+
+CREATE INDEX audit_events_user_id_idx ON audit_events (user_id, id);
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at);
+
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update_or_delete BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TABLE outbox_events (
+    id uuid PRIMARY KEY,
+    type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    dispatched_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX outbox_events_undispatched_idx ON outbox_events (created_at) WHERE dispatched_at IS NULL;
+
+CREATE TABLE webhook_deliveries (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    event_id uuid NOT NULL REFERENCES outbox_events (id),
+    endpoint TEXT NOT NULL,
+    status TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    UNIQUE (event_id, endpoint)
+);
+
+CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE email_messages (
+    id uuid PRIMARY KEY,
+    sender TEXT NOT NULL,
+    user_id uuid NOT NULL,
+    template TEXT NOT NULL,
+    accept_language TEXT NOT NULL DEFAULT '',
+    data JSONB,
+    status TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX email_messages_due_idx ON email_messages (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX email_messages_user_id_idx ON email_messages (user_id, created_at);
+
+CREATE TABLE notification_preferences (
+    user_id uuid PRIMARY KEY,
+    disabled_alerts TEXT[] NOT NULL,
+    digest BOOLEAN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE known_networks (
+    user_id uuid NOT NULL,
+    network TEXT NOT NULL,
+    first_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, network)
+);
+
+CREATE TABLE notification_alerts (
+    id uuid PRIMARY KEY,
+    user_id uuid NOT NULL,
+    type TEXT NOT NULL,
+    accept_language TEXT NOT NULL DEFAULT '',
+    data JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX notification_alerts_user_id_idx ON notification_alerts (user_id, created_at);
+
+CREATE TABLE security_actions (
+    id uuid PRIMARY KEY,
+    user_id uuid NOT NULL,
+    session_id uuid,
+    ip TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE step_up_challenges (
+    id uuid PRIMARY KEY,
+    user_id uuid NOT NULL,
+    refresh_token_id uuid,
+    session JSONB,
+    session_id uuid NOT NULL,
+    assessment_id uuid,
+    code_hash BYTEA NOT NULL,
+    ip TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CHECK ((refresh_token_id IS NULL) <> (session IS NULL))
+);
+
+CREATE TABLE risk_assessments (
+    id uuid PRIMARY KEY,
+    user_id uuid NOT NULL,
+    session_id uuid NOT NULL,
+    event TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    network TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    location JSONB,
+    score INT NOT NULL,
+    decision TEXT NOT NULL,
+    factors JSONB NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX risk_assessments_user_id_idx ON risk_assessments (user_id, created_at);
+
+INSERT INTO permissions (name, description) VALUES ('auth:admin', 'Access to the auth service admin API');
+INSERT INTO roles (name, description) VALUES ('admin', 'Auth service administrator');
+INSERT INTO role_permissions (role_name, permission_name) VALUES ('admin', 'auth:admin');
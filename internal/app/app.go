@@ -2,22 +2,52 @@ package app
 
 import (
 	"auth/internal/config"
+	apikeycontroller "auth/internal/controllers/apikey"
+	auditcontroller "auth/internal/controllers/audit"
 	authcontroller "auth/internal/controllers/auth"
+	emailcontroller "auth/internal/controllers/email"
+	"auth/internal/controllers/httputils"
+	impersonationcontroller "auth/internal/controllers/impersonation"
+	lockoutcontroller "auth/internal/controllers/lockout"
+	mailboxcontroller "auth/internal/controllers/mailbox"
+	notificationcontroller "auth/internal/controllers/notification"
+	oauthcontroller "auth/internal/controllers/oauth"
+	rbaccontroller "auth/internal/controllers/rbac"
+	usercontroller "auth/internal/controllers/user"
+	webhookcontroller "auth/internal/controllers/webhook"
 	"auth/internal/db/postgres"
+	"auth/internal/services/apikey"
+	"auth/internal/services/audit"
 	authservice "auth/internal/services/auth"
 	"auth/internal/services/email"
+	"auth/internal/services/geoip"
+	"auth/internal/services/impersonation"
+	"auth/internal/services/lockout"
+	"auth/internal/services/notification"
+	"auth/internal/services/oauth"
+	"auth/internal/services/ratelimit"
+	"auth/internal/services/rbac"
+	"auth/internal/services/risk"
+	"auth/internal/services/user"
+	"auth/internal/services/webhook"
 	"auth/internal/storages"
+	keyutils "auth/internal/utils/key"
 	logutils "auth/internal/utils/log"
+	"auth/pkg/authmw"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 )
 
@@ -28,15 +58,123 @@ func Run(cfg *config.Config) error {
 	}
 
 	refreshTokenStorage := storages.NewRefreshTokenStorage(db)
-	userStorage := storages.NewFakeUserStorage()
+	rbacStorage := storages.NewRBACStorage(db)
+	oauthClientStorage := storages.NewOAuthClientStorage(db)
+	authorizationCodeStorage := storages.NewAuthorizationCodeStorage(db)
+	clientAssertionStorage := storages.NewClientAssertionStorage(db)
+	deviceCodeStorage := storages.NewDeviceCodeStorage(db)
+	userStorage := storages.NewUserStorage(db)
+	impersonationStorage := storages.NewImpersonationStorage(db)
+	apiKeyStorage := storages.NewAPIKeyStorage(db)
+	lockoutStorage := storages.NewLockoutStorage(db)
+	auditStorage := storages.NewAuditStorage(db)
+	webhookStorage := storages.NewWebhookStorage(db)
+	emailQueueStorage := storages.NewEmailQueueStorage(db)
+	notificationStorage := storages.NewNotificationStorage(db)
+	challengeStorage := storages.NewChallengeStorage(db)
+	riskStorage := storages.NewRiskStorage(db)
 
-	emailService := email.NewEmailService(cfg.SMTP, userStorage)
-	authService := authservice.NewAuthService(refreshTokenStorage, emailService, []byte(cfg.Auth.JWTPrivateKey), cfg.Auth.AccessTokenDuration, cfg.Auth.RefreshTokenDuration, cfg.Emails)
+	signingKey, err := newSigningKey(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to load signing key")
+	}
+
+	rateLimitStore, err := newRateLimitStore(cfg, db)
+	if err != nil {
+		return errors.Wrap(err, "failed to init rate limits")
+	}
+	rateLimiter := ratelimit.NewLimiter(rateLimitStore, cfg.RateLimits)
 
-	authController := authcontroller.NewAuthController(authService)
+	geoLocator, err := geoip.NewLocator(cfg.GeoIP)
+	if err != nil {
+		return errors.Wrap(err, "failed to load geoip databases")
+	}
+
+	emailRenderer, err := email.NewRenderer(cfg.Emails)
+	if err != nil {
+		return errors.Wrap(err, "failed to load email templates")
+	}
+	emailSender, err := newEmailSender(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to init email provider")
+	}
+	if closer, ok := emailSender.(interface{ Close() }); ok {
+		defer closer.Close()
+	}
+	emailSigner, err := newEmailSigner(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to load dkim key")
+	}
+	emailService := email.NewEmailService(emailSender, userStorage, emailRenderer, emailSigner)
+	emailQueue := email.NewEmailQueue(emailQueueStorage, emailService, cfg.EmailQueue)
+	lockoutService := lockout.NewLockoutService(lockoutStorage, emailQueue, cfg.Lockout, cfg.Emails)
+	auditService := audit.NewAuditService(auditStorage, geoLocator)
+	webhookService := webhook.NewWebhookService(webhookStorage, cfg.Webhooks)
+	// the access token secret signs tokens, other purposes use keys derived
+	// from it
+	actionKey := keyutils.Derive([]byte(cfg.Auth.JWTPrivateKey), "security action links")
+	notificationService := notification.NewNotificationService(notificationStorage, emailQueue, refreshTokenStorage, apiKeyStorage, auditService, geoLocator, actionKey, cfg.Notifications, cfg.Emails)
+	riskEngine := risk.NewEngine(riskStorage, geoLocator, auditService, cfg.Risk)
+	authService := authservice.NewAuthService(refreshTokenStorage, challengeStorage, rbacStorage, notificationService, riskEngine, geoLocator, rateLimiter, lockoutService, auditService, []byte(cfg.Auth.JWTPrivateKey), cfg.Auth.AccessTokenDuration, cfg.Auth.RefreshTokenDuration, cfg.Auth.StepUp)
+	rbacService := rbac.NewRBACService(rbacStorage)
+	oauthService := oauth.NewOAuthService(oauthClientStorage, authorizationCodeStorage, clientAssertionStorage, deviceCodeStorage, userStorage, authService, signingKey, cfg.OAuth)
+	userService := user.NewUserService(userStorage)
+	impersonationService := impersonation.NewImpersonationService(impersonationStorage, userStorage, authService, emailQueue, cfg.Auth.ImpersonationDuration, cfg.Emails)
+	apiKeyService := apikey.NewAPIKeyService(apiKeyStorage, oauthClientStorage, authService, cfg.APIKeys)
+
+	accessTokenKeys := authmw.SharedSecret([]byte(cfg.Auth.JWTPrivateKey))
+	authenticateUser := authmw.New(authmw.Options{
+		Keys:             accessTokenKeys,
+		TokenFromRequest: authmw.BearerTokenOrCookie(cfg.OAuth.SessionCookie),
+		Optional:         true,
+	})
+	// tokens of clients, other audiences, impersonations and API keys may
+	// carry the admin scope of their user, but must not administer
+	authenticateAdmin := authmw.New(authmw.Options{
+		Keys:           accessTokenKeys,
+		RequiredScopes: []string{rbac.AdminPermission},
+		FirstParty:     true,
+	})
+
+	authController := authcontroller.NewAuthController(authService, authenticateUser)
+	oauthController := oauthcontroller.NewOAuthController(oauthService, authenticateUser)
+	rbacController := rbaccontroller.NewRBACController(rbacService)
+	oauthClientsController := oauthcontroller.NewClientsController(oauthService)
+	userController := usercontroller.NewUserController(userService)
+	impersonationController := impersonationcontroller.NewImpersonationController(impersonationService)
+	apiKeyController := apikeycontroller.NewAPIKeyController(apiKeyService, authenticateUser)
+	apiKeyAdminController := apikeycontroller.NewAdminController(apiKeyService)
+	lockoutController := lockoutcontroller.NewLockoutController(lockoutService)
+	notificationController := notificationcontroller.NewNotificationController(notificationService, authenticateUser)
+	lockoutAdminController := lockoutcontroller.NewAdminController(lockoutService)
+	auditController := auditcontroller.NewAuditController(auditService)
+	webhookController := webhookcontroller.NewWebhookController(webhookService)
+	emailController := emailcontroller.NewEmailController(emailQueue)
 
 	router := newRouter()
-	authController.RegisterRoutes(router)
+	router.Group(func(r chi.Router) {
+		r.Use(httputils.LimitByIP(rateLimiter))
+		authController.RegisterRoutes(r)
+		apiKeyController.RegisterRoutes(r)
+		lockoutController.RegisterRoutes(r)
+		notificationController.RegisterRoutes(r)
+		oauthController.RegisterRoutes(r)
+	})
+	if mailbox, ok := emailSender.(*email.CaptureSender); ok {
+		mailboxcontroller.NewMailboxController(mailbox).RegisterRoutes(router)
+	}
+	router.Route("/admin", func(r chi.Router) {
+		r.Use(authenticateAdmin)
+		rbacController.RegisterRoutes(r)
+		oauthClientsController.RegisterRoutes(r)
+		userController.RegisterRoutes(r)
+		impersonationController.RegisterRoutes(r)
+		apiKeyAdminController.RegisterRoutes(r)
+		lockoutAdminController.RegisterRoutes(r)
+		auditController.RegisterRoutes(r)
+		webhookController.RegisterRoutes(r)
+		emailController.RegisterRoutes(r)
+	})
 
 	slog.Info("starting server", slog.String("address", cfg.HTTPServer.Address))
 
@@ -58,9 +196,32 @@ func Run(cfg *config.Config) error {
 
 	slog.Info("server started")
 
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(4)
+	go func() {
+		defer workers.Done()
+		webhookService.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		emailQueue.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		notificationService.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		geoLocator.Run(workersCtx)
+	}()
+
 	<-done
 	slog.Info("stopping server")
 
+	stopWorkers()
+	workers.Wait()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -73,6 +234,66 @@ func Run(cfg *config.Config) error {
 	return nil
 }
 
+// newSigningKey loads the ID token signing key. Local environments without a
+// configured key get a random one, which invalidates ID tokens on restart.
+func newSigningKey(cfg *config.Config) (*oauth.SigningKey, error) {
+	if cfg.OAuth.SigningKey != "" {
+		return oauth.NewSigningKey([]byte(cfg.OAuth.SigningKey))
+	}
+	if cfg.Env != config.EnvLocal {
+		return nil, errors.New("OAUTH_SIGNING_KEY is not set")
+	}
+
+	slog.Warn("OAUTH_SIGNING_KEY is not set, generating a temporary signing key")
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, errors.Wrap(err, "generate rsa key")
+	}
+	return oauth.NewSigningKeyFromRSA(privateKey)
+}
+
+// newRateLimitStore returns the backend of the rate limits. Limits kept in
+// memory are per instance.
+func newRateLimitStore(cfg *config.Config, db *sqlx.DB) (ratelimit.Store, error) {
+	switch cfg.RateLimits.Backend {
+	case "memory":
+		return ratelimit.NewMemoryStore(), nil
+	case "postgres":
+		return storages.NewRateLimitStorage(db), nil
+	default:
+		return nil, errors.Errorf("unknown rate limit backend %q", cfg.RateLimits.Backend)
+	}
+}
+
+// newEmailSender returns the provider that delivers the emails.
+func newEmailSender(cfg *config.Config) (email.Sender, error) {
+	switch cfg.Mail.Provider {
+	case "smtp":
+		return email.NewSMTPSender(cfg.Mail.SMTP)
+	case "http":
+		return email.NewHTTPSender(cfg.Mail.HTTP)
+	case "file":
+		return email.NewFileSender(cfg.Mail.File.Dir)
+	case "noop":
+		return email.NewNoopSender(), nil
+	case "capture":
+		if cfg.Env != config.EnvLocal {
+			return nil, errors.New("the capture mail provider is only available in the local environment")
+		}
+		return email.NewCaptureSender(), nil
+	default:
+		return nil, errors.Errorf("unknown mail provider %q", cfg.Mail.Provider)
+	}
+}
+
+// newEmailSigner returns the DKIM signer, nil when DKIM is not configured.
+func newEmailSigner(cfg *config.Config) (email.Signer, error) {
+	if cfg.Emails.DKIM.Selector == "" {
+		return nil, nil
+	}
+	return email.NewDKIMSigner(cfg.Emails.DKIM)
+}
+
 func newRouter() chi.Router {
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,18 +17,186 @@ const (
 )
 
 type Config struct {
-	Env        string     `yaml:"env" env-default:"dev"`
-	DB         DB         `yaml:"db"`
-	HTTPServer HTTPServer `yaml:"http_server"`
-	SMTP       SMTP       `yaml:"smtp"`
-	Emails     Emails     `yaml:"emails"`
-	Auth       Auth       `yaml:"auth"`
+	Env           string        `yaml:"env" env-default:"dev"`
+	DB            DB            `yaml:"db"`
+	HTTPServer    HTTPServer    `yaml:"http_server"`
+	Mail          Mail          `yaml:"mail"`
+	Emails        Emails        `yaml:"emails"`
+	Auth          Auth          `yaml:"auth"`
+	OAuth         OAuth         `yaml:"oauth"`
+	APIKeys       APIKeys       `yaml:"api_keys"`
+	RateLimits    RateLimits    `yaml:"rate_limits"`
+	Lockout       Lockout       `yaml:"lockout"`
+	Webhooks      Webhooks      `yaml:"webhooks"`
+	EmailQueue    EmailQueue    `yaml:"email_queue"`
+	Notifications Notifications `yaml:"notifications"`
+	Risk          Risk          `yaml:"risk"`
+	GeoIP         GeoIP         `yaml:"geoip"`
 }
 
 type Auth struct {
 	AccessTokenDuration  time.Duration `yaml:"access_token_duration" env-required:"true"`
 	RefreshTokenDuration time.Duration `yaml:"refresh_token_duration" env-required:"true"`
-	JWTPrivateKey        string
+	// ImpersonationDuration is the lifetime of the sessions admins open as
+	// another user.
+	ImpersonationDuration time.Duration `yaml:"impersonation_duration" env-default:"15m"`
+	StepUp                StepUp        `yaml:"step_up"`
+	JWTPrivateKey         string
+}
+
+// StepUp holds refreshes from networks the user has not signed in from, and
+// the sessions the risk engine challenges, until the user enters the code
+// emailed to them. A code is valid for CodeDuration
+// and MaxAttempts tries.
+type StepUp struct {
+	Enabled      bool          `yaml:"enabled"`
+	CodeDuration time.Duration `yaml:"code_duration" env-default:"10m"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"5"`
+}
+
+type OAuth struct {
+	AuthorizationCodeDuration time.Duration `yaml:"authorization_code_duration" env-default:"1m"`
+	// SessionCookie is the cookie that carries the access token of a first
+	// party session to the authorization endpoint.
+	SessionCookie string `yaml:"session_cookie" env-default:"access_token"`
+	// Issuer is the public base URL of the service. Client assertions must be
+	// addressed to it or to its token endpoint.
+	Issuer          string        `yaml:"issuer" env-required:"true"`
+	IDTokenDuration time.Duration `yaml:"id_token_duration" env-default:"1h"`
+	// DeviceVerificationURI is the page where users enter the user code of
+	// the device flow. It defaults to the device endpoint of the issuer.
+	DeviceVerificationURI string        `yaml:"device_verification_uri"`
+	DeviceCodeDuration    time.Duration `yaml:"device_code_duration" env-default:"10m"`
+	DevicePollInterval    time.Duration `yaml:"device_poll_interval" env-default:"5s"`
+	// SigningKey is the PEM encoded RSA private key that signs ID tokens.
+	SigningKey string
+}
+
+type APIKeys struct {
+	// MaxDuration bounds the expiry of new keys and is the default one.
+	MaxDuration time.Duration `yaml:"max_duration" env-default:"8760h"`
+	// TokenDuration is the lifetime of the access tokens keys are exchanged
+	// for.
+	TokenDuration time.Duration `yaml:"token_duration" env-default:"15m"`
+}
+
+type RateLimits struct {
+	// Backend is "memory" for a single instance or "postgres" to share the
+	// limits between the instances of a cluster.
+	Backend         string    `yaml:"backend" env-default:"memory"`
+	PerIP           RateLimit `yaml:"per_ip"`
+	PerUser         RateLimit `yaml:"per_user"`
+	PerRefreshToken RateLimit `yaml:"per_refresh_token"`
+}
+
+// RateLimit allows bursts of Burst requests and one more request every
+// Interval. A zero Burst disables the limit.
+type RateLimit struct {
+	Burst    int           `yaml:"burst"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Lockout delays authentication after each failure of an account, doubling
+// from BaseDelay up to MaxDelay, and locks the account for Duration after
+// Threshold consecutive failures.
+type Lockout struct {
+	Threshold int           `yaml:"threshold" env-default:"10"`
+	BaseDelay time.Duration `yaml:"base_delay" env-default:"1s"`
+	MaxDelay  time.Duration `yaml:"max_delay" env-default:"5m"`
+	Duration  time.Duration `yaml:"duration" env-default:"1h"`
+	// UnlockURL is the link of the unlock email. The unlock token is added
+	// as the "token" query parameter.
+	UnlockURL string `yaml:"unlock_url" env-required:"true"`
+}
+
+// Webhooks deliver the events of the outbox. Failed deliveries are retried
+// with a backoff that doubles from BaseBackoff up to MaxBackoff, and are dead
+// after MaxAttempts.
+type Webhooks struct {
+	PollInterval time.Duration     `yaml:"poll_interval" env-default:"5s"`
+	Timeout      time.Duration     `yaml:"timeout" env-default:"10s"`
+	MaxAttempts  int               `yaml:"max_attempts" env-default:"10"`
+	BaseBackoff  time.Duration     `yaml:"base_backoff" env-default:"30s"`
+	MaxBackoff   time.Duration     `yaml:"max_backoff" env-default:"6h"`
+	Endpoints    []WebhookEndpoint `yaml:"endpoints"`
+}
+
+type WebhookEndpoint struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Events are the event types the endpoint receives, all when empty.
+	Events []string `yaml:"events"`
+	// Secret signs the deliveries. It is read from the WEBHOOK secret,
+	// field <NAME>_SECRET.
+	Secret string
+}
+
+// EmailQueue sends the queued emails with Workers concurrent workers. Failed
+// emails are retried with a backoff that doubles from BaseBackoff up to
+// MaxBackoff, and are dead after MaxAttempts. Lease bounds the time a worker
+// may take to send an email before another worker picks it up.
+type EmailQueue struct {
+	Workers      int           `yaml:"workers" env-default:"4"`
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"5s"`
+	Lease        time.Duration `yaml:"lease" env-default:"5m"`
+	MaxAttempts  int           `yaml:"max_attempts" env-default:"8"`
+	BaseBackoff  time.Duration `yaml:"base_backoff" env-default:"30s"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" env-default:"1h"`
+}
+
+// Notifications alert users of sign-ins from networks they have not used
+// before, an IPv4 /IPv4Prefix or IPv6 /IPv6Prefix network around the address.
+// The alerts of users in digest mode are sent together once the oldest one is
+// DigestInterval old.
+type Notifications struct {
+	IPv4Prefix     int           `yaml:"ipv4_prefix" env-default:"24"`
+	IPv6Prefix     int           `yaml:"ipv6_prefix" env-default:"48"`
+	DigestInterval time.Duration `yaml:"digest_interval" env-default:"24h"`
+	PollInterval   time.Duration `yaml:"poll_interval" env-default:"1m"`
+	// ActionURL is the "This wasn't me" link of the alerts, valid for
+	// ActionDuration. The action token is added as the "token" query
+	// parameter.
+	ActionURL      string        `yaml:"action_url" env-required:"true"`
+	ActionDuration time.Duration `yaml:"action_duration" env-default:"168h"`
+}
+
+// Risk scores session creations and refreshes by the weights of the signals
+// that apply. Scores of at least NotifyScore alert the user, ChallengeScore
+// require the step-up code and DenyScore refuse the session. Disabled, only a
+// change of IP address is considered.
+type Risk struct {
+	Enabled        bool        `yaml:"enabled"`
+	NotifyScore    int         `yaml:"notify_score" env-default:"20"`
+	ChallengeScore int         `yaml:"challenge_score" env-default:"50"`
+	DenyScore      int         `yaml:"deny_score" env-default:"90"`
+	Weights        RiskWeights `yaml:"weights"`
+	IPv4Prefix     int         `yaml:"ipv4_prefix" env-default:"24"`
+	IPv6Prefix     int         `yaml:"ipv6_prefix" env-default:"48"`
+	// MaxTravelSpeed in km/h is the speed beyond which two locations count as
+	// impossible travel.
+	MaxTravelSpeed float64 `yaml:"max_travel_speed" env-default:"1000"`
+	// More than VelocityLimit requests of a user within VelocityWindow count
+	// as velocity.
+	VelocityLimit  int           `yaml:"velocity_limit" env-default:"10"`
+	VelocityWindow time.Duration `yaml:"velocity_window" env-default:"10m"`
+}
+
+type RiskWeights struct {
+	IPChange         int `yaml:"ip_change" env-default:"10"`
+	NetworkChange    int `yaml:"network_change" env-default:"10"`
+	ASNChange        int `yaml:"asn_change" env-default:"20"`
+	UserAgentChange  int `yaml:"user_agent_change" env-default:"20"`
+	ImpossibleTravel int `yaml:"impossible_travel" env-default:"60"`
+	Velocity         int `yaml:"velocity" env-default:"40"`
+	UnknownDevice    int `yaml:"unknown_device" env-default:"20"`
+}
+
+// GeoIP resolves addresses offline with MaxMind-format databases, e.g.
+// GeoLite2-City and GeoLite2-ASN. Files that change are read again, checked
+// every ReloadInterval. Without databases, locations are unknown.
+type GeoIP struct {
+	Databases      []string      `yaml:"databases" env:"GEOIP_DATABASES" env-separator:","`
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"1m"`
 }
 
 type HTTPServer struct {
@@ -44,15 +213,68 @@ type DB struct {
 	Password string
 }
 
+// Mail selects the provider that delivers the emails: "smtp", "http",
+// "file", "noop" or "capture". Only the section of the selected provider is
+// used. "capture" keeps the emails for the development mailbox and is only
+// available in the local environment.
+type Mail struct {
+	Provider string   `yaml:"provider" env-default:"smtp"`
+	SMTP     SMTP     `yaml:"smtp"`
+	HTTP     HTTPMail `yaml:"http"`
+	File     FileMail `yaml:"file"`
+}
+
+// SMTP is the mail server. TLSMode is "starttls", "implicit" (SMTPS) or
+// "none". SendTimeout bounds the handshake of a connection and the delivery
+// of each message, up to MaxIdleConns connections are kept open for
+// IdleTimeout to reuse them.
 type SMTP struct {
-	Host     string `yaml:"host" env-required:"true"`
-	Port     string `yaml:"port" env-required:"true"`
-	UserName string
-	Password string
+	Host         string        `yaml:"host"`
+	Port         string        `yaml:"port"`
+	TLSMode      string        `yaml:"tls_mode" env-default:"starttls"`
+	DialTimeout  time.Duration `yaml:"dial_timeout" env-default:"10s"`
+	SendTimeout  time.Duration `yaml:"send_timeout" env-default:"30s"`
+	MaxIdleConns int           `yaml:"max_idle_conns" env-default:"2"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" env-default:"1m"`
+	UserName     string
+	Password     string
+}
+
+// HTTPMail is a JSON email API in the style of SendGrid or Mailgun. The API
+// key is sent as a bearer token, it is read from the MAIL secret.
+type HTTPMail struct {
+	URL     string        `yaml:"url"`
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+	APIKey  string
+}
+
+// FileMail writes the emails to the maildir Dir instead of sending them.
+type FileMail struct {
+	Dir string `yaml:"dir" env-default:"mail"`
 }
 
 type Emails struct {
 	SupportEmail string `yaml:"support_email" env-required:"true"`
+	// DefaultLocale is used for users whose locale has no templates.
+	DefaultLocale string   `yaml:"default_locale" env-default:"ru"`
+	Branding      Branding `yaml:"branding"`
+	DKIM          DKIM     `yaml:"dkim"`
+}
+
+// DKIM signs the outgoing emails when Selector is set. PrivateKey is a PEM
+// RSA or Ed25519 key, read from the DKIM secret.
+type DKIM struct {
+	Domain     string `yaml:"domain"`
+	Selector   string `yaml:"selector"`
+	PrivateKey string
+}
+
+// Branding is available to the email templates as .Brand.
+type Branding struct {
+	ProductName  string `yaml:"product_name" env-default:"Company"`
+	SupportURL   string `yaml:"support_url"`
+	LogoURL      string `yaml:"logo_url"`
+	PrimaryColor string `yaml:"primary_color" env-default:"#1a73e8"`
 }
 
 var (
@@ -84,8 +306,20 @@ func MustNew() *Config {
 		cfg.Auth.JWTPrivateKey = secretManager.MustGetSecretField("JWT", "PRIVATE_KEY")
 		cfg.DB.UserName = secretManager.MustGetSecretField("DB", "USER")
 		cfg.DB.Password = secretManager.MustGetSecretField("DB", "PASSWORD")
-		cfg.SMTP.UserName = secretManager.MustGetSecretField("SMTP", "USERNAME")
-		cfg.SMTP.Password = secretManager.MustGetSecretField("SMTP", "PASSWORD")
+		switch cfg.Mail.Provider {
+		case "smtp":
+			cfg.Mail.SMTP.UserName = secretManager.MustGetSecretField("SMTP", "USERNAME")
+			cfg.Mail.SMTP.Password = secretManager.MustGetSecretField("SMTP", "PASSWORD")
+		case "http":
+			cfg.Mail.HTTP.APIKey = secretManager.MustGetSecretField("MAIL", "API_KEY")
+		}
+		cfg.OAuth.SigningKey = secretManager.MustGetSecretField("OAUTH", "SIGNING_KEY")
+		if cfg.Emails.DKIM.Selector != "" {
+			cfg.Emails.DKIM.PrivateKey = secretManager.MustGetSecretField("DKIM", "PRIVATE_KEY")
+		}
+		for i, endpoint := range cfg.Webhooks.Endpoints {
+			cfg.Webhooks.Endpoints[i].Secret = secretManager.MustGetSecretField("WEBHOOK", strings.ToUpper(endpoint.Name)+"_SECRET")
+		}
 	})
 
 	return &cfg
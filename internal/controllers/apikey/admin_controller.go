@@ -0,0 +1,93 @@
+package apikeycontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/apikey"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &AdminController{}
+
+// AdminController manages the keys of all users and clients. Its routes are
+// relative to the admin router.
+type AdminController struct {
+	apiKeyService APIKeyService
+}
+
+func NewAdminController(apiKeyService APIKeyService) *AdminController {
+	return &AdminController{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (c *AdminController) createKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateClientAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	resp, err := c.apiKeyService.CreateKey(apikey.CreateRequest{
+		Name:      req.Name,
+		UserID:    req.UserID,
+		ClientID:  req.ClientID,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeCreated(w, r, resp)
+}
+
+func (c *AdminController) listKeys(w http.ResponseWriter, r *http.Request) {
+	filter := apikey.Filter{ClientID: r.URL.Query().Get("clientId")}
+	if userIDStr := r.URL.Query().Get("userId"); userIDStr != "" {
+		var err error
+		filter.UserID, err = uuid.Parse(userIDStr)
+		if err != nil {
+			httputils.BadRequest(w, r, errors.Wrap(err, "parse userId"))
+			return
+		}
+	}
+
+	keys, err := c.apiKeyService.ListKeys(filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, newAPIKeys(keys))
+}
+
+func (c *AdminController) revokeKey(w http.ResponseWriter, r *http.Request) {
+	keyID, err := uuid.Parse(chi.URLParam(r, "keyID"))
+	if err != nil {
+		httputils.BadRequest(w, r, errors.Wrap(err, "parse key id"))
+		return
+	}
+
+	if err := c.apiKeyService.RevokeKey(keyID, uuid.Nil); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *AdminController) RegisterRoutes(router chi.Router) {
+	router.Route("/api-keys", func(r chi.Router) {
+		r.Get("/", c.listKeys)
+		r.Post("/", c.createKey)
+		r.Delete("/{keyID}", c.revokeKey)
+	})
+}
@@ -0,0 +1,183 @@
+package apikeycontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/apikey"
+	logutils "auth/internal/utils/log"
+	"auth/pkg/authmw"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &APIKeyController{}
+
+// APIKeyController lets users manage their own API keys and exchanges keys for
+// access tokens.
+type APIKeyController struct {
+	apiKeyService APIKeyService
+	authenticate  func(http.Handler) http.Handler
+}
+
+type APIKeyService interface {
+	CreateKey(req apikey.CreateRequest) (*apikey.CreateResponse, error)
+	ListKeys(filter apikey.Filter) ([]apikey.APIKey, error)
+	RevokeKey(id uuid.UUID, userID uuid.UUID) error
+	Exchange(key, requestIP string) (*apikey.Token, error)
+}
+
+// NewAPIKeyController takes the middleware that authenticates user sessions.
+func NewAPIKeyController(apiKeyService APIKeyService, authenticate func(http.Handler) http.Handler) *APIKeyController {
+	return &APIKeyController{
+		apiKeyService: apiKeyService,
+		authenticate:  authenticate,
+	}
+}
+
+func (c *APIKeyController) token(w http.ResponseWriter, r *http.Request) {
+	var req TokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	token, err := c.apiKeyService.Exchange(req.APIKey, httputils.RequestIP(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, TokenResponse{
+		AccessToken: token.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(token.ExpiresIn.Seconds()),
+	})
+}
+
+func (c *APIKeyController) createKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+	claims, _ := authmw.FromContext(r.Context())
+	// never nil, so that a session without scopes grants none
+	sessionScopes := append([]string{}, claims.Scopes...)
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	resp, err := c.apiKeyService.CreateKey(apikey.CreateRequest{
+		Name:          req.Name,
+		UserID:        userID,
+		Scopes:        req.Scopes,
+		ExpiresAt:     req.ExpiresAt,
+		SessionScopes: sessionScopes,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeCreated(w, r, resp)
+}
+
+func (c *APIKeyController) listKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := c.apiKeyService.ListKeys(apikey.Filter{UserID: userID})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, newAPIKeys(keys))
+}
+
+func (c *APIKeyController) revokeKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+	keyID, err := uuid.Parse(chi.URLParam(r, "keyID"))
+	if err != nil {
+		httputils.BadRequest(w, r, errors.Wrap(err, "parse key id"))
+		return
+	}
+
+	if err := c.apiKeyService.RevokeKey(keyID, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *APIKeyController) RegisterRoutes(router chi.Router) {
+	router.Route("/api-keys", func(r chi.Router) {
+		r.Post("/token", c.token)
+		r.Group(func(r chi.Router) {
+			r.Use(c.authenticate)
+			r.Get("/", c.listKeys)
+			r.Post("/", c.createKey)
+			r.Delete("/{keyID}", c.revokeKey)
+		})
+	})
+}
+
+// sessionUserID returns the user of a first-party session. Keys cannot be
+// managed with tokens of OAuth clients, of impersonation sessions or of other
+// keys, which could otherwise outlive their own expiry through a key.
+func sessionUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	claims, ok := authmw.FromContext(r.Context())
+	if !ok {
+		authmw.DefaultErrorHandler(w, r, authmw.ErrMissingToken)
+		return uuid.Nil, false
+	}
+	if !claims.FirstParty() {
+		httputils.Error(w, r, http.StatusForbidden, errors.New("api keys require a user's own session"))
+		return uuid.Nil, false
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		authmw.DefaultErrorHandler(w, r, authmw.ErrInvalidToken)
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func writeCreated(w http.ResponseWriter, r *http.Request, resp *apikey.CreateResponse) {
+	w.Header().Set("Cache-Control", "no-store")
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, CreateAPIKeyResponse{
+		APIKey: newAPIKey(resp.APIKey),
+		Key:    resp.Key,
+	})
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var notFoundErr apikey.NotFoundError
+	var validationErr apikey.ValidationError
+	var unauthorizedErr apikey.UnauthorizedError
+	switch {
+	case errors.As(err, &notFoundErr):
+		httputils.NotFound(w, r, notFoundErr)
+	case errors.As(err, &validationErr):
+		httputils.BadRequest(w, r, validationErr)
+	case errors.As(err, &unauthorizedErr):
+		httputils.UnauthorizedError(w, r, unauthorizedErr)
+	default:
+		logutils.Error("api key request error", err)
+		httputils.InternalError(w, r)
+	}
+}
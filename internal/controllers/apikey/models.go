@@ -0,0 +1,81 @@
+package apikeycontroller
+
+import (
+	"auth/internal/services/apikey"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CreateClientAPIKeyRequest is the admin request. Exactly one of UserID and
+// ClientID is set.
+type CreateClientAPIKeyRequest struct {
+	CreateAPIKeyRequest
+	UserID   uuid.UUID `json:"userId"`
+	ClientID string    `json:"clientId"`
+}
+
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Prefix     string     `json:"prefix"`
+	Name       string     `json:"name"`
+	UserID     *uuid.UUID `json:"userId,omitempty"`
+	ClientID   string     `json:"clientId,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreateAPIKeyResponse is the only response that contains the key.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type TokenRequest struct {
+	APIKey string `json:"apiKey"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"accessToken"`
+	TokenType   string `json:"tokenType"`
+	ExpiresIn   int    `json:"expiresIn"`
+}
+
+func newAPIKey(key apikey.APIKey) APIKey {
+	resp := APIKey{
+		ID:        key.ID,
+		Prefix:    key.Prefix,
+		Name:      key.Name,
+		ClientID:  key.ClientID,
+		Scopes:    key.Scopes,
+		ExpiresAt: key.ExpiresAt,
+		CreatedAt: key.CreatedAt,
+	}
+	if key.UserID != uuid.Nil {
+		resp.UserID = &key.UserID
+	}
+	if !key.LastUsedAt.IsZero() {
+		resp.LastUsedAt = &key.LastUsedAt
+	}
+	if !key.RevokedAt.IsZero() {
+		resp.RevokedAt = &key.RevokedAt
+	}
+	return resp
+}
+
+func newAPIKeys(keys []apikey.APIKey) []APIKey {
+	resp := make([]APIKey, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, newAPIKey(key))
+	}
+	return resp
+}
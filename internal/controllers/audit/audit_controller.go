@@ -0,0 +1,121 @@
+package auditcontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/audit"
+	logutils "auth/internal/utils/log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &AuditController{}
+
+// AuditController serves the audit log in the admin API. Its routes are
+// relative to the admin router.
+type AuditController struct {
+	auditService AuditService
+}
+
+type AuditService interface {
+	ListEvents(filter audit.Filter) ([]audit.Event, error)
+	Verify() (*audit.Verification, error)
+}
+
+func NewAuditController(auditService AuditService) *AuditController {
+	return &AuditController{
+		auditService: auditService,
+	}
+}
+
+// listEvents filters by userId, type (repeatable), from and to (RFC 3339).
+// Pages continue after the ID of the last event with after.
+func (c *AuditController) listEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	events, err := c.auditService.ListEvents(filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]Event, 0, len(events))
+	for _, event := range events {
+		resp = append(resp, newEvent(event))
+	}
+	render.JSON(w, r, resp)
+}
+
+func (c *AuditController) verify(w http.ResponseWriter, r *http.Request) {
+	verification, err := c.auditService.Verify()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, Verification{
+		Valid:    verification.BrokenAt == 0,
+		Checked:  verification.Checked,
+		BrokenAt: verification.BrokenAt,
+	})
+}
+
+func (c *AuditController) RegisterRoutes(router chi.Router) {
+	router.Route("/audit-events", func(r chi.Router) {
+		r.Get("/", c.listEvents)
+		r.Get("/verify", c.verify)
+	})
+}
+
+func parseFilter(r *http.Request) (audit.Filter, error) {
+	query := r.URL.Query()
+	filter := audit.Filter{Types: query["type"]}
+	var err error
+	if userID := query.Get("userId"); userID != "" {
+		if filter.UserID, err = uuid.Parse(userID); err != nil {
+			return filter, errors.Wrap(err, "parse userId")
+		}
+	}
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return filter, errors.Wrap(err, "parse from")
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return filter, errors.Wrap(err, "parse to")
+		}
+	}
+	if after := query.Get("after"); after != "" {
+		if filter.AfterID, err = strconv.ParseInt(after, 10, 64); err != nil {
+			return filter, errors.Wrap(err, "parse after")
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			return filter, errors.Wrap(err, "parse limit")
+		}
+	}
+	return filter, nil
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr audit.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		httputils.BadRequest(w, r, validationErr)
+	default:
+		logutils.Error("audit request error", err)
+		httputils.InternalError(w, r)
+	}
+}
@@ -0,0 +1,42 @@
+package auditcontroller
+
+import (
+	"auth/internal/services/audit"
+	"auth/internal/services/geoip"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Event struct {
+	ID        int64             `json:"id"`
+	Type      string            `json:"type"`
+	UserID    uuid.UUID         `json:"userId"`
+	IP        string            `json:"ip"`
+	Location  *geoip.Location   `json:"location,omitempty"`
+	Details   map[string]string `json:"details"`
+	CreatedAt time.Time         `json:"createdAt"`
+	PrevHash  string            `json:"prevHash"`
+	Hash      string            `json:"hash"`
+}
+
+type Verification struct {
+	Valid    bool  `json:"valid"`
+	Checked  int   `json:"checked"`
+	BrokenAt int64 `json:"brokenAt,omitempty"`
+}
+
+func newEvent(event audit.Event) Event {
+	return Event{
+		ID:        event.ID,
+		Type:      event.Type,
+		UserID:    event.UserID,
+		IP:        event.IP,
+		Location:  event.Location,
+		Details:   event.Details,
+		CreatedAt: event.CreatedAt,
+		PrevHash:  hex.EncodeToString(event.PrevHash),
+		Hash:      hex.EncodeToString(event.Hash),
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"auth/internal/controllers"
 	"auth/internal/controllers/httputils"
 	"auth/internal/services/auth"
+	"auth/internal/services/lockout"
+	"auth/internal/services/ratelimit"
+	logutils "auth/internal/utils/log"
+	"auth/pkg/authmw"
 	"encoding/base64"
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -18,17 +23,24 @@ import (
 var _ controllers.Controller = &AuthController{}
 
 type AuthController struct {
-	authService AuthService
+	authService  AuthService
+	authenticate func(http.Handler) http.Handler
 }
 
 type AuthService interface {
-	CreateAccessAndRefreshTokens(userID uuid.UUID, requestIP string) (string, string, error)
-	RefreshAccessToken(accessToken, refreshToken, requestIP string) (string, string, error)
+	CreateSession(userID uuid.UUID, requestIP, userAgent string, opts ...auth.TokenOption) (string, string, error)
+	RefreshAccessToken(accessToken, refreshToken, requestIP, userAgent string) (string, string, error)
+	ConfirmRefresh(challengeID uuid.UUID, code, requestIP, userAgent string) (string, string, error)
+	RevokeSession(accessToken, requestIP string) error
+	ListSessions(userID uuid.UUID) ([]auth.RefreshToken, error)
 }
 
-func NewAuthController(authService AuthService) *AuthController {
+// NewAuthController takes the middleware that authenticates user sessions,
+// for the routes that act on the sessions of the caller.
+func NewAuthController(authService AuthService, authenticate func(http.Handler) http.Handler) *AuthController {
 	return &AuthController{
-		authService: authService,
+		authService:  authService,
+		authenticate: authenticate,
 	}
 }
 
@@ -39,15 +51,20 @@ func (c *AuthController) createSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, refreshToken, err := c.authService.CreateAccessAndRefreshTokens(
+	accessToken, refreshToken, err := c.authService.CreateSession(
 		userID,
-		httputils.RequestIP(r))
-	refreshTokenBase64 := base64.StdEncoding.EncodeToString([]byte(refreshToken))
+		httputils.RequestIP(r),
+		r.UserAgent(),
+		auth.WithAcceptLanguage(r.Header.Get("Accept-Language")))
+	if writeRiskError(w, r, err) {
+		return
+	}
 	if err != nil {
-		slog.Error(errors.Wrap(err, "create access and refresh tokens").Error())
+		slog.Error(errors.Wrap(err, "create session").Error())
 		httputils.InternalError(w, r)
 		return
 	}
+	refreshTokenBase64 := base64.StdEncoding.EncodeToString([]byte(refreshToken))
 
 	render.JSON(w, r, Session{
 		AccessToken:  accessToken,
@@ -70,13 +87,69 @@ func (c *AuthController) refreshSession(w http.ResponseWriter, r *http.Request)
 	accessToken, refreshToken, err := c.authService.RefreshAccessToken(
 		session.AccessToken,
 		string(refreshTokenDecoded),
-		httputils.RequestIP(r))
+		httputils.RequestIP(r),
+		r.UserAgent())
+	if writeRiskError(w, r, err) {
+		return
+	}
+	switch typedErr := err.(type) {
+	case nil:
+	case auth.UnauthorizedError:
+		httputils.UnauthorizedError(w, r, err)
+		return
+	case ratelimit.ExceededError:
+		httputils.TooManyRequests(w, r, typedErr.RetryAfter)
+		return
+	case lockout.LockedError:
+		httputils.TooManyRequests(w, r, time.Until(typedErr.RetryAt))
+		return
+	default:
+		httputils.InternalError(w, r)
+		return
+	}
+
+	refreshTokenBase64 := base64.StdEncoding.EncodeToString([]byte(refreshToken))
+	render.JSON(w, r, Session{
+		AccessToken:  accessToken,
+		RefreshToken: refreshTokenBase64,
+	})
+}
+
+// writeRiskError answers the challenges and denials of the risk engine and
+// returns whether it did.
+func writeRiskError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch typedErr := err.(type) {
+	case auth.ChallengeRequiredError:
+		render.Status(r, http.StatusAccepted)
+		render.JSON(w, r, Challenge{
+			ChallengeID: typedErr.ChallengeID,
+			ExpiresAt:   typedErr.ExpiresAt,
+		})
+		return true
+	case auth.RiskDeniedError:
+		httputils.Error(w, r, http.StatusForbidden, err)
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *AuthController) confirmRefresh(w http.ResponseWriter, r *http.Request) {
+	var req ConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	accessToken, refreshToken, err := c.authService.ConfirmRefresh(req.ChallengeID, req.Code, httputils.RequestIP(r),
+		r.UserAgent())
 	switch err.(type) {
 	case nil:
 	case auth.UnauthorizedError:
 		httputils.UnauthorizedError(w, r, err)
 		return
 	default:
+		slog.Error(errors.Wrap(err, "confirm refresh").Error())
 		httputils.InternalError(w, r)
 		return
 	}
@@ -88,9 +161,66 @@ func (c *AuthController) refreshSession(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+func (c *AuthController) revokeSession(w http.ResponseWriter, r *http.Request) {
+	var session Session
+	if err := json.NewDecoder(r.Body).Decode(&session); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	err := c.authService.RevokeSession(session.AccessToken, httputils.RequestIP(r))
+	switch err.(type) {
+	case nil:
+	case auth.UnauthorizedError:
+		httputils.UnauthorizedError(w, r, err)
+		return
+	default:
+		slog.Error(errors.Wrap(err, "revoke session").Error())
+		httputils.InternalError(w, r)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+// listSessions lists the sessions of the user of the access token, marking
+// the one the token belongs to.
+func (c *AuthController) listSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := authmw.FromContext(r.Context())
+	if !ok {
+		authmw.DefaultErrorHandler(w, r, authmw.ErrMissingToken)
+		return
+	}
+	if claims.ClientID != "" {
+		httputils.Error(w, r, http.StatusForbidden, errors.New("sessions require a user's token"))
+		return
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		authmw.DefaultErrorHandler(w, r, authmw.ErrInvalidToken)
+		return
+	}
+
+	sessions, err := c.authService.ListSessions(userID)
+	if err != nil {
+		logutils.Error("list sessions error", err)
+		httputils.InternalError(w, r)
+		return
+	}
+
+	resp := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, newSessionInfo(session, claims.RefreshTokenID))
+	}
+	render.JSON(w, r, resp)
+}
+
 func (c *AuthController) RegisterRoutes(router chi.Router) {
 	router.Route("/session", func(r chi.Router) {
 		r.Get("/", c.createSession)
 		r.Post("/refresh", c.refreshSession)
+		r.Post("/refresh/confirm", c.confirmRefresh)
+		r.Post("/revoke", c.revokeSession)
 	})
+	router.With(c.authenticate).Get("/sessions", c.listSessions)
 }
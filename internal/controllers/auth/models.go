@@ -1,6 +1,52 @@
 package authcontroller
 
+import (
+	"auth/internal/services/auth"
+	"auth/internal/services/geoip"
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type Session struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
 }
+
+// Challenge is returned with 202 when the refresh must be confirmed with the
+// code emailed to the user.
+type Challenge struct {
+	ChallengeID uuid.UUID `json:"challengeId"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+type ConfirmRequest struct {
+	ChallengeID uuid.UUID `json:"challengeId"`
+	Code        string    `json:"code"`
+}
+
+// SessionInfo describes a session of the user without its tokens. Current
+// marks the session of the access token the request was made with.
+type SessionInfo struct {
+	ID        uuid.UUID       `json:"id"`
+	IP        string          `json:"ip"`
+	UserAgent string          `json:"userAgent"`
+	Location  *geoip.Location `json:"location,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	AuthTime  time.Time       `json:"authTime"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+	Current   bool            `json:"current"`
+}
+
+func newSessionInfo(session auth.RefreshToken, currentID string) SessionInfo {
+	return SessionInfo{
+		ID:        session.ID,
+		IP:        session.IP,
+		UserAgent: session.UserAgent,
+		Location:  session.Location,
+		CreatedAt: session.CreatedAt,
+		AuthTime:  session.AuthTime,
+		ExpiresAt: session.ExpiresAt,
+		Current:   session.ID.String() == currentID,
+	}
+}
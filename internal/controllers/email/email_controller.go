@@ -0,0 +1,81 @@
+package emailcontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/email"
+	logutils "auth/internal/utils/log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &EmailController{}
+
+// EmailController lets admins inspect the email queue. Its routes are
+// relative to the admin router.
+type EmailController struct {
+	emailQueue EmailQueue
+}
+
+type EmailQueue interface {
+	List(filter email.Filter) ([]email.Message, error)
+}
+
+func NewEmailController(emailQueue EmailQueue) *EmailController {
+	return &EmailController{
+		emailQueue: emailQueue,
+	}
+}
+
+func (c *EmailController) listMessages(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := email.Filter{
+		Status: query.Get("status"),
+	}
+	if userID := query.Get("userId"); userID != "" {
+		var err error
+		if filter.UserID, err = uuid.Parse(userID); err != nil {
+			httputils.BadRequest(w, r, errors.Wrap(err, "parse user id"))
+			return
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			httputils.BadRequest(w, r, errors.Wrap(err, "parse limit"))
+			return
+		}
+	}
+
+	messages, err := c.emailQueue.List(filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]Message, 0, len(messages))
+	for _, message := range messages {
+		resp = append(resp, newMessage(message))
+	}
+	render.JSON(w, r, resp)
+}
+
+func (c *EmailController) RegisterRoutes(router chi.Router) {
+	router.Get("/emails", c.listMessages)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr email.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		httputils.BadRequest(w, r, validationErr)
+	default:
+		logutils.Error("email request error", err)
+		httputils.InternalError(w, r)
+	}
+}
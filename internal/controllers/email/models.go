@@ -0,0 +1,41 @@
+package emailcontroller
+
+import (
+	"auth/internal/services/email"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message is a queued email without its template data, which may hold
+// one-time links.
+type Message struct {
+	ID            uuid.UUID  `json:"id"`
+	From          string     `json:"from"`
+	UserID        uuid.UUID  `json:"userId"`
+	Template      string     `json:"template"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	LastError     string     `json:"lastError,omitempty"`
+	SentAt        *time.Time `json:"sentAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+func newMessage(message email.Message) Message {
+	resp := Message{
+		ID:            message.ID,
+		From:          message.From,
+		UserID:        message.UserID,
+		Template:      message.Email.Template,
+		Status:        message.Status,
+		Attempts:      message.Attempts,
+		NextAttemptAt: message.NextAttemptAt,
+		LastError:     message.LastError,
+		CreatedAt:     message.CreatedAt,
+	}
+	if !message.SentAt.IsZero() {
+		resp.SentAt = &message.SentAt
+	}
+	return resp
+}
@@ -0,0 +1,32 @@
+package httputils
+
+import (
+	"auth/internal/services/ratelimit"
+	logutils "auth/internal/utils/log"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+type RateLimiter interface {
+	Allow(scope ratelimit.Scope, key string) error
+}
+
+// LimitByIP rejects requests over the per IP limit with 429.
+func LimitByIP(limiter RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := limiter.Allow(ratelimit.ScopeIP, RequestIP(r))
+			var exceededErr ratelimit.ExceededError
+			switch {
+			case err == nil:
+				next.ServeHTTP(w, r)
+			case errors.As(err, &exceededErr):
+				TooManyRequests(w, r, exceededErr.RetryAfter)
+			default:
+				logutils.Error("rate limit error", err)
+				InternalError(w, r)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package httputils
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/render"
 )
@@ -23,7 +25,27 @@ func UnauthorizedError(w http.ResponseWriter, r *http.Request, err error) {
 	Error(w, r, http.StatusUnauthorized, err)
 }
 
+func NotFound(w http.ResponseWriter, r *http.Request, err error) {
+	Error(w, r, http.StatusNotFound, err)
+}
+
+func Conflict(w http.ResponseWriter, r *http.Request, err error) {
+	Error(w, r, http.StatusConflict, err)
+}
+
+func NoContent(w http.ResponseWriter, r *http.Request) {
+	render.NoContent(w, r)
+}
+
 func Error(w http.ResponseWriter, r *http.Request, status int, err error) {
 	render.Status(r, status)
 	render.JSON(w, r, httpError{Message: err.Error()})
 }
+
+// TooManyRequests tells the client to retry after the given duration, rounded
+// up to whole seconds.
+func TooManyRequests(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	Error(w, r, http.StatusTooManyRequests, errors.New("too many requests"))
+}
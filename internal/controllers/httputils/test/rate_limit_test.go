@@ -0,0 +1,37 @@
+package httputils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitByIP(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), config.RateLimits{
+		PerIP: config.RateLimit{Burst: 1, Interval: time.Minute},
+	})
+	handler := httputils.LimitByIP(limiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	assert.Equal(t, http.StatusOK, serve(handler, "10.0.0.1").Code)
+
+	limited := serve(handler, "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "60", limited.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, serve(handler, "10.0.0.2").Code, "limits are per ip")
+}
+
+func serve(handler http.Handler, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/token", nil)
+	req.RemoteAddr = ip + ":51234"
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	return resp
+}
@@ -0,0 +1,115 @@
+package impersonationcontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/impersonation"
+	"auth/internal/services/user"
+	logutils "auth/internal/utils/log"
+	"auth/pkg/authmw"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &ImpersonationController{}
+
+// ImpersonationController serves the impersonation part of the admin API. Its
+// routes are relative to the admin router.
+type ImpersonationController struct {
+	impersonationService ImpersonationService
+}
+
+type ImpersonationService interface {
+	Impersonate(req impersonation.ImpersonateRequest) (*impersonation.Session, error)
+	ListImpersonations(userID uuid.UUID) ([]impersonation.Impersonation, error)
+}
+
+func NewImpersonationController(impersonationService ImpersonationService) *ImpersonationController {
+	return &ImpersonationController{
+		impersonationService: impersonationService,
+	}
+}
+
+func (c *ImpersonationController) impersonate(w http.ResponseWriter, r *http.Request) {
+	claims, _ := authmw.FromContext(r.Context())
+	adminID, err := uuid.Parse(claims.Subject)
+	if err != nil || claims.ClientID != "" || claims.Actor != "" {
+		// the audit trail must name a person, and impersonation sessions
+		// must not open further ones
+		httputils.Error(w, r, http.StatusForbidden, errors.New("impersonation requires an admin's own session"))
+		return
+	}
+	var req ImpersonateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	session, err := c.impersonationService.Impersonate(impersonation.ImpersonateRequest{
+		AdminID:   adminID,
+		UserID:    req.UserID,
+		Reason:    req.Reason,
+		RequestIP: httputils.RequestIP(r),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, Session{
+		AccessToken:   session.AccessToken,
+		Impersonation: newImpersonation(session.Impersonation),
+	})
+}
+
+func (c *ImpersonationController) listImpersonations(w http.ResponseWriter, r *http.Request) {
+	userID := uuid.Nil
+	if userIDStr := r.URL.Query().Get("userId"); userIDStr != "" {
+		var err error
+		userID, err = uuid.Parse(userIDStr)
+		if err != nil {
+			httputils.BadRequest(w, r, errors.Wrap(err, "parse userId"))
+			return
+		}
+	}
+
+	records, err := c.impersonationService.ListImpersonations(userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]Impersonation, 0, len(records))
+	for _, record := range records {
+		resp = append(resp, newImpersonation(record))
+	}
+	render.JSON(w, r, resp)
+}
+
+func (c *ImpersonationController) RegisterRoutes(router chi.Router) {
+	router.Route("/impersonations", func(r chi.Router) {
+		r.Get("/", c.listImpersonations)
+		r.Post("/", c.impersonate)
+	})
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var notFoundErr user.NotFoundError
+	var validationErr impersonation.ValidationError
+	switch {
+	case errors.As(err, &notFoundErr):
+		httputils.NotFound(w, r, notFoundErr)
+	case errors.As(err, &validationErr):
+		httputils.BadRequest(w, r, validationErr)
+	default:
+		logutils.Error("impersonation request error", err)
+		httputils.InternalError(w, r)
+	}
+}
@@ -0,0 +1,41 @@
+package impersonationcontroller
+
+import (
+	"auth/internal/services/impersonation"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ImpersonateRequest struct {
+	UserID uuid.UUID `json:"userId"`
+	Reason string    `json:"reason"`
+}
+
+type Impersonation struct {
+	ID        uuid.UUID `json:"id"`
+	AdminID   uuid.UUID `json:"adminId"`
+	UserID    uuid.UUID `json:"userId"`
+	Reason    string    `json:"reason"`
+	AdminIP   string    `json:"adminIp"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Session is returned once, when the impersonation starts.
+type Session struct {
+	AccessToken   string        `json:"accessToken"`
+	Impersonation Impersonation `json:"impersonation"`
+}
+
+func newImpersonation(record impersonation.Impersonation) Impersonation {
+	return Impersonation{
+		ID:        record.ID,
+		AdminID:   record.AdminID,
+		UserID:    record.UserID,
+		Reason:    record.Reason,
+		AdminIP:   record.AdminIP,
+		CreatedAt: record.CreatedAt,
+		ExpiresAt: record.ExpiresAt,
+	}
+}
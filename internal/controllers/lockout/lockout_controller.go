@@ -0,0 +1,161 @@
+package lockoutcontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/lockout"
+	logutils "auth/internal/utils/log"
+	"html/template"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &LockoutController{}
+
+var confirmTemplate = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"><title>Unlock your account</title></head>
+<body style="font-family: sans-serif;">
+<h1>Unlock your account</h1>
+<form method="post" action="">
+<input type="hidden" name="token" value="{{.}}">
+<button type="submit">Unlock</button>
+</form>
+</body>
+</html>
+`))
+
+var unlockedTemplate = template.Must(template.New("unlocked").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"><title>Account unlocked</title></head>
+<body style="font-family: sans-serif;">
+<h1>Your account is unlocked</h1>
+</body>
+</html>
+`))
+
+// LockoutController serves the unlock link of the lockout email.
+type LockoutController struct {
+	lockoutService LockoutService
+}
+
+type LockoutService interface {
+	Unlock(token string) error
+	UnlockUser(userID uuid.UUID) error
+	ListLocked() ([]lockout.Lockout, error)
+}
+
+func NewLockoutController(lockoutService LockoutService) *LockoutController {
+	return &LockoutController{
+		lockoutService: lockoutService,
+	}
+}
+
+// confirmUnlock shows the unlock link as a form to submit. It does not
+// unlock, since mail scanners and link previews open links too.
+func (c *LockoutController) confirmUnlock(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		httputils.BadRequest(w, r, errors.New("token is required"))
+		return
+	}
+
+	writePage(w, confirmTemplate, token)
+}
+
+// unlock takes the token from the form of confirmUnlock.
+func (c *LockoutController) unlock(w http.ResponseWriter, r *http.Request) {
+	token := r.PostFormValue("token")
+	if token == "" {
+		httputils.BadRequest(w, r, errors.New("token is required"))
+		return
+	}
+
+	if err := c.lockoutService.Unlock(token); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writePage(w, unlockedTemplate, nil)
+}
+
+func (c *LockoutController) RegisterRoutes(router chi.Router) {
+	router.Get("/unlock", c.confirmUnlock)
+	router.Post("/unlock", c.unlock)
+}
+
+// writePage keeps the page, which holds the token, out of caches and
+// referrers.
+func writePage(w http.ResponseWriter, page *template.Template, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	if err := page.Execute(w, data); err != nil {
+		logutils.Error("render page error", err)
+	}
+}
+
+var _ controllers.Controller = &AdminController{}
+
+// AdminController lists and unlocks locked accounts. Its routes are relative
+// to the admin router.
+type AdminController struct {
+	lockoutService LockoutService
+}
+
+func NewAdminController(lockoutService LockoutService) *AdminController {
+	return &AdminController{
+		lockoutService: lockoutService,
+	}
+}
+
+func (c *AdminController) listLocked(w http.ResponseWriter, r *http.Request) {
+	lockouts, err := c.lockoutService.ListLocked()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]Lockout, 0, len(lockouts))
+	for _, record := range lockouts {
+		resp = append(resp, newLockout(record))
+	}
+	render.JSON(w, r, resp)
+}
+
+func (c *AdminController) unlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		httputils.BadRequest(w, r, errors.Wrap(err, "parse user id"))
+		return
+	}
+
+	if err := c.lockoutService.UnlockUser(userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *AdminController) RegisterRoutes(router chi.Router) {
+	router.Route("/lockouts", func(r chi.Router) {
+		r.Get("/", c.listLocked)
+		r.Delete("/{userID}", c.unlockUser)
+	})
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var notFoundErr lockout.NotFoundError
+	switch {
+	case errors.As(err, &notFoundErr):
+		httputils.NotFound(w, r, notFoundErr)
+	default:
+		logutils.Error("lockout request error", err)
+		httputils.InternalError(w, r)
+	}
+}
@@ -0,0 +1,24 @@
+package lockoutcontroller
+
+import (
+	"auth/internal/services/lockout"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Lockout struct {
+	UserID        uuid.UUID `json:"userId"`
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"lastFailureAt"`
+	LockedUntil   time.Time `json:"lockedUntil"`
+}
+
+func newLockout(record lockout.Lockout) Lockout {
+	return Lockout{
+		UserID:        record.UserID,
+		Failures:      record.Failures,
+		LastFailureAt: record.LastFailureAt,
+		LockedUntil:   record.LockedUntil,
+	}
+}
@@ -0,0 +1,53 @@
+package lockoutcontroller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	lockoutcontroller "auth/internal/controllers/lockout"
+	"auth/internal/services/lockout"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// lockoutService records the tokens it unlocks with.
+type lockoutService struct {
+	unlocked []string
+}
+
+func (s *lockoutService) Unlock(token string) error {
+	s.unlocked = append(s.unlocked, token)
+	return nil
+}
+
+func (s *lockoutService) UnlockUser(uuid.UUID) error { return nil }
+
+func (s *lockoutService) ListLocked() ([]lockout.Lockout, error) { return nil, nil }
+
+func TestUnlock_GetOnlyConfirms(t *testing.T) {
+	service := &lockoutService{}
+	router := chi.NewRouter()
+	lockoutcontroller.NewLockoutController(service).RegisterRoutes(router)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/unlock?token=abc", nil))
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `<form method="post"`)
+	assert.Contains(t, resp.Body.String(), `value="abc"`)
+	assert.Equal(t, "no-store", resp.Header().Get("Cache-Control"))
+	assert.Empty(t, service.unlocked, "opening the link must not unlock")
+
+	req := httptest.NewRequest(http.MethodPost, "/unlock", strings.NewReader(url.Values{"token": {"abc"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, []string{"abc"}, service.unlocked)
+}
@@ -0,0 +1,122 @@
+package mailboxcontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/email"
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &MailboxController{}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"><title>Mailbox</title></head>
+<body style="font-family: sans-serif;">
+<h1>Mailbox</h1>
+<table cellpadding="6">
+<tr><th align="left">Captured</th><th align="left">To</th><th align="left">Subject</th></tr>
+{{- range .}}
+<tr>
+<td>{{.CapturedAt.Format "2006-01-02 15:04:05"}}</td>
+<td>{{range $i, $to := .Email.To}}{{if $i}}, {{end}}{{$to}}{{end}}</td>
+<td><a href="/dev/mailbox/{{.ID}}">{{.Email.Subject}}</a></td>
+</tr>
+{{- else}}
+<tr><td colspan="3">No emails yet.</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// MailboxController shows the emails captured in local development. It must
+// not be registered in other environments.
+type MailboxController struct {
+	mailbox Mailbox
+}
+
+type Mailbox interface {
+	List(to string) []email.CapturedEmail
+	Get(id uuid.UUID) (*email.CapturedEmail, error)
+	Clear()
+}
+
+func NewMailboxController(mailbox Mailbox) *MailboxController {
+	return &MailboxController{
+		mailbox: mailbox,
+	}
+}
+
+func (c *MailboxController) index(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := indexTemplate.Execute(w, c.mailbox.List(r.URL.Query().Get("to"))); err != nil {
+		slog.Error(errors.Wrap(err, "render mailbox").Error())
+	}
+}
+
+func (c *MailboxController) show(w http.ResponseWriter, r *http.Request) {
+	captured, ok := c.getEmail(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(captured.Email.HTML))
+}
+
+func (c *MailboxController) listEmails(w http.ResponseWriter, r *http.Request) {
+	emails := c.mailbox.List(r.URL.Query().Get("to"))
+
+	resp := make([]Email, 0, len(emails))
+	for _, captured := range emails {
+		resp = append(resp, newEmail(captured))
+	}
+	render.JSON(w, r, resp)
+}
+
+func (c *MailboxController) getEmailJSON(w http.ResponseWriter, r *http.Request) {
+	captured, ok := c.getEmail(w, r)
+	if !ok {
+		return
+	}
+
+	render.JSON(w, r, newEmail(*captured))
+}
+
+func (c *MailboxController) clear(w http.ResponseWriter, r *http.Request) {
+	c.mailbox.Clear()
+	httputils.NoContent(w, r)
+}
+
+func (c *MailboxController) getEmail(w http.ResponseWriter, r *http.Request) (*email.CapturedEmail, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "emailID"))
+	if err != nil {
+		httputils.BadRequest(w, r, errors.Wrap(err, "parse email id"))
+		return nil, false
+	}
+
+	captured, err := c.mailbox.Get(id)
+	if err != nil {
+		httputils.NotFound(w, r, err)
+		return nil, false
+	}
+	return captured, true
+}
+
+func (c *MailboxController) RegisterRoutes(router chi.Router) {
+	router.Route("/dev/mailbox", func(r chi.Router) {
+		r.Get("/", c.index)
+		r.Get("/{emailID}", c.show)
+		r.Get("/api/emails", c.listEmails)
+		r.Get("/api/emails/{emailID}", c.getEmailJSON)
+		r.Delete("/api/emails", c.clear)
+	})
+}
@@ -0,0 +1,41 @@
+package mailboxcontroller
+
+import (
+	"auth/internal/services/email"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// linkPattern finds the links in the plain-text body.
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+type Email struct {
+	ID      uuid.UUID `json:"id"`
+	From    string    `json:"from"`
+	To      []string  `json:"to"`
+	Subject string    `json:"subject"`
+	Text    string    `json:"text"`
+	HTML    string    `json:"html"`
+	// Links are the links of the email, e.g. unlock or verification links.
+	Links      []string  `json:"links"`
+	CapturedAt time.Time `json:"capturedAt"`
+}
+
+func newEmail(captured email.CapturedEmail) Email {
+	links := linkPattern.FindAllString(captured.Email.Text, -1)
+	if links == nil {
+		links = []string{}
+	}
+	return Email{
+		ID:         captured.ID,
+		From:       captured.Email.From,
+		To:         captured.Email.To,
+		Subject:    captured.Email.Subject,
+		Text:       captured.Email.Text,
+		HTML:       captured.Email.HTML,
+		Links:      links,
+		CapturedAt: captured.CapturedAt,
+	}
+}
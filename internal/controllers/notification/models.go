@@ -0,0 +1,75 @@
+package notificationcontroller
+
+import (
+	"auth/internal/services/notification"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Preferences struct {
+	DisabledAlerts []string   `json:"disabledAlerts"`
+	Digest         bool       `json:"digest"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+}
+
+type KnownNetwork struct {
+	Network     string    `json:"network"`
+	FirstSeenAt time.Time `json:"firstSeenAt"`
+	LastSeenAt  time.Time `json:"lastSeenAt"`
+}
+
+// Action describes the "This wasn't me" link of an alert. SessionID is
+// omitted for links that may only revoke all sessions.
+type Action struct {
+	SessionID *uuid.UUID `json:"sessionId,omitempty"`
+	IP        string     `json:"ip,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+}
+
+type UseActionRequest struct {
+	Token            string `json:"token"`
+	Revoke           string `json:"revoke"`
+	ResetCredentials bool   `json:"resetCredentials"`
+}
+
+type UseActionResponse struct {
+	RevokedSessions int64 `json:"revokedSessions"`
+	RevokedAPIKeys  int64 `json:"revokedApiKeys"`
+}
+
+func newAction(action *notification.Action) Action {
+	resp := Action{
+		IP:        action.IP,
+		CreatedAt: action.CreatedAt,
+		ExpiresAt: action.ExpiresAt,
+	}
+	if action.SessionID != uuid.Nil {
+		resp.SessionID = &action.SessionID
+	}
+	return resp
+}
+
+func newPreferences(preferences *notification.Preferences) Preferences {
+	resp := Preferences{
+		DisabledAlerts: preferences.DisabledAlerts,
+		Digest:         preferences.Digest,
+	}
+	if !preferences.UpdatedAt.IsZero() {
+		resp.UpdatedAt = &preferences.UpdatedAt
+	}
+	return resp
+}
+
+func newKnownNetworks(networks []notification.KnownNetwork) []KnownNetwork {
+	resp := make([]KnownNetwork, 0, len(networks))
+	for _, network := range networks {
+		resp = append(resp, KnownNetwork{
+			Network:     network.Network,
+			FirstSeenAt: network.FirstSeenAt,
+			LastSeenAt:  network.LastSeenAt,
+		})
+	}
+	return resp
+}
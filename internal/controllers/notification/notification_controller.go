@@ -0,0 +1,210 @@
+package notificationcontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/notification"
+	logutils "auth/internal/utils/log"
+	"auth/pkg/authmw"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &NotificationController{}
+
+// NotificationController lets users choose the security alerts they get and
+// review the networks they have signed in from. It also serves the "This
+// wasn't me" links of the alerts, which need no session.
+type NotificationController struct {
+	notificationService NotificationService
+	authenticate        func(http.Handler) http.Handler
+}
+
+type NotificationService interface {
+	GetPreferences(userID uuid.UUID) (*notification.Preferences, error)
+	UpdatePreferences(userID uuid.UUID, req notification.PreferencesRequest) (*notification.Preferences, error)
+	ListKnownNetworks(userID uuid.UUID) ([]notification.KnownNetwork, error)
+	ForgetNetwork(userID uuid.UUID, network string) error
+	GetAction(token, requestIP string) (*notification.Action, error)
+	UseAction(req notification.ActionRequest) (*notification.ActionResult, error)
+}
+
+// NewNotificationController takes the middleware that authenticates user
+// sessions.
+func NewNotificationController(notificationService NotificationService, authenticate func(http.Handler) http.Handler) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+		authenticate:        authenticate,
+	}
+}
+
+func (c *NotificationController) getPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	preferences, err := c.notificationService.GetPreferences(userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, newPreferences(preferences))
+}
+
+func (c *NotificationController) updatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+	var req Preferences
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	preferences, err := c.notificationService.UpdatePreferences(userID, notification.PreferencesRequest{
+		DisabledAlerts: req.DisabledAlerts,
+		Digest:         req.Digest,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, newPreferences(preferences))
+}
+
+func (c *NotificationController) listKnownNetworks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+
+	networks, err := c.notificationService.ListKnownNetworks(userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, newKnownNetworks(networks))
+}
+
+// forgetNetwork takes the network as a query parameter since it contains a
+// slash.
+func (c *NotificationController) forgetNetwork(w http.ResponseWriter, r *http.Request) {
+	userID, ok := sessionUserID(w, r)
+	if !ok {
+		return
+	}
+	network := r.URL.Query().Get("network")
+	if network == "" {
+		httputils.BadRequest(w, r, errors.New("network is required"))
+		return
+	}
+
+	if err := c.notificationService.ForgetNetwork(userID, network); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *NotificationController) getAction(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		httputils.BadRequest(w, r, errors.New("token is required"))
+		return
+	}
+
+	action, err := c.notificationService.GetAction(token, httputils.RequestIP(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, newAction(action))
+}
+
+// useAction only acts on POST, so that mail scanners following the link do
+// not use it up.
+func (c *NotificationController) useAction(w http.ResponseWriter, r *http.Request) {
+	var req UseActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	result, err := c.notificationService.UseAction(notification.ActionRequest{
+		Token:            req.Token,
+		Revoke:           req.Revoke,
+		ResetCredentials: req.ResetCredentials,
+		RequestIP:        httputils.RequestIP(r),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, UseActionResponse{
+		RevokedSessions: result.RevokedSessions,
+		RevokedAPIKeys:  result.RevokedAPIKeys,
+	})
+}
+
+func (c *NotificationController) RegisterRoutes(router chi.Router) {
+	router.Route("/security-actions", func(r chi.Router) {
+		r.Get("/", c.getAction)
+		r.Post("/", c.useAction)
+	})
+	router.Route("/notifications", func(r chi.Router) {
+		r.Use(c.authenticate)
+		r.Get("/preferences", c.getPreferences)
+		r.Put("/preferences", c.updatePreferences)
+		r.Get("/known-networks", c.listKnownNetworks)
+		r.Delete("/known-networks", c.forgetNetwork)
+	})
+}
+
+// sessionUserID returns the user of a first-party session. Support agents
+// impersonating the user may not silence the alerts the user gets.
+func sessionUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	claims, ok := authmw.FromContext(r.Context())
+	if !ok {
+		authmw.DefaultErrorHandler(w, r, authmw.ErrMissingToken)
+		return uuid.Nil, false
+	}
+	if !claims.FirstParty() {
+		httputils.Error(w, r, http.StatusForbidden, errors.New("notifications require a user's own session"))
+		return uuid.Nil, false
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		authmw.DefaultErrorHandler(w, r, authmw.ErrInvalidToken)
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var notFoundErr notification.NotFoundError
+	var validationErr notification.ValidationError
+	switch {
+	case errors.As(err, &notFoundErr):
+		httputils.NotFound(w, r, notFoundErr)
+	case errors.As(err, &validationErr):
+		httputils.BadRequest(w, r, validationErr)
+	default:
+		logutils.Error("notification request error", err)
+		httputils.InternalError(w, r)
+	}
+}
@@ -0,0 +1,121 @@
+package oauthcontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/oauth"
+	logutils "auth/internal/utils/log"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &ClientsController{}
+
+// ClientsController serves the OAuth client registry of the admin API. Its
+// routes are relative to the admin router.
+type ClientsController struct {
+	oauthService ClientsService
+}
+
+type ClientsService interface {
+	CreateClient(req oauth.CreateClientRequest) (*oauth.CreateClientResponse, error)
+	GetClient(id string) (*oauth.Client, error)
+	ListClients() ([]oauth.Client, error)
+	DeleteClient(id string) error
+}
+
+func NewClientsController(oauthService ClientsService) *ClientsController {
+	return &ClientsController{
+		oauthService: oauthService,
+	}
+}
+
+func (c *ClientsController) createClient(w http.ResponseWriter, r *http.Request) {
+	var req CreateClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	createReq := oauth.CreateClientRequest{
+		Name:                    req.Name,
+		RedirectURIs:            req.RedirectURIs,
+		Scopes:                  req.Scopes,
+		GrantTypes:              req.GrantTypes,
+		TokenEndpointAuthMethod: req.TokenEndpointAuthMethod,
+		ExchangeAudiences:       req.ExchangeAudiences,
+	}
+	if req.JWKS != nil {
+		createReq.Keys = req.JWKS.Keys
+	}
+	resp, err := c.oauthService.CreateClient(createReq)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	client := newClient(resp.Client)
+	client.ClientSecret = resp.ClientSecret
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, client)
+}
+
+func (c *ClientsController) getClient(w http.ResponseWriter, r *http.Request) {
+	client, err := c.oauthService.GetClient(chi.URLParam(r, "clientID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, newClient(*client))
+}
+
+func (c *ClientsController) listClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := c.oauthService.ListClients()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]Client, 0, len(clients))
+	for _, client := range clients {
+		resp = append(resp, newClient(client))
+	}
+	render.JSON(w, r, resp)
+}
+
+func (c *ClientsController) deleteClient(w http.ResponseWriter, r *http.Request) {
+	if err := c.oauthService.DeleteClient(chi.URLParam(r, "clientID")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *ClientsController) RegisterRoutes(router chi.Router) {
+	router.Route("/oauth/clients", func(r chi.Router) {
+		r.Get("/", c.listClients)
+		r.Post("/", c.createClient)
+		r.Get("/{clientID}", c.getClient)
+		r.Delete("/{clientID}", c.deleteClient)
+	})
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var notFoundErr oauth.NotFoundError
+	var validationErr oauth.ValidationError
+	switch {
+	case errors.As(err, &notFoundErr):
+		httputils.NotFound(w, r, notFoundErr)
+	case errors.As(err, &validationErr):
+		httputils.BadRequest(w, r, validationErr)
+	default:
+		logutils.Error("oauth clients request error", err)
+		httputils.InternalError(w, r)
+	}
+}
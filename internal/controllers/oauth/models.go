@@ -0,0 +1,194 @@
+package oauthcontroller
+
+import (
+	"auth/internal/services/oauth"
+	"auth/pkg/authmw"
+	"time"
+)
+
+// TokenResponse is the successful response of RFC 6749 section 5.1.
+// RefreshToken is base64 encoded like Session.RefreshToken.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	// IssuedTokenType is set by token exchange (RFC 8693 section 2.2.1).
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+}
+
+// ErrorResponse is the error response of RFC 6749 section 5.2.
+type ErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// DeviceAuthorizationResponse is the response of RFC 8628 section 3.2.
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+func newDeviceAuthorizationResponse(resp *oauth.DeviceAuthorizationResponse) DeviceAuthorizationResponse {
+	return DeviceAuthorizationResponse{
+		DeviceCode:              resp.DeviceCode,
+		UserCode:                resp.UserCode,
+		VerificationURI:         resp.VerificationURI,
+		VerificationURIComplete: resp.VerificationURIComplete,
+		ExpiresIn:               int64(resp.ExpiresIn.Seconds()),
+		Interval:                int64(resp.PollInterval.Seconds()),
+	}
+}
+
+type DeviceConsent struct {
+	UserCode   string    `json:"userCode"`
+	ClientID   string    `json:"clientId"`
+	ClientName string    `json:"clientName"`
+	Scopes     []string  `json:"scopes"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+func newDeviceConsent(consent *oauth.DeviceConsent) DeviceConsent {
+	return DeviceConsent{
+		UserCode:   consent.UserCode,
+		ClientID:   consent.ClientID,
+		ClientName: consent.ClientName,
+		Scopes:     consent.Scopes,
+		ExpiresAt:  consent.ExpiresAt,
+	}
+}
+
+type DeviceDecisionRequest struct {
+	UserCode string `json:"userCode"`
+	Approved bool   `json:"approved"`
+}
+
+type CreateClientRequest struct {
+	Name                    string         `json:"name"`
+	RedirectURIs            []string       `json:"redirectUris"`
+	Scopes                  []string       `json:"scopes"`
+	GrantTypes              []string       `json:"grantTypes"`
+	TokenEndpointAuthMethod string         `json:"tokenEndpointAuthMethod"`
+	JWKS                    *authmw.JWKSet `json:"jwks"`
+	ExchangeAudiences       []string       `json:"exchangeAudiences"`
+}
+
+// Client is the registered client. ClientSecret is only returned on creation.
+type Client struct {
+	ID                      string         `json:"id"`
+	Name                    string         `json:"name"`
+	RedirectURIs            []string       `json:"redirectUris"`
+	Scopes                  []string       `json:"scopes"`
+	GrantTypes              []string       `json:"grantTypes"`
+	TokenEndpointAuthMethod string         `json:"tokenEndpointAuthMethod"`
+	JWKS                    *authmw.JWKSet `json:"jwks,omitempty"`
+	ExchangeAudiences       []string       `json:"exchangeAudiences"`
+	ClientSecret            string         `json:"clientSecret,omitempty"`
+	CreatedAt               time.Time      `json:"createdAt"`
+}
+
+func newClient(client oauth.Client) Client {
+	resp := Client{
+		ID:                      client.ID,
+		Name:                    client.Name,
+		RedirectURIs:            client.RedirectURIs,
+		Scopes:                  client.Scopes,
+		GrantTypes:              client.GrantTypes,
+		TokenEndpointAuthMethod: client.TokenEndpointAuthMethod,
+		ExchangeAudiences:       client.ExchangeAudiences,
+		CreatedAt:               client.CreatedAt,
+	}
+	if len(client.Keys) > 0 {
+		resp.JWKS = &authmw.JWKSet{Keys: client.Keys}
+	}
+	return resp
+}
+
+// UserInfo is the userinfo response of OpenID Connect Core section 5.3.2.
+type UserInfo struct {
+	Subject       string `json:"sub"`
+	Name          string `json:"name,omitempty"`
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+	UpdatedAt     int64  `json:"updated_at,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+func newUserInfo(userInfo *oauth.UserInfo) UserInfo {
+	resp := UserInfo{Subject: userInfo.Subject}
+	if userInfo.Profile != nil {
+		resp.Name = userInfo.Profile.Name
+		resp.GivenName = userInfo.Profile.GivenName
+		resp.FamilyName = userInfo.Profile.FamilyName
+		resp.UpdatedAt = userInfo.Profile.UpdatedAt.Unix()
+	}
+	if userInfo.Email != nil {
+		resp.Email = userInfo.Email.Email
+		resp.EmailVerified = &userInfo.Email.EmailVerified
+	}
+	return resp
+}
+
+// ProviderMetadata is the discovery document of OpenID Connect Discovery
+// section 3.
+type ProviderMetadata struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint       string   `json:"device_authorization_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	ResponseModesSupported            []string `json:"response_modes_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	TokenEndpointAuthSigningAlgValues []string `json:"token_endpoint_auth_signing_alg_values_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	ACRValuesSupported                []string `json:"acr_values_supported"`
+}
+
+func newProviderMetadata(issuer string) ProviderMetadata {
+	return ProviderMetadata{
+		Issuer:                      issuer,
+		AuthorizationEndpoint:       issuer + "/authorize",
+		TokenEndpoint:               issuer + "/token",
+		DeviceAuthorizationEndpoint: issuer + "/device_authorization",
+		UserInfoEndpoint:            issuer + "/userinfo",
+		JWKSURI:                     issuer + "/jwks",
+		ScopesSupported:             []string{oauth.ScopeOpenID, oauth.ScopeProfile, oauth.ScopeEmail},
+		ResponseTypesSupported:      []string{oauth.ResponseTypeCode},
+		ResponseModesSupported:      []string{"query"},
+		GrantTypesSupported: []string{
+			oauth.GrantTypeAuthorizationCode,
+			oauth.GrantTypeClientCredentials,
+			oauth.GrantTypeDeviceCode,
+			oauth.GrantTypeTokenExchange,
+		},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{"RS256"},
+		TokenEndpointAuthMethodsSupported: []string{
+			oauth.AuthMethodNone,
+			oauth.AuthMethodClientSecretBasic,
+			oauth.AuthMethodClientSecretPost,
+			oauth.AuthMethodPrivateKeyJWT,
+		},
+		TokenEndpointAuthSigningAlgValues: oauth.ClientAssertionSigningAlgs,
+		CodeChallengeMethodsSupported:     []string{oauth.CodeChallengeMethodS256},
+		ClaimsSupported: []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "acr", "amr", "azp",
+			"name", "given_name", "family_name", "updated_at", "email", "email_verified",
+		},
+		ACRValuesSupported: []string{oauth.ACRDefault},
+	}
+}
@@ -0,0 +1,406 @@
+package oauthcontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/oauth"
+	"auth/internal/services/user"
+	logutils "auth/internal/utils/log"
+	"auth/pkg/authmw"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &OAuthController{}
+
+type OAuthController struct {
+	oauthService OAuthService
+	authenticate func(http.Handler) http.Handler
+}
+
+type OAuthService interface {
+	Authorize(req oauth.AuthorizeRequest) (*oauth.AuthorizeResponse, error)
+	Token(req oauth.TokenRequest) (*oauth.TokenResponse, error)
+	DeviceAuthorization(req oauth.DeviceAuthorizationRequest) (*oauth.DeviceAuthorizationResponse, error)
+	GetDeviceConsent(userCode string) (*oauth.DeviceConsent, error)
+	DecideDevice(decision oauth.DeviceDecision) error
+	UserInfo(userID uuid.UUID, scopes []string) (*oauth.UserInfo, error)
+	Issuer() string
+	JWKS() authmw.JWKSet
+}
+
+// NewOAuthController creates the controller of the OAuth and OpenID Connect
+// endpoints. authenticate must put the claims of the user's session into the
+// request context when there is one and let requests without a session
+// through, so that the authorization endpoint can answer login_required.
+func NewOAuthController(oauthService OAuthService, authenticate func(http.Handler) http.Handler) *OAuthController {
+	return &OAuthController{
+		oauthService: oauthService,
+		authenticate: authenticate,
+	}
+}
+
+func (c *OAuthController) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := oauth.AuthorizeRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+		Nonce:               query.Get("nonce"),
+		Prompt:              query.Get("prompt"),
+		MaxAge:              query.Get("max_age"),
+	}
+	if claims, ok := authmw.FromContext(r.Context()); ok {
+		userID, err := sessionUserID(claims)
+		if err != nil {
+			httputils.Error(w, r, http.StatusForbidden, err)
+			return
+		}
+		req.UserID = userID
+		req.AuthTime = claims.AuthTime
+		req.AuthMethods = claims.AuthMethods
+	}
+
+	resp, err := c.oauthService.Authorize(req)
+	var oauthErr oauth.Error
+	switch {
+	case err == nil:
+		redirect(w, r, resp.RedirectURI, url.Values{"code": {resp.Code}}, resp.State)
+	case errors.As(err, &oauthErr) && resp != nil:
+		params := url.Values{"error": {oauthErr.Code}}
+		if oauthErr.Description != "" {
+			params.Set("error_description", oauthErr.Description)
+		}
+		redirect(w, r, resp.RedirectURI, params, resp.State)
+	case errors.As(err, &oauthErr):
+		writeOAuthError(w, r, oauthErr)
+	default:
+		logutils.Error("authorize error", err)
+		httputils.InternalError(w, r)
+	}
+}
+
+func (c *OAuthController) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, r, oauth.Error{Code: oauth.ErrInvalidRequest, Description: err.Error()})
+		return
+	}
+
+	credentials, err := clientCredentials(r)
+	if err != nil {
+		writeOAuthError(w, r, oauth.Error{Code: oauth.ErrInvalidRequest, Description: err.Error()})
+		return
+	}
+	req := oauth.TokenRequest{
+		GrantType:           r.PostForm.Get("grant_type"),
+		Code:                r.PostForm.Get("code"),
+		RedirectURI:         r.PostForm.Get("redirect_uri"),
+		CodeVerifier:        r.PostForm.Get("code_verifier"),
+		DeviceCode:          r.PostForm.Get("device_code"),
+		SubjectToken:        r.PostForm.Get("subject_token"),
+		SubjectTokenType:    r.PostForm.Get("subject_token_type"),
+		RequestedTokenType:  r.PostForm.Get("requested_token_type"),
+		Audience:            r.PostForm["audience"],
+		ActorToken:          r.PostForm.Get("actor_token"),
+		Scope:               r.PostForm.Get("scope"),
+		RequestIP:           httputils.RequestIP(r),
+		ClientID:            credentials.ClientID,
+		ClientSecret:        credentials.ClientSecret,
+		ClientAssertionType: credentials.ClientAssertionType,
+		ClientAssertion:     credentials.ClientAssertion,
+	}
+
+	resp, err := c.oauthService.Token(req)
+	var oauthErr oauth.Error
+	switch {
+	case err == nil:
+	case errors.As(err, &oauthErr):
+		writeOAuthError(w, r, oauthErr)
+		return
+	default:
+		logutils.Error("token error", err)
+		writeOAuthError(w, r, oauth.Error{Code: oauth.ErrServerError})
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, newTokenResponse(resp))
+}
+
+func (c *OAuthController) deviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, r, oauth.Error{Code: oauth.ErrInvalidRequest, Description: err.Error()})
+		return
+	}
+	credentials, err := clientCredentials(r)
+	if err != nil {
+		writeOAuthError(w, r, oauth.Error{Code: oauth.ErrInvalidRequest, Description: err.Error()})
+		return
+	}
+
+	resp, err := c.oauthService.DeviceAuthorization(oauth.DeviceAuthorizationRequest{
+		Scope:               r.PostForm.Get("scope"),
+		ClientID:            credentials.ClientID,
+		ClientSecret:        credentials.ClientSecret,
+		ClientAssertionType: credentials.ClientAssertionType,
+		ClientAssertion:     credentials.ClientAssertion,
+	})
+	var oauthErr oauth.Error
+	switch {
+	case err == nil:
+	case errors.As(err, &oauthErr):
+		writeOAuthError(w, r, oauthErr)
+		return
+	default:
+		logutils.Error("device authorization error", err)
+		writeOAuthError(w, r, oauth.Error{Code: oauth.ErrServerError})
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, newDeviceAuthorizationResponse(resp))
+}
+
+func (c *OAuthController) getDeviceConsent(w http.ResponseWriter, r *http.Request) {
+	if _, ok := c.deviceSession(w, r); !ok {
+		return
+	}
+
+	consent, err := c.oauthService.GetDeviceConsent(r.URL.Query().Get("user_code"))
+	var notFoundErr oauth.NotFoundError
+	switch {
+	case err == nil:
+	case errors.As(err, &notFoundErr):
+		httputils.NotFound(w, r, errors.New("unknown or expired user code"))
+		return
+	default:
+		logutils.Error("get device consent error", err)
+		httputils.InternalError(w, r)
+		return
+	}
+
+	render.JSON(w, r, newDeviceConsent(consent))
+}
+
+func (c *OAuthController) decideDevice(w http.ResponseWriter, r *http.Request) {
+	claims, ok := c.deviceSession(w, r)
+	if !ok {
+		return
+	}
+	userID, _ := sessionUserID(claims)
+
+	var req DeviceDecisionRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		httputils.BadRequest(w, r, errors.Wrap(err, "decode request"))
+		return
+	}
+
+	err := c.oauthService.DecideDevice(oauth.DeviceDecision{
+		UserCode:    req.UserCode,
+		Approved:    req.Approved,
+		UserID:      userID,
+		AuthTime:    claims.AuthTime,
+		AuthMethods: claims.AuthMethods,
+	})
+	var notFoundErr oauth.NotFoundError
+	switch {
+	case err == nil:
+		httputils.NoContent(w, r)
+	case errors.As(err, &notFoundErr):
+		httputils.NotFound(w, r, errors.New("unknown or expired user code"))
+	default:
+		logutils.Error("decide device error", err)
+		httputils.InternalError(w, r)
+	}
+}
+
+// deviceSession requires a first party session for the device verification
+// endpoints, which unlike the authorization endpoint have nothing to answer
+// without one.
+func (c *OAuthController) deviceSession(w http.ResponseWriter, r *http.Request) (*authmw.Claims, bool) {
+	claims, ok := authmw.FromContext(r.Context())
+	if !ok {
+		authmw.DefaultErrorHandler(w, r, authmw.ErrMissingToken)
+		return nil, false
+	}
+	if _, err := sessionUserID(claims); err != nil {
+		httputils.Error(w, r, http.StatusForbidden, err)
+		return nil, false
+	}
+	return claims, true
+}
+
+func (c *OAuthController) userInfo(w http.ResponseWriter, r *http.Request) {
+	claims, _ := authmw.FromContext(r.Context())
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil || claims.ClientID != "" {
+		authmw.DefaultErrorHandler(w, r, authmw.ErrInvalidToken)
+		return
+	}
+
+	userInfo, err := c.oauthService.UserInfo(userID, claims.Scopes)
+	var oauthErr oauth.Error
+	var notFoundErr user.NotFoundError
+	switch {
+	case err == nil:
+	case errors.As(err, &oauthErr):
+		authmw.DefaultErrorHandler(w, r, authmw.ErrInsufficientScope)
+		return
+	case errors.As(err, &notFoundErr):
+		authmw.DefaultErrorHandler(w, r, authmw.ErrInvalidToken)
+		return
+	default:
+		logutils.Error("userinfo error", err)
+		httputils.InternalError(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, newUserInfo(userInfo))
+}
+
+func (c *OAuthController) providerMetadata(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, newProviderMetadata(c.oauthService.Issuer()))
+}
+
+func (c *OAuthController) jwks(w http.ResponseWriter, r *http.Request) {
+	render.JSON(w, r, c.oauthService.JWKS())
+}
+
+func (c *OAuthController) RegisterRoutes(router chi.Router) {
+	router.With(c.authenticate).Get("/authorize", c.authorize)
+	router.Post("/token", c.token)
+	router.Post("/device_authorization", c.deviceAuthorization)
+	router.Route("/device", func(r chi.Router) {
+		r.Use(c.authenticate)
+		r.Get("/", c.getDeviceConsent)
+		r.Post("/", c.decideDevice)
+	})
+	router.Route("/userinfo", func(r chi.Router) {
+		r.Use(c.authenticate, authmw.RequireScopes(oauth.ScopeOpenID))
+		r.Get("/", c.userInfo)
+		r.Post("/", c.userInfo)
+	})
+	router.Get("/.well-known/openid-configuration", c.providerMetadata)
+	// URLFormat strips file extensions from routing paths, so the JWK set is
+	// served without one
+	router.Get("/jwks", c.jwks)
+}
+
+func newTokenResponse(resp *oauth.TokenResponse) TokenResponse {
+	tokenResp := TokenResponse{
+		AccessToken:     resp.AccessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       int64(resp.ExpiresIn.Seconds()),
+		Scope:           strings.Join(resp.Scopes, " "),
+		IDToken:         resp.IDToken,
+		IssuedTokenType: resp.IssuedTokenType,
+	}
+	if resp.RefreshToken != "" {
+		tokenResp.RefreshToken = base64.StdEncoding.EncodeToString([]byte(resp.RefreshToken))
+	}
+	return tokenResp
+}
+
+// sessionUserID returns the user of a first party session. Tokens issued to
+// OAuth clients must not be used to authorize other clients on the user's
+// behalf, and neither must impersonation sessions or tokens of API keys,
+// which would turn into refreshable ones.
+func sessionUserID(claims *authmw.Claims) (uuid.UUID, error) {
+	if !claims.FirstParty() {
+		return uuid.Nil, errors.New("first party session required")
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, errors.Wrap(err, "parse sub claim")
+	}
+	return userID, nil
+}
+
+// clientCredentials reads the client authentication of a form request from
+// the Authorization header or the form, but not both.
+func clientCredentials(r *http.Request) (oauth.ClientCredentials, error) {
+	credentials := oauth.ClientCredentials{
+		ClientID:            r.PostForm.Get("client_id"),
+		ClientSecret:        r.PostForm.Get("client_secret"),
+		ClientAssertionType: r.PostForm.Get("client_assertion_type"),
+		ClientAssertion:     r.PostForm.Get("client_assertion"),
+	}
+	if clientID, clientSecret, ok := basicClientCredentials(r); ok {
+		if credentials.ClientSecret != "" || (credentials.ClientID != "" && credentials.ClientID != clientID) {
+			return credentials, errors.New("multiple client authentication methods")
+		}
+		credentials.ClientID = clientID
+		credentials.ClientSecret = clientSecret
+	}
+	return credentials, nil
+}
+
+// basicClientCredentials reads client credentials from the Authorization
+// header. Both parts are form-urlencoded before base64 encoding (RFC 6749
+// section 2.3.1).
+func basicClientCredentials(r *http.Request) (string, string, bool) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return "", "", false
+	}
+	clientID, err := url.QueryUnescape(username)
+	if err != nil {
+		return "", "", false
+	}
+	clientSecret, err := url.QueryUnescape(password)
+	if err != nil {
+		return "", "", false
+	}
+	return clientID, clientSecret, true
+}
+
+func redirect(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values, state string) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		logutils.Error("parse redirect uri error", err)
+		httputils.InternalError(w, r)
+		return
+	}
+	if state != "" {
+		params.Set("state", state)
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func writeOAuthError(w http.ResponseWriter, r *http.Request, err oauth.Error) {
+	status := http.StatusBadRequest
+	switch err.Code {
+	case oauth.ErrInvalidClient:
+		status = http.StatusUnauthorized
+		if _, _, ok := r.BasicAuth(); ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+	case oauth.ErrServerError:
+		status = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	render.Status(r, status)
+	render.JSON(w, r, ErrorResponse{
+		Error:            err.Code,
+		ErrorDescription: err.Description,
+	})
+}
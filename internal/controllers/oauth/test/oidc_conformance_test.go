@@ -0,0 +1,267 @@
+package oauthcontroller
+
+// These tests follow the checks of the OpenID Connect basic certification
+// profile that do not need a browser: discovery, the code flow with PKCE, ID
+// token validation against the published JWK set and the userinfo endpoint.
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	oauthcontroller "auth/internal/controllers/oauth"
+	"auth/internal/services/auth"
+	authmocks "auth/internal/services/auth/mocks"
+	"auth/internal/services/oauth"
+	oauthmocks "auth/internal/services/oauth/mocks"
+	"auth/internal/services/user"
+	"auth/pkg/authmw"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	redirectURI  = "https://rp.example.com/callback"
+	codeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	cookieName   = "access_token"
+)
+
+var (
+	jwtSecret = []byte("secret")
+	testUser  = user.User{
+		ID:            uuid.MustParse("8798e65e-dc84-4a7d-879e-2a52e67d86da"),
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane Doe",
+		UpdatedAt:     time.Unix(1700000000, 0),
+	}
+	testClient = oauth.Client{
+		ID:                      "grafana",
+		Name:                    "Grafana",
+		RedirectURIs:            []string{redirectURI},
+		Scopes:                  []string{"openid", "profile", "email"},
+		GrantTypes:              []string{oauth.GrantTypeAuthorizationCode},
+		TokenEndpointAuthMethod: oauth.AuthMethodNone,
+	}
+)
+
+type provider struct {
+	server      *httptest.Server
+	authService *auth.AuthService
+	httpClient  *http.Client
+}
+
+func TestDiscovery(t *testing.T) {
+	p := newProvider(t)
+
+	var metadata map[string]any
+	p.getJSON(t, "/.well-known/openid-configuration", &metadata)
+
+	assert.Equal(t, p.server.URL, metadata["issuer"])
+	for _, field := range []string{
+		"authorization_endpoint", "token_endpoint", "userinfo_endpoint", "jwks_uri",
+		"response_types_supported", "subject_types_supported", "id_token_signing_alg_values_supported",
+	} {
+		assert.Contains(t, metadata, field)
+	}
+	assert.Contains(t, metadata["id_token_signing_alg_values_supported"], "RS256")
+
+	var jwks authmw.JWKSet
+	p.getJSON(t, strings.TrimPrefix(metadata["jwks_uri"].(string), p.server.URL), &jwks)
+	require.Len(t, jwks.Keys, 1)
+	assert.NotEmpty(t, jwks.Keys[0].KeyID)
+	assert.Equal(t, "sig", jwks.Keys[0].Use)
+}
+
+func TestCodeFlow(t *testing.T) {
+	p := newProvider(t)
+	session := p.newSession(t)
+
+	resp := p.authorize(t, session, url.Values{"scope": {"openid email profile"}, "nonce": {"n-0S6_WzA2Mj"}})
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "af0ifjsldkj", location.Query().Get("state"))
+	code := location.Query().Get("code")
+	require.NotEmpty(t, code)
+
+	tokenResp := p.postForm(t, "/token", url.Values{
+		"grant_type":    {oauth.GrantTypeAuthorizationCode},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {testClient.ID},
+		"code_verifier": {codeVerifier},
+	})
+	require.Equal(t, http.StatusOK, tokenResp.StatusCode)
+	assert.Equal(t, "no-store", tokenResp.Header.Get("Cache-Control"))
+	var tokens oauthcontroller.TokenResponse
+	require.NoError(t, json.NewDecoder(tokenResp.Body).Decode(&tokens))
+	assert.Equal(t, "Bearer", tokens.TokenType)
+
+	keys := authmw.NewJWKS(p.server.URL+"/jwks", p.httpClient)
+	idToken, err := authmw.NewVerifier(keys, testClient.ID, 0).Verify(context.Background(), tokens.IDToken)
+	require.NoError(t, err)
+	assert.Equal(t, p.server.URL, idToken.Raw["iss"])
+	assert.Equal(t, testUser.ID.String(), idToken.Subject)
+	assert.Equal(t, "n-0S6_WzA2Mj", idToken.Raw["nonce"])
+	assert.Contains(t, idToken.Raw, "iat")
+	assert.Contains(t, idToken.Raw, "auth_time")
+	assert.Contains(t, idToken.Raw, "acr")
+
+	req, err := http.NewRequest(http.MethodGet, p.server.URL+"/userinfo", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	userInfoResp, err := p.httpClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, userInfoResp.StatusCode)
+	var userInfo oauthcontroller.UserInfo
+	require.NoError(t, json.NewDecoder(userInfoResp.Body).Decode(&userInfo))
+	assert.Equal(t, idToken.Subject, userInfo.Subject)
+	assert.Equal(t, testUser.Email, userInfo.Email)
+	assert.Equal(t, testUser.Name, userInfo.Name)
+}
+
+func TestCodeFlow_PromptNoneWithoutSession(t *testing.T) {
+	p := newProvider(t)
+
+	resp := p.authorize(t, "", url.Values{"scope": {"openid"}, "prompt": {"none"}})
+
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, oauth.ErrLoginRequired, location.Query().Get("error"))
+	assert.Equal(t, "af0ifjsldkj", location.Query().Get("state"))
+}
+
+func TestUserInfo_WithoutToken(t *testing.T) {
+	p := newProvider(t)
+
+	resp, err := p.httpClient.Get(p.server.URL + "/userinfo")
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("WWW-Authenticate"), "Bearer"))
+}
+
+func newProvider(t *testing.T) *provider {
+	router := chi.NewRouter()
+	router.Use(middleware.URLFormat)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	refreshTokenStorage := authmocks.NewRefreshTokenStorage(t)
+	refreshTokenStorage.On("Create", mock.AnythingOfType("*auth.RefreshToken"), mock.Anything).Return(uuid.New(), nil).Maybe()
+	roleStorage := authmocks.NewRoleStorage(t)
+	roleStorage.On("GetUserRolesAndPermissions", testUser.ID).Return(nil, nil, nil).Maybe()
+	auditLog := authmocks.NewAuditLog(t)
+	auditLog.On("Record", mock.Anything).Return(nil).Maybe()
+	authService := auth.NewAuthService(refreshTokenStorage, authmocks.NewChallengeStorage(t), roleStorage,
+		authmocks.NewNotifier(t), authmocks.NewRiskEngine(t), nil, authmocks.NewRateLimiter(t), authmocks.NewLockoutService(t), auditLog, jwtSecret,
+		time.Hour, time.Hour, config.StepUp{})
+
+	clientStorage := oauthmocks.NewClientStorage(t)
+	clientStorage.On("Get", testClient.ID).Return(&testClient, nil).Maybe()
+	codeStorage := oauthmocks.NewAuthorizationCodeStorage(t)
+	codes := make(map[string]*oauth.AuthorizationCode)
+	codeStorage.On("Create", mock.AnythingOfType("*oauth.AuthorizationCode")).
+		Run(func(args mock.Arguments) {
+			code := args.Get(0).(*oauth.AuthorizationCode)
+			codes[string(code.CodeHash)] = code
+		}).
+		Return(nil).Maybe()
+	codeStorage.On("Consume", mock.Anything).
+		Return(func(codeHash []byte) (*oauth.AuthorizationCode, error) {
+			code, ok := codes[string(codeHash)]
+			if !ok {
+				return nil, oauth.NewNotFoundError("authorization code not found")
+			}
+			delete(codes, string(codeHash))
+			return code, nil
+		}).Maybe()
+	userStorage := oauthmocks.NewUserStorage(t)
+	userStorage.On("Get", testUser.ID).Return(&testUser, nil).Maybe()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	signingKey, err := oauth.NewSigningKeyFromRSA(privateKey)
+	require.NoError(t, err)
+	oauthService := oauth.NewOAuthService(clientStorage, codeStorage, oauthmocks.NewClientAssertionStorage(t),
+		oauthmocks.NewDeviceCodeStorage(t), userStorage, authService, signingKey, config.OAuth{
+			AuthorizationCodeDuration: time.Minute,
+			IDTokenDuration:           time.Hour,
+			Issuer:                    server.URL,
+		})
+
+	authenticate := authmw.New(authmw.Options{
+		Keys:             authmw.SharedSecret(jwtSecret),
+		TokenFromRequest: authmw.BearerTokenOrCookie(cookieName),
+		Optional:         true,
+	})
+	oauthcontroller.NewOAuthController(oauthService, authenticate).RegisterRoutes(router)
+
+	return &provider{
+		server:      server,
+		authService: authService,
+		httpClient: &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+func (p *provider) newSession(t *testing.T) string {
+	accessToken, _, err := p.authService.CreateAccessAndRefreshTokens(testUser.ID, "127.0.0.1")
+	require.NoError(t, err)
+	return accessToken
+}
+
+func (p *provider) authorize(t *testing.T, session string, params url.Values) *http.Response {
+	hash := sha256.Sum256([]byte(codeVerifier))
+	params.Set("response_type", oauth.ResponseTypeCode)
+	params.Set("client_id", testClient.ID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("state", "af0ifjsldkj")
+	params.Set("code_challenge", base64.RawURLEncoding.EncodeToString(hash[:]))
+	params.Set("code_challenge_method", oauth.CodeChallengeMethodS256)
+
+	req, err := http.NewRequest(http.MethodGet, p.server.URL+"/authorize?"+params.Encode(), nil)
+	require.NoError(t, err)
+	if session != "" {
+		req.AddCookie(&http.Cookie{Name: cookieName, Value: session})
+	}
+	resp, err := p.httpClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func (p *provider) postForm(t *testing.T, path string, form url.Values) *http.Response {
+	resp, err := p.httpClient.PostForm(p.server.URL+path, form)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func (p *provider) getJSON(t *testing.T, path string, v any) {
+	resp, err := p.httpClient.Get(p.server.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
@@ -0,0 +1,66 @@
+package rbaccontroller
+
+import (
+	"auth/internal/services/rbac"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateRoleRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type CreatePermissionRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type Permission struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type UserRole struct {
+	UserID    uuid.UUID `json:"userID"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func newRole(role rbac.Role) Role {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return Role{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt,
+	}
+}
+
+func newPermission(permission rbac.Permission) Permission {
+	return Permission{
+		Name:        permission.Name,
+		Description: permission.Description,
+		CreatedAt:   permission.CreatedAt,
+	}
+}
+
+func newUserRole(userRole rbac.UserRole) UserRole {
+	return UserRole{
+		UserID:    userRole.UserID,
+		Role:      userRole.RoleName,
+		CreatedAt: userRole.CreatedAt,
+	}
+}
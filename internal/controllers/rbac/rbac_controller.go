@@ -0,0 +1,242 @@
+package rbaccontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/rbac"
+	logutils "auth/internal/utils/log"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &RBACController{}
+
+// RBACController serves the role management part of the admin API. Its routes
+// are relative to the admin router, which is responsible for authorization.
+type RBACController struct {
+	rbacService RBACService
+}
+
+type RBACService interface {
+	CreateRole(name, description string) (*rbac.Role, error)
+	GetRole(name string) (*rbac.Role, error)
+	ListRoles() ([]rbac.Role, error)
+	DeleteRole(name string) error
+	CreatePermission(name, description string) (*rbac.Permission, error)
+	ListPermissions() ([]rbac.Permission, error)
+	DeletePermission(name string) error
+	GrantPermission(roleName, permissionName string) error
+	RevokePermission(roleName, permissionName string) error
+	AssignRole(userID uuid.UUID, roleName string) error
+	UnassignRole(userID uuid.UUID, roleName string) error
+	ListUserRoles(userID uuid.UUID) ([]rbac.UserRole, error)
+}
+
+func NewRBACController(rbacService RBACService) *RBACController {
+	return &RBACController{
+		rbacService: rbacService,
+	}
+}
+
+func (c *RBACController) listRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := c.rbacService.ListRoles()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]Role, 0, len(roles))
+	for _, role := range roles {
+		resp = append(resp, newRole(role))
+	}
+	render.JSON(w, r, resp)
+}
+
+func (c *RBACController) createRole(w http.ResponseWriter, r *http.Request) {
+	var req CreateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	role, err := c.rbacService.CreateRole(req.Name, req.Description)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, newRole(*role))
+}
+
+func (c *RBACController) getRole(w http.ResponseWriter, r *http.Request) {
+	role, err := c.rbacService.GetRole(chi.URLParam(r, "role"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, newRole(*role))
+}
+
+func (c *RBACController) deleteRole(w http.ResponseWriter, r *http.Request) {
+	if err := c.rbacService.DeleteRole(chi.URLParam(r, "role")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *RBACController) grantPermission(w http.ResponseWriter, r *http.Request) {
+	err := c.rbacService.GrantPermission(chi.URLParam(r, "role"), chi.URLParam(r, "permission"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *RBACController) revokePermission(w http.ResponseWriter, r *http.Request) {
+	err := c.rbacService.RevokePermission(chi.URLParam(r, "role"), chi.URLParam(r, "permission"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *RBACController) listPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := c.rbacService.ListPermissions()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		resp = append(resp, newPermission(permission))
+	}
+	render.JSON(w, r, resp)
+}
+
+func (c *RBACController) createPermission(w http.ResponseWriter, r *http.Request) {
+	var req CreatePermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	permission, err := c.rbacService.CreatePermission(req.Name, req.Description)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, newPermission(*permission))
+}
+
+func (c *RBACController) deletePermission(w http.ResponseWriter, r *http.Request) {
+	if err := c.rbacService.DeletePermission(chi.URLParam(r, "permission")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *RBACController) listUserRoles(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		httputils.BadRequest(w, r, errors.Wrap(err, "parse userID"))
+		return
+	}
+
+	userRoles, err := c.rbacService.ListUserRoles(userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]UserRole, 0, len(userRoles))
+	for _, userRole := range userRoles {
+		resp = append(resp, newUserRole(userRole))
+	}
+	render.JSON(w, r, resp)
+}
+
+func (c *RBACController) assignRole(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		httputils.BadRequest(w, r, errors.Wrap(err, "parse userID"))
+		return
+	}
+
+	if err := c.rbacService.AssignRole(userID, chi.URLParam(r, "role")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *RBACController) unassignRole(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		httputils.BadRequest(w, r, errors.Wrap(err, "parse userID"))
+		return
+	}
+
+	if err := c.rbacService.UnassignRole(userID, chi.URLParam(r, "role")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *RBACController) RegisterRoutes(router chi.Router) {
+	router.Route("/roles", func(r chi.Router) {
+		r.Get("/", c.listRoles)
+		r.Post("/", c.createRole)
+		r.Get("/{role}", c.getRole)
+		r.Delete("/{role}", c.deleteRole)
+		r.Put("/{role}/permissions/{permission}", c.grantPermission)
+		r.Delete("/{role}/permissions/{permission}", c.revokePermission)
+	})
+	router.Route("/permissions", func(r chi.Router) {
+		r.Get("/", c.listPermissions)
+		r.Post("/", c.createPermission)
+		r.Delete("/{permission}", c.deletePermission)
+	})
+	router.Route("/users/{userID}/roles", func(r chi.Router) {
+		r.Get("/", c.listUserRoles)
+		r.Put("/{role}", c.assignRole)
+		r.Delete("/{role}", c.unassignRole)
+	})
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var notFoundErr rbac.NotFoundError
+	var alreadyExistsErr rbac.AlreadyExistsError
+	var validationErr rbac.ValidationError
+	switch {
+	case errors.As(err, &notFoundErr):
+		httputils.NotFound(w, r, notFoundErr)
+	case errors.As(err, &alreadyExistsErr):
+		httputils.Conflict(w, r, alreadyExistsErr)
+	case errors.As(err, &validationErr):
+		httputils.BadRequest(w, r, validationErr)
+	default:
+		logutils.Error("rbac request error", err)
+		httputils.InternalError(w, r)
+	}
+}
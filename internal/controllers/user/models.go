@@ -0,0 +1,54 @@
+package usercontroller
+
+import (
+	"auth/internal/services/user"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserRequest struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+	Name          string `json:"name"`
+	GivenName     string `json:"givenName"`
+	FamilyName    string `json:"familyName"`
+	Locale        string `json:"locale"`
+}
+
+type User struct {
+	ID            uuid.UUID `json:"id"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"emailVerified"`
+	Name          string    `json:"name"`
+	GivenName     string    `json:"givenName"`
+	FamilyName    string    `json:"familyName"`
+	Locale        string    `json:"locale"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+func (req UserRequest) toService() user.UserRequest {
+	return user.UserRequest{
+		Email:         req.Email,
+		EmailVerified: req.EmailVerified,
+		Name:          req.Name,
+		GivenName:     req.GivenName,
+		FamilyName:    req.FamilyName,
+		Locale:        req.Locale,
+	}
+}
+
+func newUser(u user.User) User {
+	return User{
+		ID:            u.ID,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Name:          u.Name,
+		GivenName:     u.GivenName,
+		FamilyName:    u.FamilyName,
+		Locale:        u.Locale,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
+	}
+}
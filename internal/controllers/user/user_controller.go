@@ -0,0 +1,147 @@
+package usercontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/user"
+	logutils "auth/internal/utils/log"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &UserController{}
+
+// UserController serves the user management part of the admin API. Its routes
+// are relative to the admin router.
+type UserController struct {
+	userService UserService
+}
+
+type UserService interface {
+	CreateUser(req user.UserRequest) (*user.User, error)
+	GetUser(id uuid.UUID) (*user.User, error)
+	ListUsers() ([]user.User, error)
+	UpdateUser(id uuid.UUID, req user.UserRequest) (*user.User, error)
+	DeleteUser(id uuid.UUID) error
+}
+
+func NewUserController(userService UserService) *UserController {
+	return &UserController{
+		userService: userService,
+	}
+}
+
+func (c *UserController) listUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := c.userService.ListUsers()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]User, 0, len(users))
+	for _, u := range users {
+		resp = append(resp, newUser(u))
+	}
+	render.JSON(w, r, resp)
+}
+
+func (c *UserController) createUser(w http.ResponseWriter, r *http.Request) {
+	var req UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	u, err := c.userService.CreateUser(req.toService())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, newUser(*u))
+}
+
+func (c *UserController) getUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		httputils.BadRequest(w, r, errors.Wrap(err, "parse userID"))
+		return
+	}
+
+	u, err := c.userService.GetUser(userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, newUser(*u))
+}
+
+func (c *UserController) updateUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		httputils.BadRequest(w, r, errors.Wrap(err, "parse userID"))
+		return
+	}
+	var req UserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputils.BadRequest(w, r, err)
+		return
+	}
+
+	u, err := c.userService.UpdateUser(userID, req.toService())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	render.JSON(w, r, newUser(*u))
+}
+
+func (c *UserController) deleteUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		httputils.BadRequest(w, r, errors.Wrap(err, "parse userID"))
+		return
+	}
+
+	if err := c.userService.DeleteUser(userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *UserController) RegisterRoutes(router chi.Router) {
+	router.Route("/users", func(r chi.Router) {
+		r.Get("/", c.listUsers)
+		r.Post("/", c.createUser)
+		r.Get("/{userID}", c.getUser)
+		r.Put("/{userID}", c.updateUser)
+		r.Delete("/{userID}", c.deleteUser)
+	})
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var notFoundErr user.NotFoundError
+	var alreadyExistsErr user.AlreadyExistsError
+	var validationErr user.ValidationError
+	switch {
+	case errors.As(err, &notFoundErr):
+		httputils.NotFound(w, r, notFoundErr)
+	case errors.As(err, &alreadyExistsErr):
+		httputils.Conflict(w, r, alreadyExistsErr)
+	case errors.As(err, &validationErr):
+		httputils.BadRequest(w, r, validationErr)
+	default:
+		logutils.Error("user request error", err)
+		httputils.InternalError(w, r)
+	}
+}
@@ -0,0 +1,42 @@
+package webhookcontroller
+
+import (
+	"auth/internal/services/webhook"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Delivery struct {
+	ID            uuid.UUID       `json:"id"`
+	EventID       uuid.UUID       `json:"eventId"`
+	EventType     string          `json:"eventType"`
+	Payload       json.RawMessage `json:"payload"`
+	Endpoint      string          `json:"endpoint"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	LastError     string          `json:"lastError,omitempty"`
+	DeliveredAt   *time.Time      `json:"deliveredAt,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+func newDelivery(delivery webhook.Delivery) Delivery {
+	resp := Delivery{
+		ID:            delivery.ID,
+		EventID:       delivery.Event.ID,
+		EventType:     delivery.Event.Type,
+		Payload:       delivery.Event.Payload,
+		Endpoint:      delivery.Endpoint,
+		Status:        delivery.Status,
+		Attempts:      delivery.Attempts,
+		NextAttemptAt: delivery.NextAttemptAt,
+		LastError:     delivery.LastError,
+		CreatedAt:     delivery.CreatedAt,
+	}
+	if !delivery.DeliveredAt.IsZero() {
+		resp.DeliveredAt = &delivery.DeliveredAt
+	}
+	return resp
+}
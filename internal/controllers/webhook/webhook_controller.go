@@ -0,0 +1,97 @@
+package webhookcontroller
+
+import (
+	"auth/internal/controllers"
+	"auth/internal/controllers/httputils"
+	"auth/internal/services/webhook"
+	logutils "auth/internal/utils/log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ controllers.Controller = &WebhookController{}
+
+// WebhookController lets admins inspect and replay webhook deliveries. Its
+// routes are relative to the admin router.
+type WebhookController struct {
+	webhookService WebhookService
+}
+
+type WebhookService interface {
+	ListDeliveries(filter webhook.DeliveryFilter) ([]webhook.Delivery, error)
+	Replay(id uuid.UUID) error
+}
+
+func NewWebhookController(webhookService WebhookService) *WebhookController {
+	return &WebhookController{
+		webhookService: webhookService,
+	}
+}
+
+func (c *WebhookController) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := webhook.DeliveryFilter{
+		Status:   query.Get("status"),
+		Endpoint: query.Get("endpoint"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			httputils.BadRequest(w, r, errors.Wrap(err, "parse limit"))
+			return
+		}
+	}
+
+	deliveries, err := c.webhookService.ListDeliveries(filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]Delivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		resp = append(resp, newDelivery(delivery))
+	}
+	render.JSON(w, r, resp)
+}
+
+func (c *WebhookController) replay(w http.ResponseWriter, r *http.Request) {
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryID"))
+	if err != nil {
+		httputils.BadRequest(w, r, errors.Wrap(err, "parse delivery id"))
+		return
+	}
+
+	if err := c.webhookService.Replay(deliveryID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	httputils.NoContent(w, r)
+}
+
+func (c *WebhookController) RegisterRoutes(router chi.Router) {
+	router.Route("/webhook-deliveries", func(r chi.Router) {
+		r.Get("/", c.listDeliveries)
+		r.Post("/{deliveryID}/replay", c.replay)
+	})
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var notFoundErr webhook.NotFoundError
+	var validationErr webhook.ValidationError
+	switch {
+	case errors.As(err, &notFoundErr):
+		httputils.NotFound(w, r, notFoundErr)
+	case errors.As(err, &validationErr):
+		httputils.BadRequest(w, r, validationErr)
+	default:
+		logutils.Error("webhook request error", err)
+		httputils.InternalError(w, r)
+	}
+}
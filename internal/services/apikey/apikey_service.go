@@ -0,0 +1,273 @@
+package apikey
+
+import (
+	"auth/internal/config"
+	"auth/internal/services/auth"
+	"auth/internal/services/oauth"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	// keyPrefix marks API keys, so that secret scanners can find leaked ones.
+	keyPrefix = "ak_"
+	// prefixLength is the number of random hex characters of the visible
+	// prefix.
+	prefixLength = 12
+)
+
+type APIKeyService struct {
+	storage       Storage
+	clientStorage ClientStorage
+	tokenIssuer   TokenIssuer
+	maxDuration   time.Duration
+	tokenDuration time.Duration
+}
+
+//go:generate mockery --name Storage --filename storage.go
+type Storage interface {
+	Create(key *APIKey) error
+	Get(id uuid.UUID) (*APIKey, error)
+	GetByPrefix(prefix string) (*APIKey, error)
+	List(filter Filter) ([]APIKey, error)
+	// Revoke sets RevokedAt of a key that is not revoked yet.
+	Revoke(id uuid.UUID) error
+	MarkUsed(id uuid.UUID, usedAt time.Time) error
+}
+
+//go:generate mockery --name ClientStorage --filename client_storage.go
+type ClientStorage interface {
+	Get(id string) (*oauth.Client, error)
+}
+
+//go:generate mockery --name TokenIssuer --filename token_issuer.go
+type TokenIssuer interface {
+	CreateAccessAndRefreshTokens(userID uuid.UUID, requestIP string, opts ...auth.TokenOption) (string, string, error)
+	CreateClientAccessToken(clientID string, scopes []string, opts ...auth.TokenOption) (string, error)
+	AccessTokenDuration() time.Duration
+}
+
+func NewAPIKeyService(
+	storage Storage,
+	clientStorage ClientStorage,
+	tokenIssuer TokenIssuer,
+	cfg config.APIKeys,
+) *APIKeyService {
+	return &APIKeyService{
+		storage:       storage,
+		clientStorage: clientStorage,
+		tokenIssuer:   tokenIssuer,
+		maxDuration:   cfg.MaxDuration,
+		tokenDuration: cfg.TokenDuration,
+	}
+}
+
+// CreateKey creates a key. Scopes of client keys must be registered for the
+// client. Scopes of user keys must be granted to the session that creates
+// the key, if any, and are checked against the user's permissions whenever
+// the key is exchanged, like the scopes of OAuth grants.
+func (s *APIKeyService) CreateKey(req CreateRequest) (*CreateResponse, error) {
+	if req.Name == "" {
+		return nil, ValidationError{"name is required"}
+	}
+	if (req.UserID == uuid.Nil) == (req.ClientID == "") {
+		return nil, ValidationError{"a key is owned by either a user or a client"}
+	}
+	if len(req.Scopes) == 0 {
+		return nil, ValidationError{"at least one scope is required"}
+	}
+	expiresAt := req.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = time.Now().Add(s.maxDuration)
+	}
+	if !expiresAt.After(time.Now()) || expiresAt.After(time.Now().Add(s.maxDuration)) {
+		return nil, ValidationError{"expiry must be in the future and within " + s.maxDuration.String()}
+	}
+	if req.SessionScopes != nil {
+		for _, scope := range req.Scopes {
+			if !contains(req.SessionScopes, scope) {
+				return nil, ValidationError{"scope " + scope + " is not granted to the session"}
+			}
+		}
+	}
+	if req.ClientID != "" {
+		client, err := s.clientStorage.Get(req.ClientID)
+		if err != nil {
+			var notFoundErr oauth.NotFoundError
+			if errors.As(err, &notFoundErr) {
+				return nil, ValidationError{"unknown client " + req.ClientID}
+			}
+			return nil, errors.Wrap(err, "get client")
+		}
+		for _, scope := range req.Scopes {
+			if !contains(client.Scopes, scope) {
+				return nil, ValidationError{"scope " + scope + " is not allowed for the client"}
+			}
+		}
+	}
+
+	key, prefix, secretHash, err := generateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate key")
+	}
+	apiKey := APIKey{
+		Prefix:     prefix,
+		SecretHash: secretHash,
+		Name:       req.Name,
+		UserID:     req.UserID,
+		ClientID:   req.ClientID,
+		Scopes:     req.Scopes,
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.storage.Create(&apiKey); err != nil {
+		return nil, errors.Wrap(err, "create key")
+	}
+
+	return &CreateResponse{APIKey: apiKey, Key: key}, nil
+}
+
+func (s *APIKeyService) ListKeys(filter Filter) ([]APIKey, error) {
+	keys, err := s.storage.List(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "list keys")
+	}
+
+	return keys, nil
+}
+
+// RevokeKey revokes the key. A user can only revoke their own keys, admins
+// pass uuid.Nil to revoke any key.
+func (s *APIKeyService) RevokeKey(id uuid.UUID, userID uuid.UUID) error {
+	if userID != uuid.Nil {
+		key, err := s.storage.Get(id)
+		if err != nil {
+			return errors.Wrap(err, "get key")
+		}
+		if key.UserID != userID {
+			return NewNotFoundError("api key not found")
+		}
+	}
+
+	return errors.Wrap(s.storage.Revoke(id), "revoke key")
+}
+
+// Exchange turns a key into a short-lived access token of its owner,
+// restricted to the scopes of the key. No refresh token is issued, the key
+// can be exchanged again.
+func (s *APIKeyService) Exchange(key, requestIP string) (*Token, error) {
+	prefix, secret, ok := parseKey(key)
+	if !ok {
+		return nil, UnauthorizedError{"malformed api key"}
+	}
+	apiKey, err := s.storage.GetByPrefix(prefix)
+	if err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return nil, UnauthorizedError{"invalid api key"}
+		}
+		return nil, errors.Wrap(err, "get key")
+	}
+	if subtle.ConstantTimeCompare(hashSecret(secret), apiKey.SecretHash) != 1 {
+		return nil, UnauthorizedError{"invalid api key"}
+	}
+	now := time.Now()
+	if !apiKey.RevokedAt.IsZero() {
+		return nil, UnauthorizedError{"api key revoked"}
+	}
+	if now.After(apiKey.ExpiresAt) {
+		return nil, UnauthorizedError{"api key expired"}
+	}
+
+	if err := s.storage.MarkUsed(apiKey.ID, now); err != nil {
+		return nil, errors.Wrap(err, "mark key used")
+	}
+
+	var accessToken string
+	if apiKey.ClientID != "" {
+		accessToken, err = s.exchangeClientKey(apiKey)
+	} else {
+		accessToken, _, err = s.tokenIssuer.CreateAccessAndRefreshTokens(
+			apiKey.UserID,
+			requestIP,
+			auth.WithScopes(apiKey.Scopes),
+			auth.WithAPIKey(apiKey.ID),
+			auth.WithLifetime(s.tokenDuration),
+		)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "create access token")
+	}
+
+	expiresIn := s.tokenIssuer.AccessTokenDuration()
+	if s.tokenDuration < expiresIn {
+		expiresIn = s.tokenDuration
+	}
+	return &Token{AccessToken: accessToken, ExpiresIn: expiresIn}, nil
+}
+
+// exchangeClientKey issues a client token. Scopes removed from the client
+// registration since the key was created are dropped.
+func (s *APIKeyService) exchangeClientKey(apiKey *APIKey) (string, error) {
+	client, err := s.clientStorage.Get(apiKey.ClientID)
+	if err != nil {
+		return "", errors.Wrap(err, "get client")
+	}
+	scopes := []string{}
+	for _, scope := range apiKey.Scopes {
+		if contains(client.Scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return s.tokenIssuer.CreateClientAccessToken(client.ID, scopes, auth.WithLifetime(s.tokenDuration))
+}
+
+// generateKey returns a key of the form ak_<prefix>_<secret>. The secret has
+// 256 bits of entropy, so a fast hash is enough to store it, as with
+// authorization codes.
+func generateKey() (string, string, []byte, error) {
+	prefixBytes := make([]byte, prefixLength/2)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", nil, errors.Wrap(err, "read random bytes")
+	}
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", nil, errors.Wrap(err, "read random bytes")
+	}
+	prefix := keyPrefix + hex.EncodeToString(prefixBytes)
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	return prefix + "_" + secret, prefix, hashSecret(secret), nil
+}
+
+// parseKey splits a key into its prefix and secret. The secret may contain
+// underscores itself.
+func parseKey(key string) (string, string, bool) {
+	prefixEnd := len(keyPrefix) + prefixLength
+	if !strings.HasPrefix(key, keyPrefix) || len(key) <= prefixEnd+1 || key[prefixEnd] != '_' {
+		return "", "", false
+	}
+	return key[:prefixEnd], key[prefixEnd+1:], true
+}
+
+func hashSecret(secret string) []byte {
+	hash := sha256.Sum256([]byte(secret))
+	return hash[:]
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package apikey
+
+type NotFoundError struct {
+	message string
+}
+
+func (err NotFoundError) Error() string {
+	return err.message
+}
+
+func NewNotFoundError(message string) NotFoundError {
+	return NotFoundError{message: message}
+}
+
+type ValidationError struct {
+	message string
+}
+
+func (err ValidationError) Error() string {
+	return err.message
+}
+
+type UnauthorizedError struct {
+	message string
+}
+
+func (err UnauthorizedError) Error() string {
+	return err.message
+}
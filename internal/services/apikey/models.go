@@ -0,0 +1,56 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is owned by either a user (UserID) or an OAuth client (ClientID).
+// LastUsedAt and RevokedAt are zero until the key is used or revoked.
+type APIKey struct {
+	ID uuid.UUID
+	// Prefix is the public part of the key that identifies it in lists and
+	// logs.
+	Prefix     string
+	SecretHash []byte
+	Name       string
+	UserID     uuid.UUID
+	ClientID   string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+	CreatedAt  time.Time
+}
+
+// CreateRequest creates a key for UserID or ClientID. ExpiresAt defaults to
+// the longest allowed expiry. SessionScopes are the scopes of the user's
+// session that creates the key, nil when an admin does.
+type CreateRequest struct {
+	Name          string
+	UserID        uuid.UUID
+	ClientID      string
+	Scopes        []string
+	ExpiresAt     time.Time
+	SessionScopes []string
+}
+
+// CreateResponse carries the key itself, which is not stored and cannot be
+// retrieved later.
+type CreateResponse struct {
+	APIKey APIKey
+	Key    string
+}
+
+// Filter selects the keys of a user or a client. The zero Filter selects all
+// keys.
+type Filter struct {
+	UserID   uuid.UUID
+	ClientID string
+}
+
+type Token struct {
+	AccessToken string
+	ExpiresIn   time.Duration
+}
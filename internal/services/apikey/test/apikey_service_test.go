@@ -0,0 +1,139 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/services/apikey"
+	"auth/internal/services/apikey/mocks"
+	"auth/internal/services/oauth"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	userID = uuid.MustParse("8798e65e-dc84-4a7d-879e-2a52e67d86da")
+	ip     = "10.0.0.1"
+	cfg    = config.APIKeys{MaxDuration: 365 * 24 * time.Hour, TokenDuration: 15 * time.Minute}
+	client = oauth.Client{ID: "billing", Scopes: []string{"invoices:read", "invoices:write"}}
+)
+
+func TestCreateAndExchangeUserKey(t *testing.T) {
+	service, storage, _, tokenIssuer := newServiceAndMocks(t)
+	var stored *apikey.APIKey
+	storage.
+		On("Create", mock.AnythingOfType("*apikey.APIKey")).
+		Run(func(args mock.Arguments) { stored = args.Get(0).(*apikey.APIKey) }).
+		Return(nil)
+
+	resp, err := service.CreateKey(apikey.CreateRequest{Name: "ci", UserID: userID, Scopes: []string{"invoices:read"}})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.Key, stored.Prefix+"_"))
+	assert.WithinDuration(t, time.Now().Add(cfg.MaxDuration), stored.ExpiresAt, time.Second)
+
+	stored.ID = uuid.New()
+	storage.On("GetByPrefix", stored.Prefix).Return(stored, nil)
+	storage.On("MarkUsed", stored.ID, mock.Anything).Return(nil)
+	tokenIssuer.
+		On("CreateAccessAndRefreshTokens", userID, ip, mock.Anything, mock.Anything, mock.Anything).
+		Return("access", "", nil)
+	tokenIssuer.On("AccessTokenDuration").Return(time.Hour)
+
+	token, err := service.Exchange(resp.Key, ip)
+
+	require.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+	assert.Equal(t, cfg.TokenDuration, token.ExpiresIn)
+}
+
+func TestExchange_WrongSecret(t *testing.T) {
+	service, storage, _, _ := newServiceAndMocks(t)
+	key := &apikey.APIKey{Prefix: "ak_0123456789ab", SecretHash: []byte("hash"), ExpiresAt: time.Now().Add(time.Hour)}
+	storage.On("GetByPrefix", key.Prefix).Return(key, nil)
+
+	_, err := service.Exchange(key.Prefix+"_guessed", ip)
+
+	assert.ErrorAs(t, err, &apikey.UnauthorizedError{})
+}
+
+func TestExchange_Revoked(t *testing.T) {
+	service, storage, _, _ := newServiceAndMocks(t)
+	resp := createKey(t, service, storage, apikey.CreateRequest{Name: "ci", UserID: userID, Scopes: []string{"a"}})
+	resp.APIKey.RevokedAt = time.Now()
+	storage.On("GetByPrefix", resp.APIKey.Prefix).Return(&resp.APIKey, nil)
+
+	_, err := service.Exchange(resp.Key, ip)
+
+	assert.ErrorAs(t, err, &apikey.UnauthorizedError{})
+}
+
+func TestExchangeClientKey_DropsRemovedScopes(t *testing.T) {
+	service, storage, clientStorage, tokenIssuer := newServiceAndMocks(t)
+	clientStorage.On("Get", client.ID).Return(&client, nil).Once()
+	resp := createKey(t, service, storage, apikey.CreateRequest{
+		Name:     "billing export",
+		ClientID: client.ID,
+		Scopes:   []string{"invoices:read", "invoices:write"},
+	})
+	storage.On("GetByPrefix", resp.APIKey.Prefix).Return(&resp.APIKey, nil)
+	storage.On("MarkUsed", mock.Anything, mock.Anything).Return(nil)
+	clientStorage.On("Get", client.ID).Return(&oauth.Client{ID: client.ID, Scopes: []string{"invoices:read"}}, nil)
+	tokenIssuer.
+		On("CreateClientAccessToken", client.ID, []string{"invoices:read"}, mock.Anything).
+		Return("access", nil)
+	tokenIssuer.On("AccessTokenDuration").Return(time.Hour)
+
+	_, err := service.Exchange(resp.Key, ip)
+
+	require.NoError(t, err)
+}
+
+func TestCreateKey_Validation(t *testing.T) {
+	service, _, clientStorage, _ := newServiceAndMocks(t)
+	clientStorage.On("Get", client.ID).Return(&client, nil)
+
+	for name, req := range map[string]apikey.CreateRequest{
+		"no owner":           {Name: "ci", Scopes: []string{"a"}},
+		"two owners":         {Name: "ci", UserID: userID, ClientID: client.ID, Scopes: []string{"a"}},
+		"no scopes":          {Name: "ci", UserID: userID},
+		"expiry too late":    {Name: "ci", UserID: userID, Scopes: []string{"a"}, ExpiresAt: time.Now().Add(2 * cfg.MaxDuration)},
+		"unregistered scope": {Name: "ci", ClientID: client.ID, Scopes: []string{"admin"}},
+		"beyond session": {Name: "ci", UserID: userID, Scopes: []string{"auth:admin"},
+			SessionScopes: []string{"invoices:read"}},
+		"session without scopes": {Name: "ci", UserID: userID, Scopes: []string{"invoices:read"}, SessionScopes: []string{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.CreateKey(req)
+			assert.ErrorAs(t, err, &apikey.ValidationError{})
+		})
+	}
+}
+
+func TestRevokeKey_OtherUser(t *testing.T) {
+	service, storage, _, _ := newServiceAndMocks(t)
+	keyID := uuid.New()
+	storage.On("Get", keyID).Return(&apikey.APIKey{ID: keyID, UserID: uuid.New()}, nil)
+
+	err := service.RevokeKey(keyID, userID)
+
+	assert.ErrorAs(t, err, &apikey.NotFoundError{})
+}
+
+func createKey(t *testing.T, service *apikey.APIKeyService, storage *mocks.Storage, req apikey.CreateRequest) *apikey.CreateResponse {
+	storage.On("Create", mock.AnythingOfType("*apikey.APIKey")).Return(nil).Once()
+	resp, err := service.CreateKey(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func newServiceAndMocks(t *testing.T) (*apikey.APIKeyService, *mocks.Storage, *mocks.ClientStorage, *mocks.TokenIssuer) {
+	storage := mocks.NewStorage(t)
+	clientStorage := mocks.NewClientStorage(t)
+	tokenIssuer := mocks.NewTokenIssuer(t)
+	return apikey.NewAPIKeyService(storage, clientStorage, tokenIssuer, cfg), storage, clientStorage, tokenIssuer
+}
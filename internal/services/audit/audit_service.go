@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"auth/internal/services/geoip"
+	logutils "auth/internal/utils/log"
+	"bytes"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type AuditService struct {
+	storage Storage
+	locator Locator
+}
+
+//go:generate mockery --name Storage --filename storage.go
+type Storage interface {
+	// Append sets ID, PrevHash and Hash of the event with ComputeHash. The
+	// last hash is read under a lock, so that concurrent appends form a
+	// chain.
+	Append(event *Event) error
+	List(filter Filter) ([]Event, error)
+}
+
+//go:generate mockery --name Locator --filename locator.go
+type Locator interface {
+	Locate(ip string) (*geoip.Location, error)
+}
+
+// NewAuditService takes an optional locator, without it the listed events
+// have no location.
+func NewAuditService(storage Storage, locator Locator) *AuditService {
+	return &AuditService{
+		storage: storage,
+		locator: locator,
+	}
+}
+
+// Record appends the event to the log.
+func (s *AuditService) Record(event Event) error {
+	// Postgres keeps microseconds, the hash must cover the stored time
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	if event.Details == nil {
+		event.Details = map[string]string{}
+	}
+
+	return errors.Wrap(s.storage.Append(&event), "append event")
+}
+
+func (s *AuditService) ListEvents(filter Filter) ([]Event, error) {
+	if filter.Limit == 0 {
+		filter.Limit = defaultLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxLimit {
+		return nil, ValidationError{"limit must be between 1 and 1000"}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, ValidationError{"to must not be before from"}
+	}
+
+	events, err := s.storage.List(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "list events")
+	}
+	s.locate(events)
+
+	return events, nil
+}
+
+// locate sets the location of the events, looking each address up once.
+func (s *AuditService) locate(events []Event) {
+	if s.locator == nil {
+		return
+	}
+	locations := make(map[string]*geoip.Location)
+	for i := range events {
+		ip := events[i].IP
+		if ip == "" {
+			continue
+		}
+		location, ok := locations[ip]
+		if !ok {
+			var err error
+			location, err = s.locator.Locate(ip)
+			if err != nil {
+				logutils.Error("locate ip error", err)
+			}
+			locations[ip] = location
+		}
+		events[i].Location = location
+	}
+}
+
+// Verify recomputes the hash chain from the first event.
+func (s *AuditService) Verify() (*Verification, error) {
+	var verification Verification
+	var prevHash []byte
+	filter := Filter{Limit: maxLimit}
+	for {
+		events, err := s.storage.List(filter)
+		if err != nil {
+			return nil, errors.Wrap(err, "list events")
+		}
+		for _, event := range events {
+			if !bytes.Equal(event.PrevHash, prevHash) || !bytes.Equal(event.Hash, event.ComputeHash(prevHash)) {
+				verification.BrokenAt = event.ID
+				return &verification, nil
+			}
+			prevHash = event.Hash
+			verification.Checked++
+		}
+		if len(events) < filter.Limit {
+			return &verification, nil
+		}
+		filter.AfterID = events[len(events)-1].ID
+	}
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"auth/internal/services/geoip"
+	"crypto/sha256"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventSessionCreated   = "session_created"
+	EventSessionRefreshed = "session_refreshed"
+	EventIPChanged        = "ip_changed"
+	EventSessionRevoked   = "session_revoked"
+	EventReuseDetected    = "refresh_token_reuse_detected"
+	EventEmailSent        = "email_sent"
+	// EventSecurityActionOpened and EventSecurityActionUsed follow the
+	// "This wasn't me" link of an alert.
+	EventSecurityActionOpened = "security_action_opened"
+	EventSecurityActionUsed   = "security_action_used"
+	// EventStepUpRequired holds a refresh until the user enters the emailed
+	// code, EventStepUpConfirmed and EventStepUpFailed record the attempts.
+	EventStepUpRequired  = "step_up_required"
+	EventStepUpConfirmed = "step_up_confirmed"
+	EventStepUpFailed    = "step_up_failed"
+	// EventRiskAssessed logs the decision of the risk engine on a session
+	// creation or refresh with its factors.
+	EventRiskAssessed = "risk_assessed"
+)
+
+// Event is an entry of the audit log. Each entry hashes the previous one, so
+// that changing or removing an entry breaks the chain from there on.
+type Event struct {
+	ID        int64
+	Type      string
+	UserID    uuid.UUID
+	IP        string
+	Details   map[string]string
+	CreatedAt time.Time
+	PrevHash  []byte
+	Hash      []byte
+	// Location of IP is resolved when the events are listed. It is neither
+	// stored nor hashed, since the GeoIP databases change.
+	Location *geoip.Location
+}
+
+// ComputeHash hashes the event with the hash of the previous event. ID and
+// Hash itself are not covered.
+func (e Event) ComputeHash(prevHash []byte) []byte {
+	// json.Marshal sorts map keys, which makes the encoding stable
+	content, _ := json.Marshal(struct {
+		Type      string            `json:"type"`
+		UserID    uuid.UUID         `json:"userId"`
+		IP        string            `json:"ip"`
+		Details   map[string]string `json:"details"`
+		CreatedAt string            `json:"createdAt"`
+	}{e.Type, e.UserID, e.IP, e.Details, e.CreatedAt.UTC().Format(time.RFC3339Nano)})
+
+	hash := sha256.New()
+	hash.Write(prevHash)
+	hash.Write(content)
+	return hash.Sum(nil)
+}
+
+// Filter selects events. Zero fields do not filter. Events are returned
+// oldest first, starting after AfterID.
+type Filter struct {
+	UserID  uuid.UUID
+	Types   []string
+	From    time.Time
+	To      time.Time
+	AfterID int64
+	Limit   int
+}
+
+// Verification is the result of checking the hash chain. BrokenAt is the ID
+// of the first event that does not match, zero for an intact chain.
+type Verification struct {
+	Checked  int
+	BrokenAt int64
+}
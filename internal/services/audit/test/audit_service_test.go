@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"testing"
+
+	"auth/internal/services/audit"
+	"auth/internal/services/audit/mocks"
+	"auth/internal/services/geoip"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var userID = uuid.MustParse("8798e65e-dc84-4a7d-879e-2a52e67d86da")
+
+func TestVerify(t *testing.T) {
+	service, storage := newServiceAndMocks(t)
+	events := newChain(t, service, storage, 3)
+	storage.On("List", mock.Anything).Return(events, nil)
+
+	verification, err := service.Verify()
+
+	require.NoError(t, err)
+	assert.Equal(t, &audit.Verification{Checked: 3}, verification)
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	service, storage := newServiceAndMocks(t)
+	events := newChain(t, service, storage, 3)
+	events[1].IP = "10.0.0.2"
+	storage.On("List", mock.Anything).Return(events, nil)
+
+	verification, err := service.Verify()
+
+	require.NoError(t, err)
+	assert.Equal(t, &audit.Verification{Checked: 1, BrokenAt: events[1].ID}, verification)
+}
+
+func TestVerify_Removed(t *testing.T) {
+	service, storage := newServiceAndMocks(t)
+	events := newChain(t, service, storage, 3)
+	storage.On("List", mock.Anything).Return([]audit.Event{events[0], events[2]}, nil)
+
+	verification, err := service.Verify()
+
+	require.NoError(t, err)
+	assert.Equal(t, events[2].ID, verification.BrokenAt)
+}
+
+func TestListEvents_Validation(t *testing.T) {
+	service, _ := newServiceAndMocks(t)
+
+	_, err := service.ListEvents(audit.Filter{Limit: 5000})
+
+	assert.ErrorAs(t, err, &audit.ValidationError{})
+}
+
+func TestListEvents_Location(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	locator := mocks.NewLocator(t)
+	service := audit.NewAuditService(storage, locator)
+	berlin := &geoip.Location{Country: "DE", City: "Berlin"}
+	storage.
+		On("List", audit.Filter{Limit: 100}).
+		Return([]audit.Event{{ID: 1, IP: "81.2.69.160"}, {ID: 2, IP: "81.2.69.160"}, {ID: 3}}, nil)
+	locator.On("Locate", "81.2.69.160").Return(berlin, nil).Once()
+
+	events, err := service.ListEvents(audit.Filter{})
+
+	require.NoError(t, err)
+	assert.Equal(t, berlin, events[0].Location)
+	assert.Equal(t, berlin, events[1].Location)
+	assert.Nil(t, events[2].Location)
+}
+
+// newChain records events the way the storage chains them.
+func newChain(t *testing.T, service *audit.AuditService, storage *mocks.Storage, n int) []audit.Event {
+	var events []audit.Event
+	storage.
+		On("Append", mock.AnythingOfType("*audit.Event")).
+		Run(func(args mock.Arguments) {
+			event := args.Get(0).(*audit.Event)
+			event.ID = int64(len(events) + 1)
+			if len(events) > 0 {
+				event.PrevHash = events[len(events)-1].Hash
+			}
+			event.Hash = event.ComputeHash(event.PrevHash)
+			events = append(events, *event)
+		}).
+		Return(nil).
+		Times(n)
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, service.Record(audit.Event{Type: audit.EventSessionCreated, UserID: userID, IP: "10.0.0.1"}))
+	}
+	return events
+}
+
+func newServiceAndMocks(t *testing.T) (*audit.AuditService, *mocks.Storage) {
+	storage := mocks.NewStorage(t)
+	return audit.NewAuditService(storage, nil), storage
+}
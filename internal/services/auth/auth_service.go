@@ -2,8 +2,16 @@ package auth
 
 import (
 	"auth/internal/config"
+	"auth/internal/services/audit"
+	"auth/internal/services/geoip"
+	"auth/internal/services/ratelimit"
+	"auth/internal/services/risk"
+	"auth/internal/services/webhook"
 	jwtutils "auth/internal/utils/jwt"
+	keyutils "auth/internal/utils/key"
 	logutils "auth/internal/utils/log"
+	"auth/pkg/authmw"
+	"context"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -14,43 +22,176 @@ import (
 
 type AuthService struct {
 	refreshTokenStorage  RefreshTokenStorage
-	emailService         EmailService
+	challengeStorage     ChallengeStorage
+	roleStorage          RoleStorage
+	notifier             Notifier
+	riskEngine           RiskEngine
+	locator              Locator
+	rateLimiter          RateLimiter
+	lockoutService       LockoutService
+	auditLog             AuditLog
 	accessTokenDuration  time.Duration
 	refreshTokenDuration time.Duration
 	jwtPrivateKey        []byte
-	Emails               config.Emails
+	// codeKey hashes the step-up codes. It is derived from jwtPrivateKey,
+	// which only signs tokens.
+	codeKey []byte
+	stepUp  config.StepUp
 }
 
 //go:generate mockery --name RefreshTokenStorage --filename refresh_token_storage.go
 type RefreshTokenStorage interface {
-	Create(token *RefreshToken) (uuid.UUID, error)
+	// Create, Rotate and Delete store the outbox event, if any, in the
+	// transaction of the change.
+	Create(token *RefreshToken, event *webhook.Event) (uuid.UUID, error)
+	// Rotate deletes the token previousID and creates token in one
+	// transaction. It returns NotFoundError when previousID no longer
+	// exists, so that a token is rotated at most once.
+	Rotate(previousID uuid.UUID, token *RefreshToken, event *webhook.Event) (uuid.UUID, error)
 	Get(id uuid.UUID) (*RefreshToken, error)
+	// ListByUser returns the tokens of the user that expire after now, the
+	// newest first.
+	ListByUser(userID uuid.UUID, now time.Time) ([]RefreshToken, error)
+	// Delete returns NotFoundError when the token does not exist, and stores
+	// no event then.
+	Delete(id uuid.UUID, event *webhook.Event) error
+}
+
+//go:generate mockery --name RoleStorage --filename role_storage.go
+type RoleStorage interface {
+	GetUserRolesAndPermissions(userID uuid.UUID) ([]string, []string, error)
+}
+
+//go:generate mockery --name Notifier --filename notifier.go
+type Notifier interface {
+	// NotifyNewIP alerts the user when ip is in a network the user has not
+	// signed in from, and returns whether an email was sent. sessionID is
+	// the FamilyID of the session at ip.
+	NotifyNewIP(userID, sessionID uuid.UUID, previousIP, ip, acceptLanguage string) (bool, error)
+	// IsKnownIP returns whether ip is in the network of previousIP or in one
+	// the user has signed in from.
+	IsKnownIP(userID uuid.UUID, previousIP, ip string) (bool, error)
+	// TrustIP makes the network of ip known without an alert.
+	TrustIP(userID uuid.UUID, ip string) error
+	SendStepUpCode(userID uuid.UUID, code, ip, acceptLanguage string, expiresAt time.Time) error
+}
+
+//go:generate mockery --name RiskEngine --filename risk_engine.go
+type RiskEngine interface {
+	// Assess returns nil when risk scoring is disabled.
+	Assess(req risk.Request) (*risk.Assessment, error)
+	Confirm(id uuid.UUID) error
+}
+
+//go:generate mockery --name Locator --filename locator.go
+type Locator interface {
+	// Locate returns nil for addresses the GeoIP databases do not know.
+	Locate(ip string) (*geoip.Location, error)
+}
+
+//go:generate mockery --name ChallengeStorage --filename challenge_storage.go
+type ChallengeStorage interface {
+	Create(challenge *Challenge) error
+	// UseAttempt counts an attempt and returns the challenge. It returns
+	// NotFoundError for unknown and expired challenges and for challenges
+	// without attempts left.
+	UseAttempt(id uuid.UUID, maxAttempts int, now time.Time) (*Challenge, error)
+	// Delete returns NotFoundError for unknown challenges.
 	Delete(id uuid.UUID) error
 }
 
-//go:generate mockery --name EmailService --filename email_service.go
-type EmailService interface {
-	SendEmailToUser(from string, userID uuid.UUID, msg []byte) error
+//go:generate mockery --name RateLimiter --filename rate_limiter.go
+type RateLimiter interface {
+	Allow(scope ratelimit.Scope, key string) error
+}
+
+//go:generate mockery --name AuditLog --filename audit_log.go
+type AuditLog interface {
+	Record(event audit.Event) error
+}
+
+//go:generate mockery --name LockoutService --filename lockout_service.go
+type LockoutService interface {
+	Check(userID uuid.UUID) error
+	RecordFailure(userID uuid.UUID) error
+	RecordSuccess(userID uuid.UUID) error
 }
 
 func NewAuthService(
 	refershTokenStorage RefreshTokenStorage,
-	emailService EmailService,
+	challengeStorage ChallengeStorage,
+	roleStorage RoleStorage,
+	notifier Notifier,
+	riskEngine RiskEngine,
+	locator Locator,
+	rateLimiter RateLimiter,
+	lockoutService LockoutService,
+	auditLog AuditLog,
 	jwtPrivateKey []byte,
 	accessTokenDuration time.Duration,
 	refreshTokenDuration time.Duration,
-	emails config.Emails) *AuthService {
+	stepUp config.StepUp) *AuthService {
 	return &AuthService{
 		refreshTokenStorage:  refershTokenStorage,
-		emailService:         emailService,
+		challengeStorage:     challengeStorage,
+		roleStorage:          roleStorage,
+		notifier:             notifier,
+		riskEngine:           riskEngine,
+		locator:              locator,
+		rateLimiter:          rateLimiter,
+		lockoutService:       lockoutService,
+		auditLog:             auditLog,
 		accessTokenDuration:  accessTokenDuration,
 		refreshTokenDuration: refreshTokenDuration,
 		jwtPrivateKey:        jwtPrivateKey,
-		Emails:               emails,
+		codeKey:              keyutils.Derive(jwtPrivateKey, "step-up otp"),
+		stepUp:               stepUp,
 	}
 }
 
-func (s *AuthService) CreateAccessAndRefreshTokens(userID uuid.UUID, requestIP string) (string, string, error) {
+// CreateAccessAndRefreshTokens issues a new session for the user. Roles and
+// permissions are read from the current assignments, so a refresh picks up
+// any change made since the previous token was issued. Scope and audience
+// restrictions are stored with the refresh token and survive rotation, as
+// does the time and method of the original authentication. The refresh token
+// is empty for impersonation sessions and with WithoutRefreshToken.
+func (s *AuthService) CreateAccessAndRefreshTokens(userID uuid.UUID, requestIP string, opts ...TokenOption) (string, string, error) {
+	options := newTokenOptions(opts)
+
+	roles, permissions, err := s.roleStorage.GetUserRolesAndPermissions(userID)
+	if err != nil {
+		return "", "", errors.Wrap(err, "get user roles and permissions")
+	}
+
+	claims := jwt.MapClaims{
+		UserIDClaim:   userID.String(),
+		UserIPClaim:   requestIP,
+		ExpTimeClaim:  time.Now().Add(options.accessDuration(s.accessTokenDuration)).Unix(),
+		RolesClaim:    rolesClaim(roles),
+		ScopeClaim:    scopeClaim(options.grantedScopes(permissions)),
+		AuthTimeClaim: options.authTime.Unix(),
+	}
+	if len(options.audience) > 0 {
+		claims[AudienceClaim] = options.audience
+	}
+	if len(options.authMethods) > 0 {
+		claims[AuthMethodsClaim] = options.authMethods
+	}
+	if options.impersonator != uuid.Nil {
+		claims[ActorClaim] = map[string]any{UserIDClaim: options.impersonator.String()}
+	}
+	if options.apiKeyID != uuid.Nil {
+		claims[APIKeyIDClaim] = options.apiKeyID.String()
+	}
+	if options.noRefreshToken {
+		access, err := s.signAccessToken(claims)
+		if err != nil {
+			return "", "", err
+		}
+		s.recordSessionEvent(userID, requestIP, options, uuid.Nil)
+		return access, "", nil
+	}
+
 	refreshBytes, err := generateRefreshTokenBytes()
 	if err != nil {
 		return "", "", errors.Wrap(err, "generate refresh token bytes")
@@ -61,33 +202,219 @@ func (s *AuthService) CreateAccessAndRefreshTokens(userID uuid.UUID, requestIP s
 		return "", "", errors.Wrap(err, "hash refresh token")
 	}
 
-	refreshExpTime := time.Now().Add(s.refreshTokenDuration)
+	now := time.Now()
+	refreshExpTime := now.Add(s.refreshTokenDuration)
+	refreshID := options.refreshTokenID
+	if refreshID == uuid.Nil {
+		refreshID = uuid.New()
+	}
+	familyID := options.familyID
+	if familyID == uuid.Nil {
+		familyID = refreshID
+	}
 	refresh := &RefreshToken{
-		Hash:      refreshHash,
-		ExpiresAt: refreshExpTime,
+		ID:             refreshID,
+		FamilyID:       familyID,
+		UserID:         userID,
+		Hash:           refreshHash,
+		ExpiresAt:      refreshExpTime,
+		Scopes:         options.scopes,
+		Audience:       options.audience,
+		AuthTime:       options.authTime,
+		AuthMethods:    options.authMethods,
+		AcceptLanguage: options.acceptLanguage,
+		IP:             requestIP,
+		UserAgent:      options.userAgent,
+		Location:       s.locate(requestIP),
+		CreatedAt:      now,
+	}
+	event, err := newSessionEvent(userID, requestIP, options, refresh.ID)
+	if err != nil {
+		return "", "", errors.Wrap(err, "create session event")
+	}
+	var refreshTokenID uuid.UUID
+	if options.rotatedFrom != uuid.Nil {
+		refreshTokenID, err = s.refreshTokenStorage.Rotate(options.rotatedFrom, refresh, event)
+	} else {
+		refreshTokenID, err = s.refreshTokenStorage.Create(refresh, event)
 	}
-	refreshTokenID, err := s.refreshTokenStorage.Create(refresh)
 	if err != nil {
 		return "", "", errors.Wrap(err, "create refresh token")
 	}
 
-	accessExpTime := time.Now().Add(s.accessTokenDuration).Unix()
-	accessJWT := jwt.NewWithClaims(jwtSigningMethod,
-		jwt.MapClaims{
-			UserIDClaim:         userID.String(),
-			UserIPClaim:         requestIP,
-			ExpTimeClaim:        accessExpTime,
-			RefreshTokenIDClaim: refreshTokenID,
-		})
-	access, err := accessJWT.SignedString(s.jwtPrivateKey)
+	claims[RefreshTokenIDClaim] = refreshTokenID
+	access, err := s.signAccessToken(claims)
 	if err != nil {
-		return "", "", errors.Wrap(err, "sign access token")
+		return "", "", err
 	}
+	s.recordSessionEvent(userID, requestIP, options, refreshTokenID)
 
 	return access, string(refreshBytes), nil
 }
 
-func (s *AuthService) RefreshAccessToken(accessToken, refreshTokenStr, requestIP string) (string, string, error) {
+// locate returns nil without a locator and when the lookup fails, since the
+// location only informs the user.
+func (s *AuthService) locate(ip string) *geoip.Location {
+	if s.locator == nil {
+		return nil
+	}
+	location, err := s.locator.Locate(ip)
+	if err != nil {
+		logutils.Error("locate ip error", err)
+		return nil
+	}
+	return location
+}
+
+// recordSessionEvent records a new session, or the refresh of one.
+func (s *AuthService) recordSessionEvent(userID uuid.UUID, requestIP string, options *tokenOptions, refreshTokenID uuid.UUID) {
+	event := audit.Event{
+		Type:    audit.EventSessionCreated,
+		UserID:  userID,
+		IP:      requestIP,
+		Details: map[string]string{},
+	}
+	if refreshTokenID != uuid.Nil {
+		event.Details["refreshTokenId"] = refreshTokenID.String()
+	}
+	if options.rotatedFrom != uuid.Nil {
+		event.Type = audit.EventSessionRefreshed
+		event.Details["previousRefreshTokenId"] = options.rotatedFrom.String()
+	}
+	if options.impersonator != uuid.Nil {
+		event.Details["impersonator"] = options.impersonator.String()
+	}
+	if options.apiKeyID != uuid.Nil {
+		event.Details["apiKeyId"] = options.apiKeyID.String()
+	}
+	s.recordEvent(event)
+}
+
+// newSessionEvent describes a new session, or the refresh of one, to the
+// webhooks.
+func newSessionEvent(userID uuid.UUID, requestIP string, options *tokenOptions, refreshTokenID uuid.UUID) (*webhook.Event, error) {
+	payload := webhook.SessionPayload{
+		UserID:    userID,
+		SessionID: refreshTokenID,
+		IP:        requestIP,
+	}
+	if options.rotatedFrom != uuid.Nil {
+		payload.PreviousSessionID = &options.rotatedFrom
+		return webhook.NewEvent(webhook.EventSessionRefreshed, payload)
+	}
+	return webhook.NewEvent(webhook.EventSessionCreated, payload)
+}
+
+// recordEvent appends to the audit log. Failures are logged and do not fail
+// the operation, like failures to send emails.
+func (s *AuthService) recordEvent(event audit.Event) {
+	if err := s.auditLog.Record(event); err != nil {
+		logutils.Error("record audit event error", err)
+	}
+}
+
+func (s *AuthService) signAccessToken(claims jwt.MapClaims) (string, error) {
+	accessJWT := jwt.NewWithClaims(jwtSigningMethod, claims)
+	access, err := accessJWT.SignedString(s.jwtPrivateKey)
+	if err != nil {
+		return "", errors.Wrap(err, "sign access token")
+	}
+
+	return access, nil
+}
+
+// CreateClientAccessToken issues a token that represents an OAuth client
+// rather than a user. The scopes are granted as is: they are checked against
+// the client registration, not against user permissions. The token has no
+// refresh token and is not bound to an IP. WithLifetime is the only option
+// that applies.
+func (s *AuthService) CreateClientAccessToken(clientID string, scopes []string, opts ...TokenOption) (string, error) {
+	options := newTokenOptions(opts)
+	claims := jwt.MapClaims{
+		UserIDClaim:   clientID,
+		ClientIDClaim: clientID,
+		ExpTimeClaim:  time.Now().Add(options.accessDuration(s.accessTokenDuration)).Unix(),
+		ScopeClaim:    scopeClaim(scopes),
+	}
+
+	return s.signAccessToken(claims)
+}
+
+// VerifyAccessToken checks a token issued by this service and returns its
+// claims.
+func (s *AuthService) VerifyAccessToken(accessToken string) (*authmw.Claims, error) {
+	verifier := authmw.NewVerifier(authmw.SharedSecret(s.jwtPrivateKey), "", 0)
+	claims, err := verifier.Verify(context.Background(), accessToken)
+	if err != nil {
+		return nil, UnauthorizedError{err.Error()}
+	}
+
+	return claims, nil
+}
+
+// Delegation describes a token that lets Actor call Audience on behalf of
+// the user of an existing token.
+type Delegation struct {
+	Subject  *authmw.Claims
+	Actor    string
+	Audience string
+	Scopes   []string
+}
+
+// CreateDelegatedAccessToken issues the token of an RFC 8693 token exchange.
+// Scopes the user no longer has a permission for are dropped, and the token
+// never outlives the subject token. The "act" claim names the actor, nested
+// with the actor of the subject token when that was delegated itself. The
+// token is not bound to an IP, since the actor presents it.
+func (s *AuthService) CreateDelegatedAccessToken(delegation Delegation) (string, time.Time, error) {
+	userID, err := uuid.Parse(delegation.Subject.Subject)
+	if err != nil {
+		return "", time.Time{}, errors.Wrap(err, "parse subject")
+	}
+	roles, permissions, err := s.roleStorage.GetUserRolesAndPermissions(userID)
+	if err != nil {
+		return "", time.Time{}, errors.Wrap(err, "get user roles and permissions")
+	}
+
+	expiresAt := time.Now().Add(s.accessTokenDuration)
+	if delegation.Subject.ExpiresAt.Before(expiresAt) {
+		expiresAt = delegation.Subject.ExpiresAt
+	}
+	actor := map[string]any{UserIDClaim: delegation.Actor}
+	if previousActor, ok := delegation.Subject.Raw[ActorClaim]; ok {
+		actor[ActorClaim] = previousActor
+	}
+
+	options := newTokenOptions([]TokenOption{WithScopes(delegation.Scopes)})
+	claims := jwt.MapClaims{
+		UserIDClaim:   userID.String(),
+		AudienceClaim: []string{delegation.Audience},
+		ExpTimeClaim:  expiresAt.Unix(),
+		RolesClaim:    rolesClaim(roles),
+		ScopeClaim:    scopeClaim(options.grantedScopes(permissions)),
+		ActorClaim:    actor,
+	}
+	if !delegation.Subject.AuthTime.IsZero() {
+		claims[AuthTimeClaim] = delegation.Subject.AuthTime.Unix()
+	}
+	if len(delegation.Subject.AuthMethods) > 0 {
+		claims[AuthMethodsClaim] = delegation.Subject.AuthMethods
+	}
+	access, err := s.signAccessToken(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return access, expiresAt, nil
+}
+
+// RefreshAccessToken rotates the refresh token. Refreshes are limited per user
+// and per refresh token, and failed comparisons of the refresh token lock the
+// account out progressively. Both are checked before the comparison and
+// return ratelimit.ExceededError and lockout.LockedError. The refresh is
+// assessed for risk: it may return RiskDeniedError, or ChallengeRequiredError
+// and keep the refresh token until ConfirmRefresh.
+func (s *AuthService) RefreshAccessToken(accessToken, refreshTokenStr, requestIP, userAgent string) (string, string, error) {
 	jwtToken, err := jwtutils.ParseAndValidateJWTToken(accessToken, s.jwtPrivateKey, jwtSigningMethod.Name)
 	if err != nil {
 		return "", "", UnauthorizedError{"parse error"}
@@ -98,8 +425,29 @@ func (s *AuthService) RefreshAccessToken(accessToken, refreshTokenStr, requestIP
 		return "", "", UnauthorizedError{}
 	}
 
+	if err := s.rateLimiter.Allow(ratelimit.ScopeUser, jwtClaims.userID.String()); err != nil {
+		return "", "", err
+	}
+	if err := s.rateLimiter.Allow(ratelimit.ScopeRefreshToken, jwtClaims.refreshTokenID.String()); err != nil {
+		return "", "", err
+	}
+	if err := s.lockoutService.Check(jwtClaims.userID); err != nil {
+		return "", "", err
+	}
+
 	refreshToken, err := s.refreshTokenStorage.Get(jwtClaims.refreshTokenID)
 	if err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			// the access token is genuine, so its refresh token was
+			// rotated or revoked before
+			s.recordEvent(audit.Event{
+				Type:    audit.EventReuseDetected,
+				UserID:  jwtClaims.userID,
+				IP:      requestIP,
+				Details: map[string]string{"refreshTokenId": jwtClaims.refreshTokenID.String()},
+			})
+		}
 		return "", "", UnauthorizedError{}
 	}
 
@@ -108,28 +456,243 @@ func (s *AuthService) RefreshAccessToken(accessToken, refreshTokenStr, requestIP
 	}
 
 	if bcrypt.CompareHashAndPassword(refreshToken.Hash, []byte(refreshTokenStr)) != nil {
+		if err := s.lockoutService.RecordFailure(jwtClaims.userID); err != nil {
+			logutils.Error("record failed authentication error", err)
+		}
 		return "", "", UnauthorizedError{}
 	}
+	if err := s.lockoutService.RecordSuccess(jwtClaims.userID); err != nil {
+		logutils.Error("reset failed authentications error", err)
+	}
 
-	if requestIP != jwtClaims.userIP {
-		err := s.emailService.SendEmailToUser(
-			s.Emails.SupportEmail,
-			jwtClaims.userID,
-			RefreshRequestNewIPEmail(requestIP))
-		if err != nil {
-			logutils.Error("send email error", err)
+	ipChanged := requestIP != jwtClaims.userIP
+	if ipChanged {
+		s.recordEvent(audit.Event{
+			Type:    audit.EventIPChanged,
+			UserID:  jwtClaims.userID,
+			IP:      requestIP,
+			Details: map[string]string{"previousIp": jwtClaims.userIP},
+		})
+	}
+
+	newRefreshTokenID := uuid.New()
+	assessment := s.assess(risk.Request{
+		UserID:            jwtClaims.userID,
+		SessionID:         newRefreshTokenID,
+		PreviousSessionID: refreshToken.ID,
+		Event:             risk.EventRefresh,
+		IP:                requestIP,
+		PreviousIP:        jwtClaims.userIP,
+		UserAgent:         userAgent,
+	})
+	decision := s.decide(assessment, jwtClaims.userID, jwtClaims.userIP, requestIP)
+	switch decision {
+	case risk.DecisionDeny:
+		return "", "", RiskDeniedError{}
+	case risk.DecisionChallenge:
+		challenge := &Challenge{
+			UserID:         jwtClaims.userID,
+			RefreshTokenID: refreshToken.ID,
+			SessionID:      newRefreshTokenID,
+			IP:             requestIP,
 		}
+		return "", "", s.challenge(challenge, refreshToken.AcceptLanguage, assessment)
 	}
 
-	go func() {
-		err := s.refreshTokenStorage.Delete(refreshToken.ID)
-		if err != nil {
-			logutils.Error("delete old refresh token error", err)
+	access, refresh, err := s.rotate(jwtClaims.userID, refreshToken, newRefreshTokenID, requestIP, userAgent)
+	if err != nil {
+		return "", "", err
+	}
+
+	if decision == risk.DecisionNotify {
+		s.notifyNewIP(jwtClaims.userID, refreshToken.FamilyID, jwtClaims.userIP, requestIP, refreshToken.AcceptLanguage)
+	}
+
+	return access, refresh, nil
+}
+
+// CreateSession issues a new session like CreateAccessAndRefreshTokens, for a
+// user signing in from requestIP. The sign-in is assessed for risk: it may
+// return RiskDeniedError, or ChallengeRequiredError and issue the tokens
+// with ConfirmRefresh.
+func (s *AuthService) CreateSession(userID uuid.UUID, requestIP, userAgent string, opts ...TokenOption) (string, string, error) {
+	sessionID := uuid.New()
+	assessment := s.assess(risk.Request{
+		UserID:    userID,
+		SessionID: sessionID,
+		Event:     risk.EventCreate,
+		IP:        requestIP,
+		UserAgent: userAgent,
+	})
+	decision := s.decide(assessment, userID, "", requestIP)
+	if decision == risk.DecisionDeny {
+		return "", "", RiskDeniedError{}
+	}
+
+	opts = append(opts, WithUserAgent(userAgent), withRefreshTokenID(sessionID))
+	options := newTokenOptions(opts)
+	if decision == risk.DecisionChallenge {
+		// the session is created once the challenge is confirmed
+		challenge := &Challenge{
+			UserID:    userID,
+			Session:   newPendingSession(options),
+			SessionID: sessionID,
+			IP:        requestIP,
+		}
+		return "", "", s.challenge(challenge, options.acceptLanguage, assessment)
+	}
+
+	access, refresh, err := s.CreateAccessAndRefreshTokens(userID, requestIP, opts...)
+	if err != nil {
+		return "", "", err
+	}
+	if decision == risk.DecisionNotify {
+		s.notifyNewIP(userID, sessionID, "", requestIP, options.acceptLanguage)
+	}
+
+	return access, refresh, nil
+}
+
+// assess returns nil when the risk engine is disabled or fails.
+func (s *AuthService) assess(req risk.Request) *risk.Assessment {
+	assessment, err := s.riskEngine.Assess(req)
+	if err != nil {
+		logutils.Error("assess risk error", err)
+		return nil
+	}
+	return assessment
+}
+
+// decide returns the decision of the assessment. Challenges require step-up
+// and only notify without it. Without an assessment, a change of IP address
+// notifies, and with step-up, a change to a network the user does not know
+// challenges.
+func (s *AuthService) decide(assessment *risk.Assessment, userID uuid.UUID, previousIP, ip string) string {
+	if assessment != nil {
+		if assessment.Decision == risk.DecisionChallenge && !s.stepUp.Enabled {
+			return risk.DecisionNotify
+		}
+		return assessment.Decision
+	}
+
+	if previousIP == "" || previousIP == ip {
+		return risk.DecisionAllow
+	}
+	if !s.stepUp.Enabled {
+		return risk.DecisionNotify
+	}
+	known, err := s.notifier.IsKnownIP(userID, previousIP, ip)
+	if err != nil {
+		// fail closed, the user can still confirm the code
+		logutils.Error("check known ip error", err)
+	}
+	if !known {
+		return risk.DecisionChallenge
+	}
+	return risk.DecisionNotify
+}
+
+// notifyNewIP alerts the user of the session. The alert links to the
+// session, so that the user may revoke it.
+func (s *AuthService) notifyNewIP(userID, sessionID uuid.UUID, previousIP, ip, acceptLanguage string) {
+	sent, err := s.notifier.NotifyNewIP(userID, sessionID, previousIP, ip, acceptLanguage)
+	if err != nil {
+		logutils.Error("notify new ip error", err)
+	} else if sent {
+		s.recordEvent(audit.Event{
+			Type:    audit.EventEmailSent,
+			UserID:  userID,
+			IP:      ip,
+			Details: map[string]string{"email": "refresh_request_new_ip"},
+		})
+	}
+}
+
+// rotate replaces the refresh token with newRefreshTokenID of the same
+// session, issued to the client at requestIP with userAgent. A refresh token
+// that was rotated concurrently is reused, and the rotation is refused.
+func (s *AuthService) rotate(userID uuid.UUID, refreshToken *RefreshToken, newRefreshTokenID uuid.UUID, requestIP, userAgent string) (string, string, error) {
+	opts := []TokenOption{
+		rotating(refreshToken, newRefreshTokenID),
+		WithAuthTime(refreshToken.AuthTime),
+		WithAuthMethods(refreshToken.AuthMethods...),
+		WithAcceptLanguage(refreshToken.AcceptLanguage),
+		WithUserAgent(userAgent),
+	}
+	if refreshToken.Scopes != nil {
+		opts = append(opts, WithScopes(refreshToken.Scopes))
+	}
+	if len(refreshToken.Audience) > 0 {
+		opts = append(opts, WithAudience(refreshToken.Audience...))
+	}
+
+	access, refresh, err := s.CreateAccessAndRefreshTokens(userID, requestIP, opts...)
+	if err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			s.recordEvent(audit.Event{
+				Type:    audit.EventReuseDetected,
+				UserID:  userID,
+				IP:      requestIP,
+				Details: map[string]string{"refreshTokenId": refreshToken.ID.String()},
+			})
+			return "", "", UnauthorizedError{}
+		}
+		return "", "", err
+	}
+
+	return access, refresh, nil
+}
+
+// RevokeSession deletes the refresh token of the session, so that it ends
+// when the access token expires. It returns UnauthorizedError when the
+// refresh token no longer exists, and records no revocation then.
+func (s *AuthService) RevokeSession(accessToken, requestIP string) error {
+	jwtToken, err := jwtutils.ParseAndValidateJWTToken(accessToken, s.jwtPrivateKey, jwtSigningMethod.Name)
+	if err != nil {
+		return UnauthorizedError{"parse error"}
+	}
+	jwtClaims, err := parseJWTClaims(jwtToken)
+	if err != nil {
+		return UnauthorizedError{}
+	}
+
+	event, err := webhook.NewEvent(webhook.EventSessionRevoked, webhook.SessionPayload{
+		UserID:    jwtClaims.userID,
+		SessionID: jwtClaims.refreshTokenID,
+		IP:        requestIP,
+	})
+	if err != nil {
+		return errors.Wrap(err, "create session event")
+	}
+	if err := s.refreshTokenStorage.Delete(jwtClaims.refreshTokenID, event); err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			// rotated or revoked before, nothing was revoked
+			return UnauthorizedError{"session not found"}
 		}
-	}()
+		return errors.Wrap(err, "delete refresh token")
+	}
+	s.recordEvent(audit.Event{
+		Type:    audit.EventSessionRevoked,
+		UserID:  jwtClaims.userID,
+		IP:      requestIP,
+		Details: map[string]string{"refreshTokenId": jwtClaims.refreshTokenID.String()},
+	})
+
+	return nil
+}
+
+// ListSessions returns the sessions of the user that have not expired, the
+// most recently refreshed first.
+func (s *AuthService) ListSessions(userID uuid.UUID) ([]RefreshToken, error) {
+	sessions, err := s.refreshTokenStorage.ListByUser(userID, time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "list refresh tokens")
+	}
+	return sessions, nil
+}
 
-	return s.CreateAccessAndRefreshTokens(
-		jwtClaims.userID,
-		requestIP,
-	)
+func (s *AuthService) AccessTokenDuration() time.Duration {
+	return s.accessTokenDuration
 }
@@ -1,5 +1,11 @@
 package auth
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type UnauthorizedError struct {
 	message string
 }
@@ -7,3 +13,33 @@ type UnauthorizedError struct {
 func (err UnauthorizedError) Error() string {
 	return err.message
 }
+
+// ChallengeRequiredError holds a refresh until the code emailed to the user
+// is confirmed with ConfirmRefresh.
+type ChallengeRequiredError struct {
+	ChallengeID uuid.UUID
+	ExpiresAt   time.Time
+}
+
+func (err ChallengeRequiredError) Error() string {
+	return "the refresh must be confirmed with the emailed code"
+}
+
+// RiskDeniedError refuses a session the risk engine scored too high.
+type RiskDeniedError struct{}
+
+func (err RiskDeniedError) Error() string {
+	return "the session was denied for security reasons"
+}
+
+type NotFoundError struct {
+	message string
+}
+
+func (err NotFoundError) Error() string {
+	return err.message
+}
+
+func NewNotFoundError(message string) NotFoundError {
+	return NotFoundError{message: message}
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	jwtutils "auth/internal/utils/jwt"
@@ -20,6 +21,14 @@ const (
 	UserIPClaim         = "sub_ip"
 	RefreshTokenIDClaim = "refresh_token_id"
 	ExpTimeClaim        = "exp"
+	RolesClaim          = "roles"
+	ScopeClaim          = "scope"
+	AudienceClaim       = "aud"
+	ClientIDClaim       = "client_id"
+	AuthTimeClaim       = "auth_time"
+	AuthMethodsClaim    = "amr"
+	ActorClaim          = "act"
+	APIKeyIDClaim       = "api_key_id"
 )
 
 type jwtClaims struct {
@@ -29,6 +38,19 @@ type jwtClaims struct {
 	expTime        time.Time
 }
 
+func rolesClaim(roles []string) []string {
+	if roles == nil {
+		return []string{}
+	}
+	return roles
+}
+
+// scopeClaim formats permissions as the space-delimited "scope" claim of
+// RFC 9068.
+func scopeClaim(permissions []string) string {
+	return strings.Join(permissions, " ")
+}
+
 func parseJWTClaims(token *jwt.Token) (*jwtClaims, error) {
 	var claims jwtClaims
 	if claimsMap, ok := token.Claims.(jwt.MapClaims); ok {
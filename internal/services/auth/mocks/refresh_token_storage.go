@@ -7,7 +7,11 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	uuid "github.com/google/uuid"
+
+	webhook "auth/internal/services/webhook"
 )
 
 // RefreshTokenStorage is an autogenerated mock type for the RefreshTokenStorage type
@@ -15,9 +19,9 @@ type RefreshTokenStorage struct {
 	mock.Mock
 }
 
-// Create provides a mock function with given fields: token
-func (_m *RefreshTokenStorage) Create(token *auth.RefreshToken) (uuid.UUID, error) {
-	ret := _m.Called(token)
+// Create provides a mock function with given fields: token, event
+func (_m *RefreshTokenStorage) Create(token *auth.RefreshToken, event *webhook.Event) (uuid.UUID, error) {
+	ret := _m.Called(token, event)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Create")
@@ -25,19 +29,19 @@ func (_m *RefreshTokenStorage) Create(token *auth.RefreshToken) (uuid.UUID, erro
 
 	var r0 uuid.UUID
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*auth.RefreshToken) (uuid.UUID, error)); ok {
-		return rf(token)
+	if rf, ok := ret.Get(0).(func(*auth.RefreshToken, *webhook.Event) (uuid.UUID, error)); ok {
+		return rf(token, event)
 	}
-	if rf, ok := ret.Get(0).(func(*auth.RefreshToken) uuid.UUID); ok {
-		r0 = rf(token)
+	if rf, ok := ret.Get(0).(func(*auth.RefreshToken, *webhook.Event) uuid.UUID); ok {
+		r0 = rf(token, event)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(uuid.UUID)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*auth.RefreshToken) error); ok {
-		r1 = rf(token)
+	if rf, ok := ret.Get(1).(func(*auth.RefreshToken, *webhook.Event) error); ok {
+		r1 = rf(token, event)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -45,17 +49,17 @@ func (_m *RefreshTokenStorage) Create(token *auth.RefreshToken) (uuid.UUID, erro
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *RefreshTokenStorage) Delete(id uuid.UUID) error {
-	ret := _m.Called(id)
+// Delete provides a mock function with given fields: id, event
+func (_m *RefreshTokenStorage) Delete(id uuid.UUID, event *webhook.Event) error {
+	ret := _m.Called(id, event)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Delete")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uuid.UUID) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(uuid.UUID, *webhook.Event) error); ok {
+		r0 = rf(id, event)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -93,6 +97,66 @@ func (_m *RefreshTokenStorage) Get(id uuid.UUID) (*auth.RefreshToken, error) {
 	return r0, r1
 }
 
+// ListByUser provides a mock function with given fields: userID, now
+func (_m *RefreshTokenStorage) ListByUser(userID uuid.UUID, now time.Time) ([]auth.RefreshToken, error) {
+	ret := _m.Called(userID, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListByUser")
+	}
+
+	var r0 []auth.RefreshToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, time.Time) ([]auth.RefreshToken, error)); ok {
+		return rf(userID, now)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, time.Time) []auth.RefreshToken); ok {
+		r0 = rf(userID, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]auth.RefreshToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, time.Time) error); ok {
+		r1 = rf(userID, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Rotate provides a mock function with given fields: previousID, token, event
+func (_m *RefreshTokenStorage) Rotate(previousID uuid.UUID, token *auth.RefreshToken, event *webhook.Event) (uuid.UUID, error) {
+	ret := _m.Called(previousID, token, event)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Rotate")
+	}
+
+	var r0 uuid.UUID
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID, *auth.RefreshToken, *webhook.Event) (uuid.UUID, error)); ok {
+		return rf(previousID, token, event)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID, *auth.RefreshToken, *webhook.Event) uuid.UUID); ok {
+		r0 = rf(previousID, token, event)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(uuid.UUID)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID, *auth.RefreshToken, *webhook.Event) error); ok {
+		r1 = rf(previousID, token, event)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewRefreshTokenStorage creates a new instance of RefreshTokenStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewRefreshTokenStorage(t interface {
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// identityScopes are the OpenID Connect scopes. They request access to the
+// user's own profile and need no permission.
+var identityScopes = map[string]bool{
+	"openid":  true,
+	"profile": true,
+	"email":   true,
+}
+
+// maxAcceptLanguageLength and maxUserAgentLength bound the stored headers.
+const (
+	maxAcceptLanguageLength = 256
+	maxUserAgentLength      = 512
+)
+
+type TokenOption func(*tokenOptions)
+
+type tokenOptions struct {
+	// scopes restricts the "scope" claim. nil means all user permissions.
+	scopes      []string
+	audience    []string
+	authTime    time.Time
+	authMethods []string
+	// impersonator is the admin acting as the user, uuid.Nil for the
+	// user's own sessions.
+	impersonator uuid.UUID
+	// apiKeyID is the key the token is exchanged for, uuid.Nil for
+	// sessions.
+	apiKeyID       uuid.UUID
+	lifetime       time.Duration
+	noRefreshToken bool
+	// rotatedFrom is the refresh token a refresh replaces, uuid.Nil for new
+	// sessions. familyID is the session it belongs to.
+	rotatedFrom uuid.UUID
+	familyID    uuid.UUID
+	// refreshTokenID is the ID of the new refresh token, a random one when
+	// uuid.Nil.
+	refreshTokenID uuid.UUID
+	acceptLanguage string
+	userAgent      string
+}
+
+// WithScopes restricts the token to the granted scopes. Scopes the user has
+// no permission for are dropped, so revoking a permission takes effect at the
+// next refresh even for restricted tokens.
+func WithScopes(scopes []string) TokenOption {
+	return func(opts *tokenOptions) {
+		if scopes == nil {
+			scopes = []string{}
+		}
+		opts.scopes = scopes
+	}
+}
+
+// WithAudience sets the "aud" claim, e.g. to the OAuth client the token is
+// issued to.
+func WithAudience(audience ...string) TokenOption {
+	return func(opts *tokenOptions) {
+		opts.audience = audience
+	}
+}
+
+// WithAuthTime keeps the time the user authenticated at when a session is
+// derived from an earlier one. Without it the session counts as a new
+// authentication.
+func WithAuthTime(authTime time.Time) TokenOption {
+	return func(opts *tokenOptions) {
+		opts.authTime = authTime
+	}
+}
+
+// WithAuthMethods sets the "amr" claim (RFC 8176).
+func WithAuthMethods(methods ...string) TokenOption {
+	return func(opts *tokenOptions) {
+		opts.authMethods = methods
+	}
+}
+
+// WithImpersonator marks the session as an admin acting as the user: the "act"
+// claim names the admin. Impersonation sessions cannot be refreshed, no
+// refresh token is issued.
+func WithImpersonator(adminID uuid.UUID) TokenOption {
+	return func(opts *tokenOptions) {
+		opts.impersonator = adminID
+		opts.noRefreshToken = true
+	}
+}
+
+// WithAPIKey marks the token as exchanged for the API key, so that it is not
+// taken for a session of the user. No refresh token is issued.
+func WithAPIKey(keyID uuid.UUID) TokenOption {
+	return func(opts *tokenOptions) {
+		opts.apiKeyID = keyID
+		opts.noRefreshToken = true
+	}
+}
+
+// WithoutRefreshToken issues a single access token, e.g. for an API key
+// that can be exchanged again instead.
+func WithoutRefreshToken() TokenOption {
+	return func(opts *tokenOptions) {
+		opts.noRefreshToken = true
+	}
+}
+
+// WithLifetime shortens the access token lifetime. It cannot be extended
+// beyond the configured duration.
+func WithLifetime(lifetime time.Duration) TokenOption {
+	return func(opts *tokenOptions) {
+		opts.lifetime = lifetime
+	}
+}
+
+// WithAcceptLanguage keeps the Accept-Language of the request that started
+// the session. Emails about the session are in this language for users
+// without a locale.
+func WithAcceptLanguage(acceptLanguage string) TokenOption {
+	return func(opts *tokenOptions) {
+		if len(acceptLanguage) > maxAcceptLanguageLength {
+			acceptLanguage = acceptLanguage[:maxAcceptLanguageLength]
+		}
+		opts.acceptLanguage = acceptLanguage
+	}
+}
+
+// WithUserAgent keeps the User-Agent of the request that issued the tokens,
+// to tell the sessions of a user apart.
+func WithUserAgent(userAgent string) TokenOption {
+	return func(opts *tokenOptions) {
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+		opts.userAgent = userAgent
+	}
+}
+
+// withRefreshTokenID issues the refresh token with the given ID.
+func withRefreshTokenID(id uuid.UUID) TokenOption {
+	return func(opts *tokenOptions) {
+		opts.refreshTokenID = id
+	}
+}
+
+// rotating marks the tokens as the refresh of a session, whose refresh token
+// rotates from the given one to the ID to.
+func rotating(from *RefreshToken, to uuid.UUID) TokenOption {
+	return func(opts *tokenOptions) {
+		opts.rotatedFrom = from.ID
+		opts.familyID = from.FamilyID
+		opts.refreshTokenID = to
+	}
+}
+
+func newTokenOptions(opts []TokenOption) *tokenOptions {
+	var options tokenOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.authTime.IsZero() {
+		options.authTime = time.Now()
+	}
+	return &options
+}
+
+// accessDuration is the lifetime of the access token, the configured one
+// unless WithLifetime shortened it.
+func (opts *tokenOptions) accessDuration(configured time.Duration) time.Duration {
+	if opts.lifetime > 0 && opts.lifetime < configured {
+		return opts.lifetime
+	}
+	return configured
+}
+
+// grantedScopes returns the scopes to put into the token.
+func (opts *tokenOptions) grantedScopes(permissions []string) []string {
+	if opts.scopes == nil {
+		return permissions
+	}
+
+	userPermissions := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		userPermissions[permission] = true
+	}
+	granted := make([]string, 0, len(opts.scopes))
+	for _, scope := range opts.scopes {
+		if userPermissions[scope] || identityScopes[scope] {
+			granted = append(granted, scope)
+		}
+	}
+	return granted
+}
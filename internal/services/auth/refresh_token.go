@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"auth/internal/services/geoip"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,9 +9,30 @@ import (
 )
 
 type RefreshToken struct {
-	ID        uuid.UUID
+	ID uuid.UUID
+	// FamilyID identifies the session across rotations: it is the ID of the
+	// first refresh token of the session and is kept by its successors.
+	FamilyID  uuid.UUID
+	UserID    uuid.UUID
 	Hash      []byte
 	ExpiresAt time.Time
+	// Scopes restricts the sessions issued from this token, nil means
+	// unrestricted.
+	Scopes   []string
+	Audience []string
+	// AuthTime and AuthMethods describe the authentication the session
+	// started with.
+	AuthTime    time.Time
+	AuthMethods []string
+	// AcceptLanguage is the Accept-Language of the request that started the
+	// session.
+	AcceptLanguage string
+	// IP, UserAgent and Location describe the request that issued the
+	// token. Location is nil when the address is not in the GeoIP databases.
+	IP        string
+	UserAgent string
+	Location  *geoip.Location
+	CreatedAt time.Time
 }
 
 func generateRefreshTokenBytes() ([]byte, error) {
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"auth/internal/services/audit"
+	"auth/internal/services/risk"
+	logutils "auth/internal/utils/log"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Challenge holds the rotation of a refresh token, or the creation of a
+// session, until the code emailed to the user is confirmed. RefreshTokenID is
+// the refresh token to rotate, uuid.Nil when Session is the session to
+// create. SessionID is the refresh token the confirmation issues,
+// AssessmentID the risk assessment that required the challenge, uuid.Nil
+// without one.
+type Challenge struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	RefreshTokenID uuid.UUID
+	Session        *PendingSession
+	SessionID      uuid.UUID
+	AssessmentID   uuid.UUID
+	CodeHash       []byte
+	IP             string
+	Attempts       int
+	ExpiresAt      time.Time
+	CreatedAt      time.Time
+}
+
+// PendingSession keeps what the refresh token of a new session would keep,
+// so that the session is created only once the challenge is confirmed. The
+// client is the one that confirms.
+type PendingSession struct {
+	// Scopes is nil for all user permissions, like RefreshToken.Scopes.
+	Scopes         []string  `json:"scopes"`
+	Audience       []string  `json:"audience,omitempty"`
+	AuthTime       time.Time `json:"authTime"`
+	AuthMethods    []string  `json:"amr,omitempty"`
+	AcceptLanguage string    `json:"acceptLanguage,omitempty"`
+}
+
+func newPendingSession(options *tokenOptions) *PendingSession {
+	return &PendingSession{
+		Scopes:         options.scopes,
+		Audience:       options.audience,
+		AuthTime:       options.authTime,
+		AuthMethods:    options.authMethods,
+		AcceptLanguage: options.acceptLanguage,
+	}
+}
+
+func (session *PendingSession) tokenOptions() []TokenOption {
+	opts := []TokenOption{
+		WithAuthTime(session.AuthTime),
+		WithAuthMethods(session.AuthMethods...),
+		WithAcceptLanguage(session.AcceptLanguage),
+	}
+	if session.Scopes != nil {
+		opts = append(opts, WithScopes(session.Scopes))
+	}
+	if len(session.Audience) > 0 {
+		opts = append(opts, WithAudience(session.Audience...))
+	}
+	return opts
+}
+
+// challenge completes and stores the challenge and emails its code in
+// acceptLanguage. It returns ChallengeRequiredError on success.
+func (s *AuthService) challenge(challenge *Challenge, acceptLanguage string, assessment *risk.Assessment) error {
+	code, err := newCode()
+	if err != nil {
+		return errors.Wrap(err, "generate code")
+	}
+
+	now := time.Now()
+	challenge.ID = uuid.New()
+	challenge.ExpiresAt = now.Add(s.stepUp.CodeDuration)
+	challenge.CreatedAt = now
+	if assessment != nil {
+		challenge.AssessmentID = assessment.ID
+	}
+	challenge.CodeHash = s.hashCode(challenge.ID, code)
+	if err := s.challengeStorage.Create(challenge); err != nil {
+		return errors.Wrap(err, "create challenge")
+	}
+	err = s.notifier.SendStepUpCode(challenge.UserID, code, challenge.IP, acceptLanguage, challenge.ExpiresAt)
+	if err != nil {
+		return errors.Wrap(err, "send code")
+	}
+
+	s.recordEvent(audit.Event{
+		Type:    audit.EventStepUpRequired,
+		UserID:  challenge.UserID,
+		IP:      challenge.IP,
+		Details: map[string]string{"challengeId": challenge.ID.String()},
+	})
+	return ChallengeRequiredError{ChallengeID: challenge.ID, ExpiresAt: challenge.ExpiresAt}
+}
+
+// ConfirmRefresh rotates the refresh token of the challenge, or creates its
+// session, once the code matches, and makes the network and the assessment
+// of the challenge known. The tokens are issued to the client at requestIP
+// with userAgent, which confirms.
+// Every attempt counts against the limit, and the challenge is removed on
+// success.
+func (s *AuthService) ConfirmRefresh(challengeID uuid.UUID, code, requestIP, userAgent string) (string, string, error) {
+	challenge, err := s.challengeStorage.UseAttempt(challengeID, s.stepUp.MaxAttempts, time.Now())
+	if err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return "", "", UnauthorizedError{"challenge not found"}
+		}
+		return "", "", errors.Wrap(err, "use attempt")
+	}
+	if requestIP != challenge.IP {
+		return "", "", UnauthorizedError{"the refresh must be confirmed from the same address"}
+	}
+	if !hmac.Equal(challenge.CodeHash, s.hashCode(challenge.ID, code)) {
+		s.recordEvent(audit.Event{
+			Type:   audit.EventStepUpFailed,
+			UserID: challenge.UserID,
+			IP:     requestIP,
+			Details: map[string]string{
+				"challengeId": challenge.ID.String(),
+				"attempts":    fmt.Sprint(challenge.Attempts),
+			},
+		})
+		return "", "", UnauthorizedError{"wrong code"}
+	}
+
+	// only one of concurrent confirmations deletes the challenge
+	if err := s.challengeStorage.Delete(challenge.ID); err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return "", "", UnauthorizedError{"challenge not found"}
+		}
+		return "", "", errors.Wrap(err, "delete challenge")
+	}
+
+	var refreshToken *RefreshToken
+	if challenge.Session == nil {
+		refreshToken, err = s.refreshTokenStorage.Get(challenge.RefreshTokenID)
+		if err != nil {
+			var notFoundErr NotFoundError
+			if errors.As(err, &notFoundErr) {
+				return "", "", UnauthorizedError{"refresh token not found"}
+			}
+			return "", "", errors.Wrap(err, "get refresh token")
+		}
+		if time.Now().After(refreshToken.ExpiresAt) {
+			return "", "", UnauthorizedError{"refresh token expired"}
+		}
+	}
+
+	if err := s.notifier.TrustIP(challenge.UserID, requestIP); err != nil {
+		return "", "", errors.Wrap(err, "trust ip")
+	}
+	if challenge.AssessmentID != uuid.Nil {
+		if err := s.riskEngine.Confirm(challenge.AssessmentID); err != nil {
+			logutils.Error("confirm assessment error", err)
+		}
+	}
+	s.recordEvent(audit.Event{
+		Type:    audit.EventStepUpConfirmed,
+		UserID:  challenge.UserID,
+		IP:      requestIP,
+		Details: map[string]string{"challengeId": challenge.ID.String()},
+	})
+
+	if refreshToken == nil {
+		opts := append(challenge.Session.tokenOptions(), WithUserAgent(userAgent), withRefreshTokenID(challenge.SessionID))
+		return s.CreateAccessAndRefreshTokens(challenge.UserID, requestIP, opts...)
+	}
+	return s.rotate(challenge.UserID, refreshToken, challenge.SessionID, requestIP, userAgent)
+}
+
+// newCode returns a random 6-digit code.
+func newCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashCode binds the code to the challenge, so that a leaked hash does not
+// reveal it.
+func (s *AuthService) hashCode(challengeID uuid.UUID, code string) []byte {
+	mac := hmac.New(sha256.New, s.codeKey)
+	mac.Write(challengeID[:])
+	mac.Write([]byte(code))
+	return mac.Sum(nil)
+}
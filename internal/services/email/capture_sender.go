@@ -0,0 +1,77 @@
+package email
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxCapturedEmails bounds the emails CaptureSender keeps, older ones are
+// dropped.
+const maxCapturedEmails = 200
+
+// CaptureSender keeps the emails in memory instead of sending them, for the
+// development mailbox.
+type CaptureSender struct {
+	mu     sync.Mutex
+	emails []CapturedEmail
+}
+
+func NewCaptureSender() *CaptureSender {
+	return &CaptureSender{}
+}
+
+func (s *CaptureSender) Send(msg *Outgoing) error {
+	captured := CapturedEmail{
+		ID:         uuid.New(),
+		Email:      *msg,
+		CapturedAt: time.Now(),
+	}
+	captured.Email.To = slices.Clone(msg.To)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emails = append(s.emails, captured)
+	if len(s.emails) > maxCapturedEmails {
+		s.emails = slices.Delete(s.emails, 0, len(s.emails)-maxCapturedEmails)
+	}
+	return nil
+}
+
+// List returns the captured emails, newest first. A non-empty to selects
+// the emails to that recipient.
+func (s *CaptureSender) List(to string) []CapturedEmail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	emails := make([]CapturedEmail, 0, len(s.emails))
+	for i := len(s.emails) - 1; i >= 0; i-- {
+		if to == "" || slices.Contains(s.emails[i].Email.To, to) {
+			emails = append(emails, s.emails[i])
+		}
+	}
+	return emails
+}
+
+func (s *CaptureSender) Get(id uuid.UUID) (*CapturedEmail, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, captured := range s.emails {
+		if captured.ID == id {
+			return &captured, nil
+		}
+	}
+	return nil, NewNotFoundError(fmt.Sprintf("email %s not found", id))
+}
+
+func (s *CaptureSender) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.emails = nil
+}
+
+var _ Sender = &CaptureSender{}
@@ -0,0 +1,180 @@
+package email
+
+import (
+	"auth/internal/config"
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// dkimHeaders are the headers signed when the message has them.
+var dkimHeaders = []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type"}
+
+// DKIMSigner adds a DKIM-Signature (RFC 6376) to messages, with relaxed
+// canonicalization of the header and the body.
+type DKIMSigner struct {
+	domain    string
+	selector  string
+	key       crypto.Signer
+	algorithm string
+}
+
+func NewDKIMSigner(cfg config.DKIM) (*DKIMSigner, error) {
+	block, _ := pem.Decode([]byte(cfg.PrivateKey))
+	if block == nil {
+		return nil, errors.New("dkim private key is not PEM encoded")
+	}
+
+	var key any
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "parse dkim private key")
+	}
+
+	s := &DKIMSigner{
+		domain:   cfg.Domain,
+		selector: cfg.Selector,
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		s.key, s.algorithm = key, "rsa-sha256"
+	case ed25519.PrivateKey:
+		s.key, s.algorithm = key, "ed25519-sha256"
+	default:
+		return nil, errors.Errorf("unsupported dkim key type %T", key)
+	}
+
+	return s, nil
+}
+
+// Sign returns the message with CRLF line endings and a DKIM-Signature
+// header.
+func (s *DKIMSigner) Sign(msg []byte) ([]byte, error) {
+	msg = toCRLF(msg)
+	header, body, ok := bytes.Cut(msg, []byte("\r\n\r\n"))
+	if !ok {
+		header, body = bytes.TrimSuffix(msg, []byte("\r\n")), nil
+	}
+	fields := splitHeaderFields(string(header))
+
+	bodyHash := sha256.Sum256(canonicalBodyRelaxed(body))
+	var signed []string
+	var canonical strings.Builder
+	for _, name := range dkimHeaders {
+		if field, ok := lastHeaderField(fields, name); ok {
+			signed = append(signed, strings.ToLower(name))
+			canonical.WriteString(canonicalHeaderRelaxed(field))
+		}
+	}
+
+	signature := fmt.Sprintf("DKIM-Signature: v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%d; h=%s; bh=%s; b=",
+		s.algorithm, s.domain, s.selector, time.Now().Unix(), strings.Join(signed, ":"),
+		base64.StdEncoding.EncodeToString(bodyHash[:]))
+	canonical.WriteString(strings.TrimSuffix(canonicalHeaderRelaxed(signature), "\r\n"))
+	hash := sha256.Sum256([]byte(canonical.String()))
+
+	// Ed25519 signs the SHA-256 hash itself (RFC 8463)
+	var opts crypto.SignerOpts = crypto.SHA256
+	if s.algorithm == "ed25519-sha256" {
+		opts = crypto.Hash(0)
+	}
+	b, err := s.key.Sign(rand.Reader, hash[:], opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "sign message")
+	}
+
+	out := make([]byte, 0, len(signature)+len(msg)+512)
+	out = append(out, signature...)
+	out = append(out, base64.StdEncoding.EncodeToString(b)...)
+	out = append(out, "\r\n"...)
+	out = append(out, msg...)
+	return out, nil
+}
+
+// toCRLF replaces bare LF line endings with CRLF.
+func toCRLF(msg []byte) []byte {
+	msg = bytes.ReplaceAll(msg, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(msg, []byte("\n"), []byte("\r\n"))
+}
+
+// splitHeaderFields returns the header fields with their folded lines.
+func splitHeaderFields(header string) []string {
+	var fields []string
+	for _, line := range strings.Split(header, "\r\n") {
+		if len(fields) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			fields[len(fields)-1] += "\r\n" + line
+			continue
+		}
+		fields = append(fields, line)
+	}
+	return fields
+}
+
+// lastHeaderField returns the bottom-most field with the name.
+func lastHeaderField(fields []string, name string) (string, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		fieldName, _, ok := strings.Cut(fields[i], ":")
+		if ok && strings.EqualFold(strings.TrimSpace(fieldName), name) {
+			return fields[i], true
+		}
+	}
+	return "", false
+}
+
+// canonicalHeaderRelaxed implements the "relaxed" header canonicalization
+// (RFC 6376, section 3.4.2).
+func canonicalHeaderRelaxed(field string) string {
+	name, value, _ := strings.Cut(field, ":")
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = strings.Join(strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == '\t' }), " ")
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + value + "\r\n"
+}
+
+// canonicalBodyRelaxed implements the "relaxed" body canonicalization
+// (RFC 6376, section 3.4.4).
+func canonicalBodyRelaxed(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		var b strings.Builder
+		prevWSP := false
+		for j := 0; j < len(line); j++ {
+			if isWSP(line[j]) {
+				prevWSP = true
+				continue
+			}
+			if prevWSP {
+				b.WriteByte(' ')
+				prevWSP = false
+			}
+			b.WriteByte(line[j])
+		}
+		lines[i] = b.String()
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+func isWSP(c byte) bool {
+	return c == ' ' || c == '\t'
+}
@@ -0,0 +1,173 @@
+package email
+
+import (
+	"auth/internal/config"
+	logutils "auth/internal/utils/log"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// batchSize is the number of messages a worker claims at once.
+const batchSize = 10
+
+// EmailQueue stores emails and sends them in the background, so that callers
+// do not wait for the SMTP server and failed emails are retried.
+type EmailQueue struct {
+	storage      QueueStorage
+	sender       UserEmailSender
+	workers      int
+	pollInterval time.Duration
+	lease        time.Duration
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+}
+
+//go:generate mockery --name QueueStorage --filename queue_storage.go
+type QueueStorage interface {
+	Enqueue(message *Message) error
+	// Claim returns up to limit pending messages that are due and postpones
+	// them by lease, so that other workers skip them while they are sent.
+	Claim(now time.Time, limit int, lease time.Duration) ([]Message, error)
+	Update(message *Message) error
+	List(filter Filter) ([]Message, error)
+}
+
+//go:generate mockery --name UserEmailSender --filename user_email_sender.go
+type UserEmailSender interface {
+	SendEmailToUser(from string, userID uuid.UUID, msg Email) error
+}
+
+func NewEmailQueue(storage QueueStorage, sender UserEmailSender, cfg config.EmailQueue) *EmailQueue {
+	return &EmailQueue{
+		storage:      storage,
+		sender:       sender,
+		workers:      cfg.Workers,
+		pollInterval: cfg.PollInterval,
+		lease:        cfg.Lease,
+		maxAttempts:  cfg.MaxAttempts,
+		baseBackoff:  cfg.BaseBackoff,
+		maxBackoff:   cfg.MaxBackoff,
+	}
+}
+
+// SendEmailToUser queues the email. It is sent by the workers.
+func (q *EmailQueue) SendEmailToUser(from string, userID uuid.UUID, email Email) error {
+	now := time.Now()
+	message := &Message{
+		ID:            uuid.New(),
+		From:          from,
+		UserID:        userID,
+		Email:         email,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+
+	return errors.Wrap(q.storage.Enqueue(message), "enqueue email")
+}
+
+// Run sends the queued emails until the context is done.
+func (q *EmailQueue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range q.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (q *EmailQueue) work(ctx context.Context) {
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+	for {
+		for ctx.Err() == nil {
+			n, err := q.Process()
+			if err != nil {
+				logutils.Error("process email queue error", err)
+				break
+			}
+			if n < batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Process sends one batch of due emails and returns the number of emails it
+// claimed.
+func (q *EmailQueue) Process() (int, error) {
+	messages, err := q.storage.Claim(time.Now(), batchSize, q.lease)
+	if err != nil {
+		return 0, errors.Wrap(err, "claim messages")
+	}
+	for i := range messages {
+		if err := q.send(&messages[i]); err != nil {
+			return 0, errors.Wrap(err, "send message")
+		}
+	}
+
+	return len(messages), nil
+}
+
+func (q *EmailQueue) List(filter Filter) ([]Message, error) {
+	if filter.Limit == 0 {
+		filter.Limit = 100
+	}
+	if filter.Limit < 0 || filter.Limit > 1000 {
+		return nil, ValidationError{"limit must be between 1 and 1000"}
+	}
+
+	messages, err := q.storage.List(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "list messages")
+	}
+
+	return messages, nil
+}
+
+// send sends the message once and records the outcome. Only storage errors
+// are returned, failed emails are retried later.
+func (q *EmailQueue) send(message *Message) error {
+	message.Attempts++
+	sendErr := q.sender.SendEmailToUser(message.From, message.UserID, message.Email)
+	switch {
+	case sendErr == nil:
+		message.Status = StatusSent
+		message.SentAt = time.Now()
+		message.Email.Data = nil
+		message.LastError = ""
+	case message.Attempts >= q.maxAttempts:
+		message.Status = StatusDead
+		message.LastError = sendErr.Error()
+	default:
+		message.NextAttemptAt = time.Now().Add(q.backoff(message.Attempts))
+		message.LastError = sendErr.Error()
+	}
+
+	return errors.Wrap(q.storage.Update(message), "update message")
+}
+
+// backoff doubles the delay after each attempt up to maxBackoff.
+func (q *EmailQueue) backoff(attempts int) time.Duration {
+	delay := q.baseBackoff
+	for i := 1; i < attempts && delay < q.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > q.maxBackoff {
+		delay = q.maxBackoff
+	}
+	return delay
+}
@@ -1,44 +1,79 @@
 package email
 
 import (
-	"auth/internal/config"
-	"net/smtp"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
 type EmailService struct {
-	smtpConfig  config.SMTP
+	sender      Sender
 	userStorage UserStorage
-	auth        smtp.Auth
+	renderer    *Renderer
+	signer      Signer
+}
+
+// Sender delivers the emails: SMTPSender, HTTPSender, FileSender,
+// CaptureSender or NoopSender.
+type Sender interface {
+	Send(msg *Outgoing) error
+}
+
+// Signer signs the outgoing messages, e.g. DKIMSigner.
+type Signer interface {
+	Sign(msg []byte) ([]byte, error)
 }
 
 type UserStorage interface {
-	GetUserEmail(userID uuid.UUID) (string, error)
+	GetUserContact(userID uuid.UUID) (*Contact, error)
 }
 
 func NewEmailService(
-	smtpConfig config.SMTP,
+	sender Sender,
 	userStorage UserStorage,
+	renderer *Renderer,
+	signer Signer,
 ) *EmailService {
-	auth := smtp.PlainAuth("", smtpConfig.UserName, smtpConfig.Password, smtpConfig.Host)
 	return &EmailService{
-		smtpConfig:  smtpConfig,
+		sender:      sender,
 		userStorage: userStorage,
-		auth:        auth,
+		renderer:    renderer,
+		signer:      signer,
 	}
 }
 
-func (s *EmailService) SendEmail(from string, to []string, msg []byte) error {
-	return smtp.SendMail(s.smtpConfig.Host+s.smtpConfig.Port, s.auth, from, to, msg)
-}
+// SendEmailToUser renders the email in the locale of the user, falling back
+// to the Accept-Language of the email, signs it, unless the signer is nil,
+// and sends it.
+func (s *EmailService) SendEmailToUser(from string, userID uuid.UUID, email Email) error {
+	contact, err := s.userStorage.GetUserContact(userID)
+	if err != nil {
+		return errors.Wrap(err, "get user contact")
+	}
 
-func (s *EmailService) SendEmailToUser(from string, userID uuid.UUID, msg []byte) error {
-	to, err := s.userStorage.GetUserEmail(userID)
+	locale := s.renderer.Locale(contact.Locale, email.AcceptLanguage)
+	rendered, err := s.renderer.Render(email, locale)
+	if err != nil {
+		return errors.Wrap(err, "render email")
+	}
+	raw, err := buildMessage(from, contact.Email, rendered, time.Now())
 	if err != nil {
-		return errors.Wrap(err, "get user email")
+		return errors.Wrap(err, "build message")
+	}
+	if s.signer != nil {
+		if raw, err = s.signer.Sign(raw); err != nil {
+			return errors.Wrap(err, "sign email")
+		}
 	}
 
-	return s.SendEmail(from, []string{to}, msg)
+	msg := &Outgoing{
+		From:    from,
+		To:      []string{contact.Email},
+		Subject: rendered.Subject,
+		Text:    rendered.Text,
+		HTML:    rendered.HTML,
+		Raw:     raw,
+	}
+	return errors.Wrap(s.sender.Send(msg), "send email")
 }
@@ -0,0 +1,48 @@
+package email
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FileSender delivers the raw emails to a maildir, e.g. for local
+// development. Each email is written to tmp and moved to new once complete.
+type FileSender struct {
+	dir string
+}
+
+func NewFileSender(dir string) (*FileSender, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, errors.Wrap(err, "create maildir")
+		}
+	}
+
+	return &FileSender{dir: dir}, nil
+}
+
+func (s *FileSender) Send(msg *Outgoing) error {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return errors.Wrap(err, "generate file name")
+	}
+	name := fmt.Sprintf("%d.%s.auth", time.Now().UnixNano(), hex.EncodeToString(b))
+
+	tmp := filepath.Join(s.dir, "tmp", name)
+	if err := os.WriteFile(tmp, msg.Raw, 0o600); err != nil {
+		return errors.Wrap(err, "write message")
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, "new", name)); err != nil {
+		return errors.Wrap(err, "deliver message")
+	}
+
+	return nil
+}
+
+var _ Sender = &FileSender{}
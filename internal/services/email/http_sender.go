@@ -0,0 +1,76 @@
+package email
+
+import (
+	"auth/internal/config"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// maxErrorLength bounds the response body kept in the error of a failed
+// request.
+const maxErrorLength = 512
+
+// HTTPSender posts the emails as JSON to an email API.
+type HTTPSender struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type httpMessage struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text"`
+	HTML    string   `json:"html"`
+}
+
+func NewHTTPSender(cfg config.HTTPMail) (*HTTPSender, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("mail api url is required")
+	}
+
+	return &HTTPSender{
+		url:        cfg.URL,
+		apiKey:     cfg.APIKey,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+func (s *HTTPSender) Send(msg *Outgoing) error {
+	body, err := json.Marshal(httpMessage{
+		From:    msg.From,
+		To:      msg.To,
+		Subject: msg.Subject,
+		Text:    msg.Text,
+		HTML:    msg.HTML,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal message")
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "send request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+		return fmt.Errorf("mail api responded %d: %s", resp.StatusCode, respBody)
+	}
+
+	return nil
+}
+
+var _ Sender = &HTTPSender{}
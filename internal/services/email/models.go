@@ -0,0 +1,78 @@
+package email
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	// StatusDead is set after the last failed attempt.
+	StatusDead = "dead"
+)
+
+// Email is an email built from a template. It is rendered in the locale of
+// the recipient when it is sent.
+type Email struct {
+	Template string
+	// AcceptLanguage is the Accept-Language of the session the email is
+	// about. It picks the locale for users without one.
+	AcceptLanguage string
+	Data           map[string]string
+}
+
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Outgoing is a rendered email ready for delivery. Raw is the complete MIME
+// message, signed when DKIM is enabled. API providers send the parts
+// instead.
+type Outgoing struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+	Raw     []byte
+}
+
+// CapturedEmail is an email kept by CaptureSender.
+type CapturedEmail struct {
+	ID         uuid.UUID
+	Email      Outgoing
+	CapturedAt time.Time
+}
+
+// Contact is where and in which locale a user gets emails. Locale is empty
+// for users without a preference.
+type Contact struct {
+	Email  string
+	Locale string
+}
+
+// Message is an email of the queue. The template data is dropped once the
+// email is sent, it may hold one-time links.
+type Message struct {
+	ID            uuid.UUID
+	From          string
+	UserID        uuid.UUID
+	Email         Email
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	SentAt        time.Time
+	CreatedAt     time.Time
+}
+
+// Filter selects messages, newest first. Zero fields do not filter.
+type Filter struct {
+	Status string
+	UserID uuid.UUID
+	Limit  int
+}
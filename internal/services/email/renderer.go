@@ -0,0 +1,193 @@
+package email
+
+import (
+	"auth/internal/config"
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+// templateFS holds a directory per locale with a .txt and a .html file per
+// template. The .txt file defines the "subject" and is the plain-text body,
+// the .html file defines the "content" of layout.html. common.txt and
+// common.html of a locale are shared by its templates.
+//
+//go:embed templates
+var templateFS embed.FS
+
+const (
+	TemplateNewIP         = "new_ip"
+	TemplateImpersonation = "impersonation"
+	TemplateAccountLocked = "account_locked"
+	TemplateAlertDigest   = "alert_digest"
+	TemplateStepUpCode    = "step_up_code"
+)
+
+// templateFuncs are available to the templates. lines splits the data values
+// that hold a list, one item per line.
+var templateFuncs = map[string]any{
+	"lines": func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return strings.Split(s, "\n")
+	},
+}
+
+// Renderer renders the emails in the locale of the recipient.
+type Renderer struct {
+	// templates by locale and name
+	templates map[string]map[string]*localizedTemplate
+	locales   []string
+	matcher   language.Matcher
+	brand     config.Branding
+}
+
+type localizedTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+type templateData struct {
+	Locale  string
+	Subject string
+	Brand   config.Branding
+	Data    map[string]string
+}
+
+func NewRenderer(cfg config.Emails) (*Renderer, error) {
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, errors.Wrap(err, "read templates")
+	}
+	layout, err := htmltemplate.ParseFS(templateFS, "templates/layout.html")
+	if err != nil {
+		return nil, errors.Wrap(err, "parse layout")
+	}
+
+	r := &Renderer{
+		templates: make(map[string]map[string]*localizedTemplate),
+		brand:     cfg.Branding,
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		locale := entry.Name()
+		templates, err := parseLocale(layout, locale)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse %s templates", locale)
+		}
+		r.templates[locale] = templates
+		r.locales = append(r.locales, locale)
+	}
+	if r.templates[cfg.DefaultLocale] == nil {
+		return nil, errors.Errorf("no templates for default locale %q", cfg.DefaultLocale)
+	}
+
+	// the matcher falls back to the first tag
+	r.locales = slices.DeleteFunc(r.locales, func(locale string) bool { return locale == cfg.DefaultLocale })
+	r.locales = append([]string{cfg.DefaultLocale}, r.locales...)
+	tags := make([]language.Tag, 0, len(r.locales))
+	for _, locale := range r.locales {
+		tags = append(tags, language.Make(locale))
+	}
+	r.matcher = language.NewMatcher(tags)
+
+	return r, nil
+}
+
+func parseLocale(layout *htmltemplate.Template, locale string) (map[string]*localizedTemplate, error) {
+	dir := path.Join("templates", locale)
+	files, err := fs.Glob(templateFS, path.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, errors.Wrap(err, "list templates")
+	}
+
+	templates := make(map[string]*localizedTemplate)
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".txt")
+		if name == "common" {
+			continue
+		}
+
+		text, err := texttemplate.New(name+".txt").
+			Option("missingkey=error").
+			Funcs(templateFuncs).
+			ParseFS(templateFS, path.Join(dir, "common.txt"), file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse %s", file)
+		}
+		html, err := htmltemplate.Must(layout.Clone()).
+			Option("missingkey=error").
+			Funcs(templateFuncs).
+			ParseFS(templateFS, path.Join(dir, "common.html"), path.Join(dir, name+".html"))
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse %s.html", name)
+		}
+		templates[name] = &localizedTemplate{text: text, html: html}
+	}
+
+	return templates, nil
+}
+
+// Locale returns the supported locale that matches the preferences best, the
+// default locale if none does. Preferences are locales or Accept-Language
+// values, in order of precedence.
+func (r *Renderer) Locale(preferences ...string) string {
+	_, index := language.MatchStrings(r.matcher, preferences...)
+	return r.locales[index]
+}
+
+// Locales returns the supported locales, the default one first.
+func (r *Renderer) Locales() []string {
+	return r.locales
+}
+
+// Templates returns the names of the templates of the locale, sorted.
+func (r *Renderer) Templates(locale string) []string {
+	names := make([]string, 0, len(r.templates[locale]))
+	for name := range r.templates[locale] {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (r *Renderer) Render(email Email, locale string) (*Rendered, error) {
+	tmpl, ok := r.templates[locale][email.Template]
+	if !ok {
+		return nil, errors.Errorf("no template %s for locale %s", email.Template, locale)
+	}
+
+	data := templateData{
+		Locale: locale,
+		Brand:  r.brand,
+		Data:   email.Data,
+	}
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, errors.Wrap(err, "execute subject template")
+	}
+	data.Subject = strings.Join(strings.Fields(subject.String()), " ")
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, errors.Wrap(err, "execute text template")
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return nil, errors.Wrap(err, "execute html template")
+	}
+
+	return &Rendered{
+		Subject: data.Subject,
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    strings.TrimSpace(html.String()) + "\n",
+	}, nil
+}
@@ -0,0 +1,231 @@
+package email
+
+import (
+	"auth/internal/config"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	TLSModeStartTLS = "starttls"
+	TLSModeImplicit = "implicit"
+	TLSModeNone     = "none"
+)
+
+// SMTPSender sends the raw messages over SMTP and keeps idle connections
+// open to reuse them for the next messages.
+type SMTPSender struct {
+	addr         string
+	host         string
+	tlsMode      string
+	tlsConfig    *tls.Config
+	auth         smtp.Auth
+	dialTimeout  time.Duration
+	sendTimeout  time.Duration
+	maxIdleConns int
+	idleTimeout  time.Duration
+
+	mu   sync.Mutex
+	idle []*smtpConn
+}
+
+type smtpConn struct {
+	conn      net.Conn
+	client    *smtp.Client
+	idleSince time.Time
+}
+
+type SMTPOption func(*SMTPSender)
+
+// WithTLSConfig replaces the TLS configuration, e.g. to trust a private CA.
+func WithTLSConfig(tlsConfig *tls.Config) SMTPOption {
+	return func(t *SMTPSender) {
+		t.tlsConfig = tlsConfig.Clone()
+		if t.tlsConfig.ServerName == "" {
+			t.tlsConfig.ServerName = t.host
+		}
+	}
+}
+
+func NewSMTPSender(cfg config.SMTP, opts ...SMTPOption) (*SMTPSender, error) {
+	if cfg.Host == "" || cfg.Port == "" {
+		return nil, errors.New("smtp host and port are required")
+	}
+	switch cfg.TLSMode {
+	case TLSModeStartTLS, TLSModeImplicit, TLSModeNone:
+	default:
+		return nil, errors.Errorf("unknown smtp tls mode %q", cfg.TLSMode)
+	}
+
+	t := &SMTPSender{
+		addr:         net.JoinHostPort(cfg.Host, cfg.Port),
+		host:         cfg.Host,
+		tlsMode:      cfg.TLSMode,
+		tlsConfig:    &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12},
+		dialTimeout:  cfg.DialTimeout,
+		sendTimeout:  cfg.SendTimeout,
+		maxIdleConns: cfg.MaxIdleConns,
+		idleTimeout:  cfg.IdleTimeout,
+	}
+	if cfg.UserName != "" {
+		t.auth = smtp.PlainAuth("", cfg.UserName, cfg.Password, cfg.Host)
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t, nil
+}
+
+var _ Sender = &SMTPSender{}
+
+// Send delivers the message to the recipients. A failed connection is
+// closed, it is not reused.
+func (t *SMTPSender) Send(msg *Outgoing) error {
+	c, err := t.get()
+	if err != nil {
+		return err
+	}
+
+	if err := c.send(msg.From, msg.To, msg.Raw, t.sendTimeout); err != nil {
+		c.client.Close()
+		return err
+	}
+
+	t.put(c)
+	return nil
+}
+
+// Close closes the idle connections.
+func (t *SMTPSender) Close() {
+	t.mu.Lock()
+	idle := t.idle
+	t.idle = nil
+	t.mu.Unlock()
+
+	for _, c := range idle {
+		c.quit(t.sendTimeout)
+	}
+}
+
+// get returns an idle connection that still responds, or a new one.
+func (t *SMTPSender) get() (*smtpConn, error) {
+	for {
+		t.mu.Lock()
+		if len(t.idle) == 0 {
+			t.mu.Unlock()
+			return t.dial()
+		}
+		c := t.idle[len(t.idle)-1]
+		t.idle = t.idle[:len(t.idle)-1]
+		t.mu.Unlock()
+
+		if time.Since(c.idleSince) > t.idleTimeout {
+			c.quit(t.sendTimeout)
+			continue
+		}
+		c.conn.SetDeadline(time.Now().Add(t.sendTimeout))
+		if err := c.client.Reset(); err != nil {
+			c.client.Close()
+			continue
+		}
+		return c, nil
+	}
+}
+
+func (t *SMTPSender) put(c *smtpConn) {
+	c.idleSince = time.Now()
+	t.mu.Lock()
+	if len(t.idle) < t.maxIdleConns {
+		t.idle = append(t.idle, c)
+		c = nil
+	}
+	t.mu.Unlock()
+
+	if c != nil {
+		c.quit(t.sendTimeout)
+	}
+}
+
+func (t *SMTPSender) dial() (*smtpConn, error) {
+	dialer := &net.Dialer{Timeout: t.dialTimeout}
+	var conn net.Conn
+	var err error
+	if t.tlsMode == TLSModeImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", t.addr, t.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", t.addr)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "dial smtp server")
+	}
+
+	// the greeting, TLS handshake and authentication share the send timeout
+	conn.SetDeadline(time.Now().Add(t.sendTimeout))
+	client, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "start smtp session")
+	}
+	if err := t.handshake(client); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &smtpConn{conn: conn, client: client}, nil
+}
+
+func (t *SMTPSender) handshake(client *smtp.Client) error {
+	if t.tlsMode == TLSModeStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(t.tlsConfig); err != nil {
+			return errors.Wrap(err, "starttls")
+		}
+	}
+	if t.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		if err := client.Auth(t.auth); err != nil {
+			return errors.Wrap(err, "authenticate")
+		}
+	}
+	return nil
+}
+
+func (c *smtpConn) send(from string, to []string, msg []byte, timeout time.Duration) error {
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	if err := c.client.Mail(from); err != nil {
+		return errors.Wrap(err, "mail from")
+	}
+	for _, rcpt := range to {
+		if err := c.client.Rcpt(rcpt); err != nil {
+			return errors.Wrapf(err, "rcpt to %s", rcpt)
+		}
+	}
+	w, err := c.client.Data()
+	if err != nil {
+		return errors.Wrap(err, "data")
+	}
+	if _, err := w.Write(msg); err != nil {
+		return errors.Wrap(err, "write message")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "end data")
+	}
+	return nil
+}
+
+func (c *smtpConn) quit(timeout time.Duration) {
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	if err := c.client.Quit(); err != nil {
+		c.client.Close()
+	}
+}
@@ -0,0 +1,47 @@
+package email
+
+import (
+	"fmt"
+	"testing"
+
+	"auth/internal/services/email"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureSender(t *testing.T) {
+	sender := email.NewCaptureSender()
+	require.NoError(t, sender.Send(&email.Outgoing{To: []string{"alice@example.com"}, Subject: "first"}))
+	require.NoError(t, sender.Send(&email.Outgoing{To: []string{"bob@example.com"}, Subject: "second"}))
+	require.NoError(t, sender.Send(&email.Outgoing{To: []string{"alice@example.com"}, Subject: "third"}))
+
+	all := sender.List("")
+	require.Len(t, all, 3)
+	assert.Equal(t, "third", all[0].Email.Subject)
+	alice := sender.List("alice@example.com")
+	require.Len(t, alice, 2)
+	assert.Equal(t, []string{"third", "first"}, []string{alice[0].Email.Subject, alice[1].Email.Subject})
+
+	captured, err := sender.Get(all[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "second", captured.Email.Subject)
+	_, err = sender.Get(uuid.New())
+	assert.ErrorAs(t, err, &email.NotFoundError{})
+
+	sender.Clear()
+	assert.Empty(t, sender.List(""))
+}
+
+func TestCaptureSender_DropsOldest(t *testing.T) {
+	sender := email.NewCaptureSender()
+	for i := 0; i < 250; i++ {
+		require.NoError(t, sender.Send(&email.Outgoing{Subject: fmt.Sprint(i)}))
+	}
+
+	emails := sender.List("")
+	require.Len(t, emails, 200)
+	assert.Equal(t, "249", emails[0].Email.Subject)
+	assert.Equal(t, "50", emails[199].Email.Subject)
+}
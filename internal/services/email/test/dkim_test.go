@@ -0,0 +1,168 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"auth/internal/config"
+	"auth/internal/services/email"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6376Message is the example message of RFC 6376 and RFC 8463.
+const rfc6376Message = "From: Joe SixPack <joe@football.example.com>\r\n" +
+	"To: Suzie Q <suzie@shopping.example.net>\r\n" +
+	"Subject: Is dinner ready?\r\n" +
+	"Date: Fri, 11 Jul 2003 21:00:37 -0700 (PDT)\r\n" +
+	"Message-ID: <20030712040037.46341.5F8J@football.example.com>\r\n" +
+	"\r\n" +
+	"Hi.\r\n" +
+	"\r\n" +
+	"We lost the game. Are you hungry yet?\r\n" +
+	"\r\n" +
+	"Joe.\r\n"
+
+func TestDKIMSigner_RSA(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	signer := newDKIMSigner(t, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	signed, err := signer.Sign([]byte(rfc6376Message))
+	require.NoError(t, err)
+
+	tags := verifyDKIM(t, signed, &key.PublicKey)
+	assert.Equal(t, "rsa-sha256", tags["a"])
+	assert.Equal(t, "company.com", tags["d"])
+	assert.Equal(t, "mail", tags["s"])
+	assert.Equal(t, "from:to:subject:date:message-id", tags["h"])
+}
+
+func TestDKIMSigner_Ed25519(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	signer := newDKIMSigner(t, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	signed, err := signer.Sign([]byte(rfc6376Message))
+	require.NoError(t, err)
+
+	tags := verifyDKIM(t, signed, public)
+	assert.Equal(t, "ed25519-sha256", tags["a"])
+	// body hash of the example of RFC 8463, appendix A
+	assert.Equal(t, "2jUSOH9NhtVGCQWNr9BrIAPreKQjO6Sn7XIkfJVOzv8=", tags["bh"])
+}
+
+func TestDKIMSigner_RelaxedCanonicalization(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	signer := newDKIMSigner(t, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	signed, err := signer.Sign([]byte(rfc6376Message))
+	require.NoError(t, err)
+
+	// relays may refold headers, change whitespace and add trailing lines
+	relayed := strings.Replace(string(signed), "Subject: Is dinner ready?", "subject:   Is dinner\r\n\tready?  ", 1)
+	relayed = strings.Replace(relayed, "We lost the game.", "We  lost\tthe game.  ", 1) + "\r\n\r\n"
+	verifyDKIM(t, []byte(relayed), public)
+
+	tampered := strings.Replace(string(signed), "Joe.", "Jim.", 1)
+	_, err = checkDKIM([]byte(tampered), public)
+	assert.ErrorContains(t, err, "body hash")
+}
+
+func TestNewDKIMSigner_InvalidKey(t *testing.T) {
+	_, err := email.NewDKIMSigner(config.DKIM{Domain: "company.com", Selector: "mail", PrivateKey: "not a key"})
+
+	assert.Error(t, err)
+}
+
+func newDKIMSigner(t *testing.T, privateKey []byte) *email.DKIMSigner {
+	signer, err := email.NewDKIMSigner(config.DKIM{Domain: "company.com", Selector: "mail", PrivateKey: string(privateKey)})
+	require.NoError(t, err)
+	return signer
+}
+
+func verifyDKIM(t *testing.T, msg []byte, public crypto.PublicKey) map[string]string {
+	tags, err := checkDKIM(msg, public)
+	require.NoError(t, err)
+	return tags
+}
+
+var wsp = regexp.MustCompile(`[ \t]+`)
+
+// checkDKIM verifies the DKIM-Signature of the message the way a receiver
+// does, with relaxed canonicalization.
+func checkDKIM(msg []byte, public crypto.PublicKey) (map[string]string, error) {
+	header, body, _ := strings.Cut(string(msg), "\r\n\r\n")
+	var fields []string
+	for _, line := range strings.Split(header, "\r\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			fields[len(fields)-1] += "\r\n" + line
+		} else {
+			fields = append(fields, line)
+		}
+	}
+	canonicalHeader := func(field string) string {
+		name, value, _ := strings.Cut(field, ":")
+		value = wsp.ReplaceAllString(strings.ReplaceAll(value, "\r\n", ""), " ")
+		return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.TrimSpace(value)
+	}
+
+	signature := fields[0]
+	tags := map[string]string{}
+	_, value, _ := strings.Cut(signature, ":")
+	for _, tag := range strings.Split(value, ";") {
+		name, tagValue, _ := strings.Cut(tag, "=")
+		tags[strings.TrimSpace(name)] = strings.TrimSpace(tagValue)
+	}
+
+	lines := strings.Split(body, "\r\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(wsp.ReplaceAllString(line, " "), " ")
+	}
+	canonicalBody := strings.TrimRight(strings.Join(lines, "\r\n"), "\r\n") + "\r\n"
+	bodyHash := sha256.Sum256([]byte(canonicalBody))
+	if base64.StdEncoding.EncodeToString(bodyHash[:]) != tags["bh"] {
+		return nil, errors.New("body hash mismatch")
+	}
+
+	var signed bytes.Buffer
+	for _, name := range strings.Split(tags["h"], ":") {
+		for i := len(fields) - 1; i > 0; i-- {
+			if strings.HasPrefix(strings.ToLower(fields[i]), name+":") {
+				signed.WriteString(canonicalHeader(fields[i]) + "\r\n")
+				break
+			}
+		}
+	}
+	signed.WriteString(canonicalHeader(signature[:strings.Index(signature, "; b=")+len("; b=")]))
+	hash := sha256.Sum256(signed.Bytes())
+	b, err := base64.StdEncoding.DecodeString(tags["b"])
+	if err != nil {
+		return nil, err
+	}
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(public, crypto.SHA256, hash[:], b)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(public, hash[:], b) {
+			err = errors.New("signature mismatch")
+		}
+	}
+	return tags, err
+}
@@ -0,0 +1,96 @@
+package email
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/services/email"
+	"auth/internal/services/email/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendEmailToUser_Enqueues(t *testing.T) {
+	queue, storage, sender := newQueueAndMocks(t)
+	userID := uuid.New()
+	msg := email.Email{Template: email.TemplateNewIP, Data: map[string]string{"ip": "127.0.0.1"}}
+	storage.
+		On("Enqueue", mock.MatchedBy(func(m *email.Message) bool {
+			return m.UserID == userID && m.From == "support@company.com" &&
+				assert.ObjectsAreEqual(msg, m.Email) && m.Status == email.StatusPending
+		})).
+		Return(nil)
+
+	require.NoError(t, queue.SendEmailToUser("support@company.com", userID, msg))
+	sender.AssertNotCalled(t, "SendEmailToUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProcess(t *testing.T) {
+	queue, storage, sender := newQueueAndMocks(t)
+	sent, failed, exhausted := newMessage(0), newMessage(0), newMessage(2)
+	storage.
+		On("Claim", mock.Anything, mock.Anything, time.Minute).
+		Return([]email.Message{sent, failed, exhausted}, nil)
+	sender.On("SendEmailToUser", sent.From, sent.UserID, sent.Email).Return(nil)
+	sender.On("SendEmailToUser", failed.From, failed.UserID, failed.Email).Return(errors.New("smtp down"))
+	sender.On("SendEmailToUser", exhausted.From, exhausted.UserID, exhausted.Email).Return(errors.New("smtp down"))
+	storage.
+		On("Update", mock.MatchedBy(func(m *email.Message) bool {
+			return m.ID == sent.ID && m.Status == email.StatusSent && m.Email.Data == nil && !m.SentAt.IsZero()
+		})).
+		Return(nil)
+	storage.
+		On("Update", mock.MatchedBy(func(m *email.Message) bool {
+			return m.ID == failed.ID && m.Status == email.StatusPending && m.Attempts == 1 &&
+				m.LastError == "smtp down" && m.NextAttemptAt.After(time.Now().Add(20*time.Second))
+		})).
+		Return(nil)
+	storage.
+		On("Update", mock.MatchedBy(func(m *email.Message) bool {
+			return m.ID == exhausted.ID && m.Status == email.StatusDead && m.Attempts == 3
+		})).
+		Return(nil)
+
+	n, err := queue.Process()
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+}
+
+func TestList_InvalidLimit(t *testing.T) {
+	queue, _, _ := newQueueAndMocks(t)
+
+	_, err := queue.List(email.Filter{Limit: 1001})
+
+	assert.ErrorAs(t, err, &email.ValidationError{})
+}
+
+func newMessage(attempts int) email.Message {
+	id := uuid.New()
+	return email.Message{
+		ID:       id,
+		From:     "support@company.com",
+		UserID:   uuid.New(),
+		Email:    email.Email{Template: email.TemplateNewIP, Data: map[string]string{"id": id.String()}},
+		Status:   email.StatusPending,
+		Attempts: attempts,
+	}
+}
+
+func newQueueAndMocks(t *testing.T) (*email.EmailQueue, *mocks.QueueStorage, *mocks.UserEmailSender) {
+	storage := mocks.NewQueueStorage(t)
+	sender := mocks.NewUserEmailSender(t)
+	queue := email.NewEmailQueue(storage, sender, config.EmailQueue{
+		Workers:      1,
+		PollInterval: time.Second,
+		Lease:        time.Minute,
+		MaxAttempts:  3,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   time.Hour,
+	})
+	return queue, storage, sender
+}
@@ -0,0 +1,66 @@
+package email
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"auth/internal/services/email"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailService_SendEmailToUser(t *testing.T) {
+	server := newFakeSMTPServer(t, nil)
+	sender := newSMTPSender(t, server, email.TLSModeNone, nil)
+	userID := uuid.New()
+	users := fakeUserStorage{userID: {Email: "user@example.com", Locale: "en"}}
+	service := email.NewEmailService(sender, users, newRenderer(t), nil)
+
+	err := service.SendEmailToUser("support@company.com", userID, email.Email{
+		Template: email.TemplateNewIP,
+		Data:     templateData[email.TemplateNewIP],
+	})
+	require.NoError(t, err)
+
+	messages := server.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, []string{"user@example.com"}, messages[0].To)
+	msg, err := mail.ReadMessage(strings.NewReader(messages[0].Data))
+	require.NoError(t, err)
+	assert.Equal(t, "<support@company.com>", msg.Header.Get("From"))
+	assert.Equal(t, "<user@example.com>", msg.Header.Get("To"))
+	assert.Equal(t, "Company: sign-in from a new IP address", msg.Header.Get("Subject"))
+	_, err = msg.Header.Date()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(msg.Header.Get("Message-ID"), "@company.com>"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	var contentTypes []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "203.0.113.7")
+		contentTypes = append(contentTypes, part.Header.Get("Content-Type"))
+	}
+	assert.Equal(t, []string{"text/plain; charset=UTF-8", "text/html; charset=UTF-8"}, contentTypes)
+}
+
+type fakeUserStorage map[uuid.UUID]*email.Contact
+
+func (s fakeUserStorage) GetUserContact(userID uuid.UUID) (*email.Contact, error) {
+	return s[userID], nil
+}
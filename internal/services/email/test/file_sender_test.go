@@ -0,0 +1,35 @@
+package email
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"auth/internal/services/email"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSender_Send(t *testing.T) {
+	dir := t.TempDir()
+	sender, err := email.NewFileSender(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, sender.Send(newOutgoing("Subject: hi\r\n\r\nhello\r\n")))
+	require.NoError(t, sender.Send(newOutgoing("Subject: bye\r\n\r\nbye\r\n")))
+
+	delivered, err := os.ReadDir(filepath.Join(dir, "new"))
+	require.NoError(t, err)
+	require.Len(t, delivered, 2)
+	pending, err := os.ReadDir(filepath.Join(dir, "tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	var contents []string
+	for _, entry := range delivered {
+		content, err := os.ReadFile(filepath.Join(dir, "new", entry.Name()))
+		require.NoError(t, err)
+		contents = append(contents, string(content))
+	}
+	assert.ElementsMatch(t, []string{"Subject: hi\r\n\r\nhello\r\n", "Subject: bye\r\n\r\nbye\r\n"}, contents)
+}
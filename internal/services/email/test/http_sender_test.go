@@ -0,0 +1,64 @@
+package email
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/services/email"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPSender_Send(t *testing.T) {
+	var received map[string]any
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	sender := newHTTPSender(t, server.URL)
+
+	err := sender.Send(&email.Outgoing{
+		From:    "support@company.com",
+		To:      []string{"user@example.com"},
+		Subject: "Subject",
+		Text:    "text",
+		HTML:    "<p>html</p>",
+		Raw:     []byte("raw"),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer key", authorization)
+	assert.Equal(t, map[string]any{
+		"from":    "support@company.com",
+		"to":      []any{"user@example.com"},
+		"subject": "Subject",
+		"text":    "text",
+		"html":    "<p>html</p>",
+	}, received)
+}
+
+func TestHTTPSender_ErrorResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid from address", http.StatusBadRequest)
+	}))
+	defer server.Close()
+	sender := newHTTPSender(t, server.URL)
+
+	err := sender.Send(&email.Outgoing{From: "support@company.com", To: []string{"user@example.com"}})
+
+	assert.ErrorContains(t, err, "400: invalid from address")
+}
+
+func newHTTPSender(t *testing.T, url string) *email.HTTPSender {
+	sender, err := email.NewHTTPSender(config.HTTPMail{URL: url, Timeout: time.Second, APIKey: "key"})
+	require.NoError(t, err)
+	return sender
+}
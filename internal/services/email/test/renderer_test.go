@@ -0,0 +1,106 @@
+package email
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"auth/internal/config"
+	"auth/internal/services/email"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// templateData holds the data of every template.
+var templateData = map[string]map[string]string{
+	email.TemplateNewIP: {
+		"time":      "2024-05-01 12:00:00 (UTC)",
+		"ip":        "203.0.113.7",
+		"location":  "Berlin, DE",
+		"actionUrl": "https://company.com/security-actions?token=abc&x=1",
+	},
+	email.TemplateImpersonation: {
+		"time":   "2024-05-01 12:00:00 (UTC)",
+		"reason": "Ticket #42 <script>",
+	},
+	email.TemplateAccountLocked: {
+		"lockedUntil": "2024-05-01 13:00:00 (UTC)",
+		"unlockUrl":   "https://company.com/unlock?token=abc&x=1",
+	},
+	email.TemplateAlertDigest: {
+		"new_ip":    "2024-05-01 12:00:00 (UTC), 203.0.113.7 (Berlin, DE)\n2024-05-01 18:30:00 (UTC), 2001:db8::<b>",
+		"actionUrl": "https://company.com/security-actions?token=abc&x=1",
+	},
+	email.TemplateStepUpCode: {
+		"code":      "042917",
+		"ip":        "203.0.113.7",
+		"expiresAt": "2024-05-01 12:10:00 (UTC)",
+	},
+}
+
+func TestRender_Golden(t *testing.T) {
+	renderer := newRenderer(t)
+
+	for _, locale := range renderer.Locales() {
+		assert.ElementsMatch(t, mapKeys(templateData), renderer.Templates(locale), "templates of %s", locale)
+		for name, data := range templateData {
+			t.Run(locale+"/"+name, func(t *testing.T) {
+				rendered, err := renderer.Render(email.Email{Template: name, Data: data}, locale)
+				require.NoError(t, err)
+
+				got := "Subject: " + rendered.Subject + "\n\n" + rendered.Text + "\n" + rendered.HTML
+				golden := filepath.Join("testdata", locale, name+".golden")
+				if *update {
+					require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+					require.NoError(t, os.WriteFile(golden, []byte(got), 0o644))
+				}
+				want, err := os.ReadFile(golden)
+				require.NoError(t, err)
+				assert.Equal(t, string(want), got)
+			})
+		}
+	}
+}
+
+func TestRender_MissingData(t *testing.T) {
+	renderer := newRenderer(t)
+
+	_, err := renderer.Render(email.Email{Template: email.TemplateNewIP}, "en")
+
+	assert.Error(t, err)
+}
+
+func TestLocale(t *testing.T) {
+	renderer := newRenderer(t)
+
+	assert.Equal(t, "ru", renderer.Locale())
+	assert.Equal(t, "en", renderer.Locale("", "en-US,en;q=0.9,ru;q=0.8"))
+	assert.Equal(t, "ru", renderer.Locale("", "de-DE,de;q=0.9"))
+	assert.Equal(t, "ru", renderer.Locale("ru", "en-US"))
+}
+
+func newRenderer(t *testing.T) *email.Renderer {
+	renderer, err := email.NewRenderer(config.Emails{
+		DefaultLocale: "ru",
+		Branding: config.Branding{
+			ProductName:  "Company",
+			SupportURL:   "https://company.com/support",
+			LogoURL:      "https://company.com/logo.png",
+			PrimaryColor: "#1a73e8",
+		},
+	})
+	require.NoError(t, err)
+	return renderer
+}
+
+func mapKeys(m map[string]map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,117 @@
+package email
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/services/email"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSMTPSender_ReusesConnection(t *testing.T) {
+	server := newFakeSMTPServer(t, nil)
+	sender := newSMTPSender(t, server, email.TLSModeNone, nil)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, sender.Send(newOutgoing("Subject: hi\r\n\r\nhello\r\n")))
+	}
+
+	messages := server.Messages()
+	require.Len(t, messages, 3)
+	assert.Equal(t, "support@company.com", messages[0].From)
+	assert.Equal(t, []string{"user@example.com"}, messages[0].To)
+	assert.Equal(t, "Subject: hi\n\nhello\n", messages[0].Data)
+	assert.Equal(t, 1, server.Connections())
+}
+
+func TestSMTPSender_ReconnectsAfterDroppedConnection(t *testing.T) {
+	server := newFakeSMTPServer(t, func(s *fakeSMTPServer) { s.closeAfterMessage = true })
+	sender := newSMTPSender(t, server, email.TLSModeNone, nil)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, sender.Send(newOutgoing("hello\r\n")))
+	}
+
+	assert.Len(t, server.Messages(), 2)
+	assert.Equal(t, 2, server.Connections())
+}
+
+func TestSMTPSender_StartTLS(t *testing.T) {
+	serverTLS, clientTLS := newTLSConfigs(t)
+	server := newFakeSMTPServer(t, func(s *fakeSMTPServer) { s.tlsConfig = serverTLS })
+	sender := newSMTPSender(t, server, email.TLSModeStartTLS, clientTLS)
+
+	require.NoError(t, sender.Send(newOutgoing("hello\r\n")))
+
+	messages := server.Messages()
+	require.Len(t, messages, 1)
+	assert.True(t, messages[0].TLS)
+	assert.True(t, strings.HasPrefix(messages[0].Auth, "PLAIN"))
+}
+
+func TestSMTPSender_StartTLSNotSupported(t *testing.T) {
+	server := newFakeSMTPServer(t, nil)
+	sender := newSMTPSender(t, server, email.TLSModeStartTLS, nil)
+
+	err := sender.Send(newOutgoing("hello\r\n"))
+
+	assert.ErrorContains(t, err, "STARTTLS")
+	assert.Empty(t, server.Messages())
+}
+
+func TestSMTPSender_ImplicitTLS(t *testing.T) {
+	serverTLS, clientTLS := newTLSConfigs(t)
+	server := newFakeSMTPServer(t, func(s *fakeSMTPServer) {
+		s.tlsConfig = serverTLS
+		s.implicitTLS = true
+	})
+	sender := newSMTPSender(t, server, email.TLSModeImplicit, clientTLS)
+
+	require.NoError(t, sender.Send(newOutgoing("hello\r\n")))
+
+	messages := server.Messages()
+	require.Len(t, messages, 1)
+	assert.True(t, messages[0].TLS)
+}
+
+func TestSMTPSender_SendTimeout(t *testing.T) {
+	server := newFakeSMTPServer(t, func(s *fakeSMTPServer) { s.noGreeting = true })
+	sender := newSMTPSender(t, server, email.TLSModeNone, nil)
+
+	start := time.Now()
+	err := sender.Send(newOutgoing("hello\r\n"))
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 900*time.Millisecond)
+}
+
+func newOutgoing(raw string) *email.Outgoing {
+	return &email.Outgoing{From: "support@company.com", To: []string{"user@example.com"}, Raw: []byte(raw)}
+}
+
+func newSMTPSender(t *testing.T, server *fakeSMTPServer, tlsMode string, tlsConfig *tls.Config) *email.SMTPSender {
+	cfg := config.SMTP{
+		Host:         "127.0.0.1",
+		Port:         server.Port(),
+		TLSMode:      tlsMode,
+		DialTimeout:  time.Second,
+		SendTimeout:  200 * time.Millisecond,
+		MaxIdleConns: 2,
+		IdleTimeout:  time.Minute,
+		UserName:     "user",
+		Password:     "password",
+	}
+	var opts []email.SMTPOption
+	if tlsConfig != nil {
+		opts = append(opts, email.WithTLSConfig(tlsConfig))
+	}
+	sender, err := email.NewSMTPSender(cfg, opts...)
+	require.NoError(t, err)
+	t.Cleanup(sender.Close)
+	return sender
+}
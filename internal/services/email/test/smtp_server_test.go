@@ -0,0 +1,189 @@
+package email
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer is an in-process SMTP server that accepts every message.
+type fakeSMTPServer struct {
+	listener net.Listener
+	// tlsConfig enables STARTTLS, or implicit TLS with implicitTLS.
+	tlsConfig   *tls.Config
+	implicitTLS bool
+	// noGreeting makes the server accept connections without answering.
+	noGreeting bool
+	// closeAfterMessage drops the connection after each message.
+	closeAfterMessage bool
+
+	mu          sync.Mutex
+	messages    []fakeMessage
+	connections int
+}
+
+type fakeMessage struct {
+	From string
+	To   []string
+	Data string
+	TLS  bool
+	Auth string
+}
+
+func newFakeSMTPServer(t *testing.T, configure func(s *fakeSMTPServer)) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeSMTPServer{listener: listener}
+	if configure != nil {
+		configure(s)
+	}
+	if s.implicitTLS {
+		s.listener = tls.NewListener(listener, s.tlsConfig)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.connections++
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) Port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func (s *fakeSMTPServer) Messages() []fakeMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeMessage(nil), s.messages...)
+}
+
+func (s *fakeSMTPServer) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	if s.noGreeting {
+		time.Sleep(time.Second)
+		return
+	}
+
+	_, isTLS := conn.(*tls.Conn)
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 fake ESMTP")
+	var msg fakeMessage
+	var auth string
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			text.PrintfLine("250-fake")
+			if s.tlsConfig != nil && !s.implicitTLS && !isTLS {
+				text.PrintfLine("250-STARTTLS")
+			}
+			text.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			text.PrintfLine("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, isTLS = tlsConn, true
+			text = textproto.NewConn(conn)
+		case "AUTH":
+			auth = arg
+			text.PrintfLine("235 ok")
+		case "MAIL":
+			msg = fakeMessage{From: address(arg), TLS: isTLS, Auth: auth}
+			text.PrintfLine("250 ok")
+		case "RCPT":
+			msg.To = append(msg.To, address(arg))
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			text.PrintfLine("250 ok")
+			if s.closeAfterMessage {
+				return
+			}
+		case "RSET", "NOOP":
+			text.PrintfLine("250 ok")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 unknown command")
+		}
+	}
+}
+
+// address returns the address of a MAIL FROM or RCPT TO argument.
+func address(arg string) string {
+	start, end := strings.Index(arg, "<"), strings.Index(arg, ">")
+	if start == -1 || end < start {
+		return arg
+	}
+	return arg[start+1 : end]
+}
+
+// newTLSConfigs returns the configuration of a server with a self-signed
+// certificate for 127.0.0.1 and of a client that trusts it.
+func newTLSConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake smtp"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	server := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client := &tls.Config{RootCAs: roots}
+	return server, client
+}
@@ -0,0 +1,127 @@
+package geoip
+
+import (
+	"auth/internal/config"
+	logutils "auth/internal/utils/log"
+	"context"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Locator resolves addresses with local MaxMind-format databases and makes
+// no network calls. Each database adds the fields it knows, so that e.g. a
+// City and an ASN database complement each other.
+type Locator struct {
+	paths          []string
+	reloadInterval time.Duration
+	mu             sync.RWMutex
+	// databases are in the order of paths.
+	databases []*database
+}
+
+// NewLocator reads the databases of the configuration. Without databases,
+// every location is unknown.
+func NewLocator(cfg config.GeoIP) (*Locator, error) {
+	locator := &Locator{
+		paths:          cfg.Databases,
+		reloadInterval: cfg.ReloadInterval,
+	}
+	for _, path := range cfg.Databases {
+		db, err := openDatabase(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "open %s", path)
+		}
+		locator.databases = append(locator.databases, db)
+	}
+	return locator, nil
+}
+
+// Locate returns nil for addresses the databases do not know and for
+// strings that are not addresses.
+func (l *Locator) Locate(ip string) (*Location, error) {
+	addr, err := netip.ParseAddr(strings.Trim(ip, "[]"))
+	if err != nil {
+		return nil, nil
+	}
+	addr = addr.Unmap().WithZone("")
+
+	l.mu.RLock()
+	databases := l.databases
+	l.mu.RUnlock()
+
+	var location Location
+	for i, db := range databases {
+		record, ok, err := db.lookup(addr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "look up in %s", l.paths[i])
+		}
+		if m, isMap := record.(map[string]any); ok && isMap {
+			location.merge(m)
+		}
+	}
+	if location.empty() {
+		return nil, nil
+	}
+	return &location, nil
+}
+
+// Run reloads the databases whose files changed, every reload interval until
+// the context is done.
+func (l *Locator) Run(ctx context.Context) {
+	if len(l.paths) == 0 {
+		return
+	}
+	ticker := time.NewTicker(l.reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := l.Reload(); err != nil {
+			logutils.Error("reload geoip databases error", err)
+		}
+	}
+}
+
+// Reload reads the databases whose files changed since they were read. The
+// previous version of a database that fails to read stays in use.
+func (l *Locator) Reload() error {
+	l.mu.RLock()
+	databases := slices.Clone(l.databases)
+	l.mu.RUnlock()
+
+	var reloadErr error
+	changed := false
+	for i, path := range l.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			reloadErr = errors.Wrapf(err, "stat %s", path)
+			continue
+		}
+		if info.ModTime().Equal(databases[i].modTime) && info.Size() == databases[i].size {
+			continue
+		}
+		db, err := openDatabase(path)
+		if err != nil {
+			reloadErr = errors.Wrapf(err, "open %s", path)
+			continue
+		}
+		databases[i] = db
+		changed = true
+	}
+
+	if changed {
+		l.mu.Lock()
+		l.databases = databases
+		l.mu.Unlock()
+	}
+	return reloadErr
+}
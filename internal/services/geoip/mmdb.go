@@ -0,0 +1,348 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/big"
+	"net/netip"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// metadataMarker precedes the metadata at the end of a MaxMind DB file.
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+var errInvalidDatabase = errors.New("invalid database")
+
+// database is a MaxMind DB file read into memory, see
+// https://maxmind.github.io/MaxMind-DB/.
+type database struct {
+	tree       []byte
+	data       decoder
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	// ipv4Start is the node of ::/96, where IPv4 addresses start in IPv6
+	// databases.
+	ipv4Start uint
+	modTime   time.Time
+	size      int64
+}
+
+func openDatabase(path string) (*database, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "stat file")
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read file")
+	}
+	db, err := parseDatabase(buf)
+	if err != nil {
+		return nil, err
+	}
+	db.modTime = info.ModTime()
+	db.size = info.Size()
+	return db, nil
+}
+
+func parseDatabase(buf []byte) (*database, error) {
+	metadataStart := bytes.LastIndex(buf, metadataMarker)
+	if metadataStart < 0 {
+		return nil, errors.Wrap(errInvalidDatabase, "no metadata")
+	}
+	metadata, _, err := decoder{buf[metadataStart+len(metadataMarker):]}.decode(0, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode metadata")
+	}
+
+	db := &database{}
+	var ok [3]bool
+	db.nodeCount, ok[0] = uintValue(path(metadata, "node_count"))
+	db.recordSize, ok[1] = uintValue(path(metadata, "record_size"))
+	db.ipVersion, ok[2] = uintValue(path(metadata, "ip_version"))
+	if ok != [3]bool{true, true, true} {
+		return nil, errors.Wrap(errInvalidDatabase, "incomplete metadata")
+	}
+	if db.recordSize != 24 && db.recordSize != 28 && db.recordSize != 32 {
+		return nil, errors.Wrapf(errInvalidDatabase, "record size %d", db.recordSize)
+	}
+	if db.ipVersion != 4 && db.ipVersion != 6 {
+		return nil, errors.Wrapf(errInvalidDatabase, "ip version %d", db.ipVersion)
+	}
+
+	// the search tree is followed by 16 zero bytes and the data section
+	treeSize := db.nodeCount * db.recordSize / 4
+	if treeSize+16 > uint(metadataStart) {
+		return nil, errors.Wrap(errInvalidDatabase, "search tree exceeds the file")
+	}
+	db.tree = buf[:treeSize]
+	db.data = decoder{buf[treeSize+16 : metadataStart]}
+
+	if db.ipVersion == 6 {
+		for i := 0; i < 96 && db.ipv4Start < db.nodeCount; i++ {
+			db.ipv4Start = db.record(db.ipv4Start, 0)
+		}
+	}
+	return db, nil
+}
+
+// lookup returns the data of the network of the address, false for
+// addresses the database does not know.
+func (db *database) lookup(addr netip.Addr) (any, bool, error) {
+	var ip []byte
+	node := uint(0)
+	if addr.Is4() {
+		ip4 := addr.As4()
+		ip = ip4[:]
+		if db.ipVersion == 6 {
+			node = db.ipv4Start
+		}
+	} else {
+		if db.ipVersion == 4 {
+			return nil, false, nil
+		}
+		ip16 := addr.As16()
+		ip = ip16[:]
+	}
+
+	for i := 0; i < len(ip)*8 && node < db.nodeCount; i++ {
+		bit := uint(ip[i/8]>>(7-i%8)) & 1
+		node = db.record(node, bit)
+	}
+	switch {
+	case node == db.nodeCount:
+		return nil, false, nil
+	case node < db.nodeCount:
+		return nil, false, errors.Wrap(errInvalidDatabase, "search tree deeper than the address")
+	}
+
+	value, _, err := db.data.decode(node-db.nodeCount-16, 0)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "decode data")
+	}
+	return value, true, nil
+}
+
+// record returns the left (bit 0) or right (bit 1) record of the node.
+func (db *database) record(node, bit uint) uint {
+	b := db.tree
+	switch db.recordSize {
+	case 24:
+		offset := node*6 + bit*3
+		return uint(b[offset])<<16 | uint(b[offset+1])<<8 | uint(b[offset+2])
+	case 28:
+		offset := node * 7
+		if bit == 0 {
+			return uint(b[offset+3]&0xf0)<<20 | uint(b[offset])<<16 | uint(b[offset+1])<<8 | uint(b[offset+2])
+		}
+		return uint(b[offset+3]&0x0f)<<24 | uint(b[offset+4])<<16 | uint(b[offset+5])<<8 | uint(b[offset+6])
+	default:
+		offset := node*8 + bit*4
+		return uint(binary.BigEndian.Uint32(b[offset:]))
+	}
+}
+
+// The data types of the data section.
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// maxDepth bounds the nesting of maps, arrays and pointers.
+const maxDepth = 32
+
+// decoder reads values of the data section. Maps decode to map[string]any,
+// arrays to []any and unsigned integers to uint64, except uint128, which
+// decodes to *big.Int.
+type decoder struct {
+	buf []byte
+}
+
+// decode returns the value at offset and the offset after it.
+func (d decoder) decode(offset uint, depth int) (any, uint, error) {
+	if depth > maxDepth {
+		return nil, 0, errors.Wrap(errInvalidDatabase, "data nested too deep")
+	}
+	ctrl, err := d.bytes(offset, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset++
+
+	kind := uint(ctrl[0] >> 5)
+	if kind == typePointer {
+		pointer, next, err := d.pointer(ctrl[0], offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := d.decode(pointer, depth+1)
+		return value, next, err
+	}
+	if kind == typeExtended {
+		extended, err := d.bytes(offset, 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		kind = 7 + uint(extended[0])
+		offset++
+	}
+	size, offset, err := d.size(ctrl[0], offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch kind {
+	case typeMap:
+		m := make(map[string]any, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			keyString, ok := key.(string)
+			if !ok {
+				return nil, 0, errors.Wrap(errInvalidDatabase, "map key is not a string")
+			}
+			m[keyString], offset, err = d.decode(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+		return m, offset, nil
+	case typeArray:
+		array := make([]any, 0, size)
+		for i := uint(0); i < size; i++ {
+			var value any
+			value, offset, err = d.decode(offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			array = append(array, value)
+		}
+		return array, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	b, err := d.bytes(offset, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	offset += size
+	switch kind {
+	case typeString:
+		return string(b), offset, nil
+	case typeBytes:
+		return bytes.Clone(b), offset, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errors.Wrap(errInvalidDatabase, "double of invalid size")
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), offset, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errors.Wrap(errInvalidDatabase, "float of invalid size")
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), offset, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, errors.Wrap(errInvalidDatabase, "integer of invalid size")
+		}
+		return bigEndian(b), offset, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, errors.Wrap(errInvalidDatabase, "integer of invalid size")
+		}
+		return int64(int32(uint32(bigEndian(b)))), offset, nil
+	case typeUint128:
+		if size > 16 {
+			return nil, 0, errors.Wrap(errInvalidDatabase, "integer of invalid size")
+		}
+		return new(big.Int).SetBytes(b), offset, nil
+	default:
+		return nil, 0, errors.Wrapf(errInvalidDatabase, "data type %d", kind)
+	}
+}
+
+// size reads the payload size of the control byte and returns it with the
+// offset after its extension bytes.
+func (d decoder) size(ctrl byte, offset uint) (uint, uint, error) {
+	size := uint(ctrl & 0x1f)
+	if size < 29 {
+		return size, offset, nil
+	}
+	n := size - 28
+	b, err := d.bytes(offset, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	extension := uint(bigEndian(b))
+	switch size {
+	case 29:
+		size = 29 + extension
+	case 30:
+		size = 285 + extension
+	default:
+		size = 65821 + extension
+	}
+	return size, offset + n, nil
+}
+
+// pointer returns the offset the pointer points to and the offset after it.
+func (d decoder) pointer(ctrl byte, offset uint) (uint, uint, error) {
+	sizeBits := uint(ctrl>>3) & 0x3
+	valueBits := uint(ctrl & 0x7)
+	b, err := d.bytes(offset, sizeBits+1)
+	if err != nil {
+		return 0, 0, err
+	}
+	value := uint(bigEndian(b))
+	switch sizeBits {
+	case 0:
+		value |= valueBits << 8
+	case 1:
+		value = valueBits<<16 | value + 2048
+	case 2:
+		value = valueBits<<24 | value + 526336
+	}
+	return value, offset + sizeBits + 1, nil
+}
+
+func (d decoder) bytes(offset, n uint) ([]byte, error) {
+	if offset > uint(len(d.buf)) || n > uint(len(d.buf))-offset {
+		return nil, errors.Wrap(errInvalidDatabase, "data exceeds the section")
+	}
+	return d.buf[offset : offset+n], nil
+}
+
+func bigEndian(b []byte) uint64 {
+	var value uint64
+	for _, c := range b {
+		value = value<<8 | uint64(c)
+	}
+	return value
+}
+
+func uintValue(value any) (uint, bool) {
+	v, ok := value.(uint64)
+	return uint(v), ok
+}
@@ -0,0 +1,72 @@
+package geoip
+
+// Location of an address. Fields the databases do not know are zero.
+type Location struct {
+	Country      string       `json:"country,omitempty"`
+	City         string       `json:"city,omitempty"`
+	ASN          uint         `json:"asn,omitempty"`
+	Organization string       `json:"organization,omitempty"`
+	Coordinates  *Coordinates `json:"coordinates,omitempty"`
+}
+
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// String formats the place for people, e.g. "Berlin, DE". It is empty when
+// the country is unknown.
+func (l *Location) String() string {
+	if l == nil || l.Country == "" {
+		return ""
+	}
+	if l.City == "" {
+		return l.Country
+	}
+	return l.City + ", " + l.Country
+}
+
+// merge fills the fields of a GeoIP2 or GeoLite2 City, Country or ASN record
+// that l does not have yet.
+func (l *Location) merge(record map[string]any) {
+	if l.Country == "" {
+		l.Country, _ = path(record, "country", "iso_code").(string)
+	}
+	if l.Country == "" {
+		l.Country, _ = path(record, "registered_country", "iso_code").(string)
+	}
+	if l.City == "" {
+		l.City, _ = path(record, "city", "names", "en").(string)
+	}
+	if l.ASN == 0 {
+		if asn, ok := path(record, "autonomous_system_number").(uint64); ok {
+			l.ASN = uint(asn)
+		}
+	}
+	if l.Organization == "" {
+		l.Organization, _ = path(record, "autonomous_system_organization").(string)
+	}
+	if l.Coordinates == nil {
+		latitude, latOK := path(record, "location", "latitude").(float64)
+		longitude, lonOK := path(record, "location", "longitude").(float64)
+		if latOK && lonOK {
+			l.Coordinates = &Coordinates{Latitude: latitude, Longitude: longitude}
+		}
+	}
+}
+
+func (l *Location) empty() bool {
+	return *l == Location{}
+}
+
+// path returns the value at the keys of nested maps, nil when missing.
+func path(value any, keys ...string) any {
+	for _, key := range keys {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
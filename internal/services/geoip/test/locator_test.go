@@ -0,0 +1,240 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"math"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/services/geoip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocate(t *testing.T) {
+	dir := t.TempDir()
+	city := writeDatabase(t, filepath.Join(dir, "city.mmdb"), map[string]map[string]any{
+		"81.2.69.0/24": {
+			"country":  map[string]any{"iso_code": "DE"},
+			"city":     map[string]any{"names": map[string]any{"en": "Berlin", "de": "Berlin"}},
+			"location": map[string]any{"latitude": 52.52, "longitude": 13.4},
+		},
+		"2001:db8::/32": {
+			"registered_country": map[string]any{"iso_code": "US"},
+		},
+	})
+	asn := writeDatabase(t, filepath.Join(dir, "asn.mmdb"), map[string]map[string]any{
+		"81.2.0.0/16": {
+			"autonomous_system_number":       uint32(3320),
+			"autonomous_system_organization": "Deutsche Telekom AG",
+		},
+	})
+	locator, err := geoip.NewLocator(config.GeoIP{Databases: []string{city, asn}, ReloadInterval: time.Minute})
+	require.NoError(t, err)
+
+	location, err := locator.Locate("81.2.69.160")
+	require.NoError(t, err)
+	assert.Equal(t, &geoip.Location{
+		Country:      "DE",
+		City:         "Berlin",
+		ASN:          3320,
+		Organization: "Deutsche Telekom AG",
+		Coordinates:  &geoip.Coordinates{Latitude: 52.52, Longitude: 13.4},
+	}, location)
+	assert.Equal(t, "Berlin, DE", location.String())
+
+	location, err = locator.Locate("::ffff:81.2.69.160")
+	require.NoError(t, err)
+	assert.Equal(t, "Berlin, DE", location.String())
+
+	location, err = locator.Locate("[2001:db8::1]")
+	require.NoError(t, err)
+	assert.Equal(t, "US", location.String())
+
+	location, err = locator.Locate("81.2.1.1")
+	require.NoError(t, err)
+	assert.Equal(t, &geoip.Location{ASN: 3320, Organization: "Deutsche Telekom AG"}, location)
+	assert.Empty(t, location.String())
+
+	for _, ip := range []string{"192.0.2.1", "2001:db9::1", "unknown"} {
+		location, err = locator.Locate(ip)
+		require.NoError(t, err)
+		assert.Nil(t, location, ip)
+	}
+}
+
+func TestLocate_NoDatabases(t *testing.T) {
+	locator, err := geoip.NewLocator(config.GeoIP{})
+	require.NoError(t, err)
+
+	location, err := locator.Locate("81.2.69.160")
+	require.NoError(t, err)
+	assert.Nil(t, location)
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeDatabase(t, path, map[string]map[string]any{
+		"81.2.69.0/24": {"country": map[string]any{"iso_code": "DE"}},
+	})
+	locator, err := geoip.NewLocator(config.GeoIP{Databases: []string{path}, ReloadInterval: time.Minute})
+	require.NoError(t, err)
+
+	writeDatabase(t, path, map[string]map[string]any{
+		"81.2.69.0/24": {"country": map[string]any{"iso_code": "FR"}},
+	})
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+	require.NoError(t, locator.Reload())
+
+	location, err := locator.Locate("81.2.69.160")
+	require.NoError(t, err)
+	assert.Equal(t, "FR", location.String())
+
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0o644))
+	assert.Error(t, locator.Reload())
+	location, err = locator.Locate("81.2.69.160")
+	require.NoError(t, err)
+	assert.Equal(t, "FR", location.String(), "the previous database must stay in use")
+}
+
+func TestNewLocator_InvalidDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0o644))
+
+	_, err := geoip.NewLocator(config.GeoIP{Databases: []string{path}})
+
+	assert.Error(t, err)
+}
+
+// writeDatabase writes an IPv6 database with 24-bit records. IPv4 networks
+// are stored under ::/96.
+func writeDatabase(t *testing.T, path string, networks map[string]map[string]any) string {
+	t.Helper()
+	prefixes := make([]string, 0, len(networks))
+	for prefix := range networks {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	data := &dataWriter{strings: map[string]int{}}
+	// a node has two records: the index of a node, or the data offset + 1
+	// negated, or 0 for none
+	nodes := [][2]int{{}}
+	for _, prefix := range prefixes {
+		network := netip.MustParsePrefix(prefix)
+		bits := network.Bits()
+		addr := network.Addr().As16()
+		if network.Addr().Is4() {
+			bits += 96
+			addr = netip.AddrFrom16([16]byte{}).As16()
+			copy(addr[12:], network.Addr().AsSlice())
+		}
+		dataRecord := -(data.write(networks[prefix]) + 1)
+
+		node := 0
+		for i := 0; i < bits; i++ {
+			bit := (addr[i/8] >> (7 - i%8)) & 1
+			if i == bits-1 {
+				nodes[node][bit] = dataRecord
+				break
+			}
+			if nodes[node][bit] <= 0 {
+				nodes = append(nodes, [2]int{})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+	}
+
+	var buf []byte
+	nodeCount := len(nodes)
+	for _, node := range nodes {
+		for _, record := range node {
+			value := nodeCount
+			switch {
+			case record > 0:
+				value = record
+			case record < 0:
+				value = nodeCount + 16 + (-record - 1)
+			}
+			buf = append(buf, byte(value>>16), byte(value>>8), byte(value))
+		}
+	}
+	buf = append(buf, make([]byte, 16)...)
+	buf = append(buf, data.buf...)
+	buf = append(buf, "\xab\xcd\xefMaxMind.com"...)
+	metadata := &dataWriter{strings: map[string]int{}}
+	metadata.write(map[string]any{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(6),
+		"binary_format_major_version": uint16(2),
+		"database_type":               "Test",
+	})
+	buf = append(buf, metadata.buf...)
+
+	require.NoError(t, os.WriteFile(path, buf, 0o644))
+	return path
+}
+
+// dataWriter encodes values of the data section. Strings written before are
+// written as pointers.
+type dataWriter struct {
+	buf     []byte
+	strings map[string]int
+}
+
+func (w *dataWriter) write(value any) int {
+	offset := len(w.buf)
+	switch v := value.(type) {
+	case string:
+		if previous, ok := w.strings[v]; ok && previous < 2048 {
+			w.buf = append(w.buf, 1<<5|byte(previous>>8), byte(previous))
+			return offset
+		}
+		w.strings[v] = offset
+		w.control(2, len(v))
+		w.buf = append(w.buf, v...)
+	case float64:
+		w.control(3, 8)
+		w.buf = binary.BigEndian.AppendUint64(w.buf, math.Float64bits(v))
+	case uint16:
+		w.control(5, 2)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, v)
+	case uint32:
+		w.control(6, 4)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, v)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		w.control(7, len(v))
+		for _, key := range keys {
+			w.write(key)
+			w.write(v[key])
+		}
+	default:
+		panic("unsupported type")
+	}
+	return offset
+}
+
+func (w *dataWriter) control(kind, size int) {
+	switch {
+	case size < 29:
+		w.buf = append(w.buf, byte(kind<<5|size))
+	case size < 29+256:
+		w.buf = append(w.buf, byte(kind<<5|29), byte(size-29))
+	default:
+		panic("unsupported size")
+	}
+}
@@ -0,0 +1,16 @@
+package impersonation
+
+import (
+	"auth/internal/services/email"
+	"time"
+)
+
+func ImpersonationEmail(impersonation Impersonation) email.Email {
+	return email.Email{
+		Template: email.TemplateImpersonation,
+		Data: map[string]string{
+			"time":   impersonation.CreatedAt.In(time.UTC).Format("2006-01-02 15:04:05") + " (UTC)",
+			"reason": impersonation.Reason,
+		},
+	}
+}
@@ -0,0 +1,9 @@
+package impersonation
+
+type ValidationError struct {
+	message string
+}
+
+func (err ValidationError) Error() string {
+	return err.message
+}
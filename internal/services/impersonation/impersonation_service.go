@@ -0,0 +1,118 @@
+package impersonation
+
+import (
+	"auth/internal/config"
+	"auth/internal/services/auth"
+	"auth/internal/services/email"
+	"auth/internal/services/user"
+	logutils "auth/internal/utils/log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type ImpersonationService struct {
+	storage      Storage
+	userStorage  UserStorage
+	tokenIssuer  TokenIssuer
+	emailService EmailService
+	duration     time.Duration
+	emails       config.Emails
+}
+
+//go:generate mockery --name Storage --filename storage.go
+type Storage interface {
+	Create(record *Impersonation) error
+	// List returns the impersonations of the user, or of all users for
+	// uuid.Nil, newest first.
+	List(userID uuid.UUID) ([]Impersonation, error)
+}
+
+//go:generate mockery --name UserStorage --filename user_storage.go
+type UserStorage interface {
+	Get(id uuid.UUID) (*user.User, error)
+}
+
+//go:generate mockery --name TokenIssuer --filename token_issuer.go
+type TokenIssuer interface {
+	CreateAccessAndRefreshTokens(userID uuid.UUID, requestIP string, opts ...auth.TokenOption) (string, string, error)
+}
+
+//go:generate mockery --name EmailService --filename email_service.go
+type EmailService interface {
+	SendEmailToUser(from string, userID uuid.UUID, msg email.Email) error
+}
+
+func NewImpersonationService(
+	storage Storage,
+	userStorage UserStorage,
+	tokenIssuer TokenIssuer,
+	emailService EmailService,
+	duration time.Duration,
+	emails config.Emails,
+) *ImpersonationService {
+	return &ImpersonationService{
+		storage:      storage,
+		userStorage:  userStorage,
+		tokenIssuer:  tokenIssuer,
+		emailService: emailService,
+		duration:     duration,
+		emails:       emails,
+	}
+}
+
+// Impersonate opens a session as the user for the admin. The impersonation is
+// recorded before the token is issued, so that no session exists without an
+// audit record, and the user is told about it by email.
+func (s *ImpersonationService) Impersonate(req ImpersonateRequest) (*Session, error) {
+	if req.Reason == "" {
+		return nil, ValidationError{"reason is required"}
+	}
+	if req.AdminID == req.UserID {
+		return nil, ValidationError{"admins cannot impersonate themselves"}
+	}
+	if _, err := s.userStorage.Get(req.UserID); err != nil {
+		return nil, errors.Wrap(err, "get user")
+	}
+
+	impersonation := &Impersonation{
+		AdminID:   req.AdminID,
+		UserID:    req.UserID,
+		Reason:    req.Reason,
+		AdminIP:   req.RequestIP,
+		ExpiresAt: time.Now().Add(s.duration),
+	}
+	if err := s.storage.Create(impersonation); err != nil {
+		return nil, errors.Wrap(err, "create impersonation")
+	}
+
+	accessToken, _, err := s.tokenIssuer.CreateAccessAndRefreshTokens(
+		req.UserID,
+		req.RequestIP,
+		auth.WithImpersonator(req.AdminID),
+		auth.WithLifetime(s.duration),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "create access token")
+	}
+
+	err = s.emailService.SendEmailToUser(s.emails.SupportEmail, req.UserID, ImpersonationEmail(*impersonation))
+	if err != nil {
+		logutils.Error("send impersonation email error", err)
+	}
+
+	return &Session{
+		AccessToken:   accessToken,
+		Impersonation: *impersonation,
+	}, nil
+}
+
+func (s *ImpersonationService) ListImpersonations(userID uuid.UUID) ([]Impersonation, error) {
+	impersonations, err := s.storage.List(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list impersonations")
+	}
+
+	return impersonations, nil
+}
@@ -3,8 +3,11 @@
 package mocks
 
 import (
-	uuid "github.com/google/uuid"
 	mock "github.com/stretchr/testify/mock"
+
+	email "auth/internal/services/email"
+
+	uuid "github.com/google/uuid"
 )
 
 // EmailService is an autogenerated mock type for the EmailService type
@@ -13,7 +16,7 @@ type EmailService struct {
 }
 
 // SendEmailToUser provides a mock function with given fields: from, userID, msg
-func (_m *EmailService) SendEmailToUser(from string, userID uuid.UUID, msg []byte) error {
+func (_m *EmailService) SendEmailToUser(from string, userID uuid.UUID, msg email.Email) error {
 	ret := _m.Called(from, userID, msg)
 
 	if len(ret) == 0 {
@@ -21,7 +24,7 @@ func (_m *EmailService) SendEmailToUser(from string, userID uuid.UUID, msg []byt
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, uuid.UUID, []byte) error); ok {
+	if rf, ok := ret.Get(0).(func(string, uuid.UUID, email.Email) error); ok {
 		r0 = rf(from, userID, msg)
 	} else {
 		r0 = ret.Error(0)
@@ -0,0 +1,32 @@
+package impersonation
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Impersonation is the audit record of an admin acting as a user.
+type Impersonation struct {
+	ID        uuid.UUID
+	AdminID   uuid.UUID
+	UserID    uuid.UUID
+	Reason    string
+	AdminIP   string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type ImpersonateRequest struct {
+	AdminID   uuid.UUID
+	UserID    uuid.UUID
+	Reason    string
+	RequestIP string
+}
+
+// Session is the access token of an impersonation. There is no refresh
+// token, the admin has to start a new impersonation when it expires.
+type Session struct {
+	AccessToken   string
+	Impersonation Impersonation
+}
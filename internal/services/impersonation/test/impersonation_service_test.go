@@ -0,0 +1,86 @@
+package impersonation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/services/impersonation"
+	"auth/internal/services/impersonation/mocks"
+	"auth/internal/services/user"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	adminID  = uuid.MustParse("0b7c6e38-7bb2-4d4c-9d0e-6f3a1f1f0a01")
+	userID   = uuid.MustParse("8798e65e-dc84-4a7d-879e-2a52e67d86da")
+	ip       = "10.0.0.1"
+	duration = 15 * time.Minute
+	emails   = config.Emails{SupportEmail: "support@company.com"}
+)
+
+func TestImpersonate(t *testing.T) {
+	service, storage, userStorage, tokenIssuer, emailService := newServiceAndMocks(t)
+	userStorage.On("Get", userID).Return(&user.User{ID: userID}, nil)
+	storage.
+		On("Create", mock.MatchedBy(func(record *impersonation.Impersonation) bool {
+			return record.AdminID == adminID && record.UserID == userID && record.Reason == "ticket 42"
+		})).
+		Run(func(args mock.Arguments) { args.Get(0).(*impersonation.Impersonation).ID = uuid.New() }).
+		Return(nil)
+	tokenIssuer.
+		On("CreateAccessAndRefreshTokens", userID, ip, mock.Anything, mock.Anything).
+		Return("access", "", nil)
+	emailService.On("SendEmailToUser", emails.SupportEmail, userID, mock.Anything).Return(nil)
+
+	session, err := service.Impersonate(newImpersonateRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, "access", session.AccessToken)
+	assert.NotEqual(t, uuid.Nil, session.Impersonation.ID)
+	assert.WithinDuration(t, time.Now().Add(duration), session.Impersonation.ExpiresAt, time.Second)
+}
+
+func TestImpersonate_AuditFailure(t *testing.T) {
+	service, storage, userStorage, _, _ := newServiceAndMocks(t)
+	userStorage.On("Get", userID).Return(&user.User{ID: userID}, nil)
+	storage.On("Create", mock.Anything).Return(errors.New("db is down"))
+
+	_, err := service.Impersonate(newImpersonateRequest())
+
+	assert.Error(t, err, "no session may be issued without an audit record")
+}
+
+func TestImpersonate_ReasonRequired(t *testing.T) {
+	service, _, _, _, _ := newServiceAndMocks(t)
+
+	req := newImpersonateRequest()
+	req.Reason = ""
+	_, err := service.Impersonate(req)
+
+	assert.ErrorAs(t, err, &impersonation.ValidationError{})
+}
+
+func newImpersonateRequest() impersonation.ImpersonateRequest {
+	return impersonation.ImpersonateRequest{
+		AdminID:   adminID,
+		UserID:    userID,
+		Reason:    "ticket 42",
+		RequestIP: ip,
+	}
+}
+
+func newServiceAndMocks(t *testing.T) (*impersonation.ImpersonationService, *mocks.Storage, *mocks.UserStorage, *mocks.TokenIssuer, *mocks.EmailService) {
+	storage := mocks.NewStorage(t)
+	userStorage := mocks.NewUserStorage(t)
+	tokenIssuer := mocks.NewTokenIssuer(t)
+	emailService := mocks.NewEmailService(t)
+	service := impersonation.NewImpersonationService(storage, userStorage, tokenIssuer, emailService, duration, emails)
+
+	return service, storage, userStorage, tokenIssuer, emailService
+}
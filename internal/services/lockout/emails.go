@@ -0,0 +1,16 @@
+package lockout
+
+import (
+	"auth/internal/services/email"
+	"time"
+)
+
+func AccountLockedEmail(lockedUntil time.Time, unlockURL string) email.Email {
+	return email.Email{
+		Template: email.TemplateAccountLocked,
+		Data: map[string]string{
+			"lockedUntil": lockedUntil.In(time.UTC).Format("2006-01-02 15:04:05") + " (UTC)",
+			"unlockUrl":   unlockURL,
+		},
+	}
+}
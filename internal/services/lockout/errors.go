@@ -0,0 +1,25 @@
+package lockout
+
+import "time"
+
+// LockedError is returned for accounts that may not authenticate before
+// RetryAt, either because of the backoff or because they are locked.
+type LockedError struct {
+	RetryAt time.Time
+}
+
+func (err LockedError) Error() string {
+	return "account is temporarily locked"
+}
+
+type NotFoundError struct {
+	message string
+}
+
+func (err NotFoundError) Error() string {
+	return err.message
+}
+
+func NewNotFoundError(message string) NotFoundError {
+	return NotFoundError{message: message}
+}
@@ -0,0 +1,175 @@
+package lockout
+
+import (
+	"auth/internal/config"
+	"auth/internal/services/email"
+	logutils "auth/internal/utils/log"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type LockoutService struct {
+	storage      Storage
+	emailService EmailService
+	threshold    int
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+	duration     time.Duration
+	unlockURL    string
+	emails       config.Emails
+}
+
+//go:generate mockery --name Storage --filename storage.go
+type Storage interface {
+	// Get returns NotFoundError for accounts without failures.
+	Get(userID uuid.UUID) (*Lockout, error)
+	// RecordFailure counts a failure and returns the new state. The count
+	// starts over once a lock has expired.
+	RecordFailure(userID uuid.UUID, failedAt time.Time) (*Lockout, error)
+	Lock(userID uuid.UUID, lockedUntil time.Time, unlockTokenHash []byte) error
+	// Delete resets the account. It is a no-op for accounts without
+	// failures.
+	Delete(userID uuid.UUID) error
+	// DeleteByUnlockToken resets the account of a lock that has not expired
+	// yet. It returns NotFoundError for unknown or expired tokens.
+	DeleteByUnlockToken(unlockTokenHash []byte, now time.Time) error
+	ListLocked(now time.Time) ([]Lockout, error)
+}
+
+//go:generate mockery --name EmailService --filename email_service.go
+type EmailService interface {
+	SendEmailToUser(from string, userID uuid.UUID, msg email.Email) error
+}
+
+func NewLockoutService(
+	storage Storage,
+	emailService EmailService,
+	cfg config.Lockout,
+	emails config.Emails,
+) *LockoutService {
+	return &LockoutService{
+		storage:      storage,
+		emailService: emailService,
+		threshold:    cfg.Threshold,
+		baseDelay:    cfg.BaseDelay,
+		maxDelay:     cfg.MaxDelay,
+		duration:     cfg.Duration,
+		unlockURL:    cfg.UnlockURL,
+		emails:       emails,
+	}
+}
+
+// Check returns LockedError while the account is locked or waits for the
+// backoff of its last failure. Call it before comparing any secret, so that
+// locked accounts cost no hashing.
+func (s *LockoutService) Check(userID uuid.UUID) error {
+	lockout, err := s.storage.Get(userID)
+	if err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return nil
+		}
+		return errors.Wrap(err, "get lockout")
+	}
+
+	if retryAt := s.retryAt(lockout); time.Now().Before(retryAt) {
+		return LockedError{RetryAt: retryAt}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed authentication. The account is locked when
+// the failures reach the threshold and the user gets an unlock link.
+func (s *LockoutService) RecordFailure(userID uuid.UUID) error {
+	now := time.Now()
+	lockout, err := s.storage.RecordFailure(userID, now)
+	if err != nil {
+		return errors.Wrap(err, "record failure")
+	}
+	if lockout.Failures < s.threshold || !lockout.LockedUntil.IsZero() {
+		return nil
+	}
+
+	token, tokenHash, err := generateUnlockToken()
+	if err != nil {
+		return errors.Wrap(err, "generate unlock token")
+	}
+	lockedUntil := now.Add(s.duration)
+	if err := s.storage.Lock(userID, lockedUntil, tokenHash); err != nil {
+		return errors.Wrap(err, "lock account")
+	}
+
+	unlockURL := s.unlockURL + "?" + url.Values{"token": {token}}.Encode()
+	err = s.emailService.SendEmailToUser(s.emails.SupportEmail, userID, AccountLockedEmail(lockedUntil, unlockURL))
+	if err != nil {
+		logutils.Error("send email error", err)
+	}
+
+	return nil
+}
+
+// RecordSuccess resets the failures of the account.
+func (s *LockoutService) RecordSuccess(userID uuid.UUID) error {
+	return errors.Wrap(s.storage.Delete(userID), "delete lockout")
+}
+
+// Unlock resets the account with the token of the unlock link.
+func (s *LockoutService) Unlock(token string) error {
+	err := s.storage.DeleteByUnlockToken(hashUnlockToken(token), time.Now())
+	return errors.Wrap(err, "delete lockout")
+}
+
+// UnlockUser resets the account on behalf of an admin.
+func (s *LockoutService) UnlockUser(userID uuid.UUID) error {
+	return errors.Wrap(s.storage.Delete(userID), "delete lockout")
+}
+
+func (s *LockoutService) ListLocked() ([]Lockout, error) {
+	lockouts, err := s.storage.ListLocked(time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "list locked accounts")
+	}
+
+	return lockouts, nil
+}
+
+// retryAt is the end of the lock, or of the backoff that doubles with each
+// failure below the threshold.
+func (s *LockoutService) retryAt(lockout *Lockout) time.Time {
+	if !lockout.LockedUntil.IsZero() {
+		return lockout.LockedUntil
+	}
+	if lockout.Failures == 0 {
+		return time.Time{}
+	}
+
+	delay := s.baseDelay
+	for i := 1; i < lockout.Failures && delay < s.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.maxDelay {
+		delay = s.maxDelay
+	}
+	return lockout.LastFailureAt.Add(delay)
+}
+
+func generateUnlockToken() (string, []byte, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", nil, errors.Wrap(err, "read random bytes")
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	return token, hashUnlockToken(token), nil
+}
+
+func hashUnlockToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
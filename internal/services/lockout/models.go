@@ -0,0 +1,16 @@
+package lockout
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lockout counts the consecutive failed authentications of an account.
+// LockedUntil is zero unless the threshold was reached.
+type Lockout struct {
+	UserID        uuid.UUID
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
@@ -0,0 +1,97 @@
+package lockout
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/services/email"
+	"auth/internal/services/lockout"
+	"auth/internal/services/lockout/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	userID = uuid.MustParse("8798e65e-dc84-4a7d-879e-2a52e67d86da")
+	cfg    = config.Lockout{
+		Threshold: 3,
+		BaseDelay: time.Second,
+		MaxDelay:  time.Minute,
+		Duration:  time.Hour,
+		UnlockURL: "https://auth.company.com/unlock",
+	}
+	emails = config.Emails{SupportEmail: "support@company.com"}
+)
+
+func TestCheck_Backoff(t *testing.T) {
+	service, storage, _ := newServiceAndMocks(t)
+	lastFailureAt := time.Now()
+	storage.On("Get", userID).Return(&lockout.Lockout{UserID: userID, Failures: 2, LastFailureAt: lastFailureAt}, nil)
+
+	err := service.Check(userID)
+
+	assert.Equal(t, lockout.LockedError{RetryAt: lastFailureAt.Add(2 * time.Second)}, err)
+}
+
+func TestCheck_BackoffElapsed(t *testing.T) {
+	service, storage, _ := newServiceAndMocks(t)
+	storage.
+		On("Get", userID).
+		Return(&lockout.Lockout{UserID: userID, Failures: 2, LastFailureAt: time.Now().Add(-3 * time.Second)}, nil)
+
+	assert.NoError(t, service.Check(userID))
+}
+
+func TestCheck_NoFailures(t *testing.T) {
+	service, storage, _ := newServiceAndMocks(t)
+	storage.On("Get", userID).Return(nil, lockout.NewNotFoundError("lockout not found"))
+
+	assert.NoError(t, service.Check(userID))
+}
+
+func TestRecordFailure_LocksAtThreshold(t *testing.T) {
+	service, storage, emailService := newServiceAndMocks(t)
+	storage.
+		On("RecordFailure", userID, mock.Anything).
+		Return(&lockout.Lockout{UserID: userID, Failures: cfg.Threshold, LastFailureAt: time.Now()}, nil)
+	var unlockTokenHash []byte
+	storage.
+		On("Lock", userID, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { unlockTokenHash = args.Get(2).([]byte) }).
+		Return(nil)
+	var msg email.Email
+	emailService.
+		On("SendEmailToUser", emails.SupportEmail, userID, mock.Anything).
+		Run(func(args mock.Arguments) { msg = args.Get(2).(email.Email) }).
+		Return(nil)
+
+	require.NoError(t, service.RecordFailure(userID))
+
+	assert.Equal(t, email.TemplateAccountLocked, msg.Template)
+	unlockURL, err := url.Parse(msg.Data["unlockUrl"])
+	require.NoError(t, err)
+	token := unlockURL.Query().Get("token")
+	require.NotEmpty(t, token)
+	storage.On("DeleteByUnlockToken", unlockTokenHash, mock.Anything).Return(nil)
+	assert.NoError(t, service.Unlock(token))
+}
+
+func TestRecordFailure_BelowThreshold(t *testing.T) {
+	service, storage, _ := newServiceAndMocks(t)
+	storage.
+		On("RecordFailure", userID, mock.Anything).
+		Return(&lockout.Lockout{UserID: userID, Failures: cfg.Threshold - 1, LastFailureAt: time.Now()}, nil)
+
+	assert.NoError(t, service.RecordFailure(userID))
+}
+
+func newServiceAndMocks(t *testing.T) (*lockout.LockoutService, *mocks.Storage, *mocks.EmailService) {
+	storage := mocks.NewStorage(t)
+	emailService := mocks.NewEmailService(t)
+	return lockout.NewLockoutService(storage, emailService, cfg, emails), storage, emailService
+}
@@ -0,0 +1,194 @@
+package notification
+
+import (
+	"auth/internal/services/audit"
+	"auth/internal/services/webhook"
+	logutils "auth/internal/utils/log"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// createAction stores a "This wasn't me" action and returns its link. The
+// token is the action ID and its HMAC, so that forged tokens are rejected
+// before the storage is asked.
+func (s *NotificationService) createAction(userID, sessionID uuid.UUID, ip string) (string, error) {
+	now := time.Now()
+	action := &Action{
+		ID:        uuid.New(),
+		UserID:    userID,
+		SessionID: sessionID,
+		IP:        ip,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.actionDuration),
+	}
+	if err := s.storage.CreateAction(action); err != nil {
+		return "", errors.Wrap(err, "create action")
+	}
+
+	actionURL, err := url.Parse(s.actionURL)
+	if err != nil {
+		return "", errors.Wrap(err, "parse action url")
+	}
+	query := actionURL.Query()
+	query.Set("token", s.signAction(action.ID))
+	actionURL.RawQuery = query.Encode()
+
+	return actionURL.String(), nil
+}
+
+func (s *NotificationService) signAction(id uuid.UUID) string {
+	mac := hmac.New(sha256.New, s.actionKey)
+	mac.Write(id[:])
+	return base64.RawURLEncoding.EncodeToString(id[:]) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseAction returns the ID of a genuine token.
+func (s *NotificationService) parseAction(token string) (uuid.UUID, error) {
+	encodedID, _, _ := strings.Cut(token, ".")
+	rawID, err := base64.RawURLEncoding.DecodeString(encodedID)
+	if err != nil || len(rawID) != len(uuid.UUID{}) {
+		return uuid.Nil, NewNotFoundError("action not found")
+	}
+	id := uuid.UUID(rawID)
+	if !hmac.Equal([]byte(token), []byte(s.signAction(id))) {
+		return uuid.Nil, NewNotFoundError("action not found")
+	}
+	return id, nil
+}
+
+// GetAction returns the action of the link, to let the user choose what to
+// revoke. It does not use the action, since mail scanners open links too.
+func (s *NotificationService) GetAction(token, requestIP string) (*Action, error) {
+	id, err := s.parseAction(token)
+	if err != nil {
+		return nil, err
+	}
+	action, err := s.storage.GetAction(id, time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "get action")
+	}
+
+	s.recordEvent(audit.Event{
+		Type:    audit.EventSecurityActionOpened,
+		UserID:  action.UserID,
+		IP:      requestIP,
+		Details: map[string]string{"actionId": action.ID.String()},
+	})
+	return action, nil
+}
+
+// UseAction revokes the session of the alert, or all sessions and API keys of
+// the user, since a session in the wrong hands may have created keys. Each
+// action may be used once, and is used by a successful revocation only.
+func (s *NotificationService) UseAction(req ActionRequest) (*ActionResult, error) {
+	if req.Revoke != RevokeSession && req.Revoke != RevokeAll {
+		return nil, ValidationError{"revoke must be session or all"}
+	}
+	id, err := s.parseAction(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	action, err := s.storage.GetAction(id, time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "get action")
+	}
+	revokeAll := req.Revoke == RevokeAll || req.ResetCredentials
+	if !revokeAll && action.SessionID == uuid.Nil {
+		return nil, ValidationError{"the alert is not about a single session"}
+	}
+
+	result := &ActionResult{}
+	if revokeAll {
+		result.RevokedSessions, result.RevokedAPIKeys, err = s.revokeAll(action, req)
+	} else {
+		err = s.revokeSession(action, req.RequestIP)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the action is used only once the revocation succeeded, so that the
+	// user may retry a failed one. Revoking again is harmless, so a failure
+	// to mark the action does not undo the revocation.
+	if used, err := s.storage.UseAction(id, time.Now()); err != nil {
+		logutils.Error("use action error", err)
+	} else {
+		action = used
+	}
+	result.Action = *action
+
+	s.recordEvent(audit.Event{
+		Type:   audit.EventSecurityActionUsed,
+		UserID: action.UserID,
+		IP:     req.RequestIP,
+		Details: map[string]string{
+			"actionId":         action.ID.String(),
+			"revoke":           req.Revoke,
+			"resetCredentials": strconv.FormatBool(req.ResetCredentials),
+			"revokedSessions":  strconv.FormatInt(result.RevokedSessions, 10),
+			"revokedApiKeys":   strconv.FormatInt(result.RevokedAPIKeys, 10),
+		},
+	})
+	return result, nil
+}
+
+func (s *NotificationService) revokeSession(action *Action, requestIP string) error {
+	event, err := webhook.NewEvent(webhook.EventSessionRevoked, webhook.SessionPayload{
+		UserID:    action.UserID,
+		SessionID: action.SessionID,
+		IP:        requestIP,
+	})
+	if err != nil {
+		return errors.Wrap(err, "create session event")
+	}
+	deleted, err := s.sessionStorage.DeleteFamily(action.SessionID, event)
+	if err != nil {
+		return errors.Wrap(err, "delete session")
+	}
+	if deleted == 0 {
+		return NewNotFoundError("the session has ended already")
+	}
+	return nil
+}
+
+// revokeAll revokes the API keys before the sessions, so that no key created
+// by a revoked session survives a failure in between.
+func (s *NotificationService) revokeAll(action *Action, req ActionRequest) (int64, int64, error) {
+	payload := webhook.UserPayload{UserID: action.UserID, IP: req.RequestIP, Reason: "security_action"}
+	event, err := webhook.NewEvent(webhook.EventUserSessionsRevoked, payload)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "create sessions event")
+	}
+	events := []*webhook.Event{event}
+	if req.ResetCredentials {
+		event, err := webhook.NewEvent(webhook.EventCredentialResetRequired, payload)
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "create credential reset event")
+		}
+		events = append(events, event)
+	}
+
+	revokedKeys, err := s.apiKeyStorage.RevokeByUser(action.UserID)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "revoke api keys")
+	}
+	revokedSessions, err := s.sessionStorage.DeleteByUser(action.UserID, events...)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "delete sessions")
+	}
+	return revokedSessions, revokedKeys, nil
+}
+
+func (s *NotificationService) recordEvent(event audit.Event) {
+	if err := s.auditLog.Record(event); err != nil {
+		logutils.Error("record audit event error", err)
+	}
+}
@@ -0,0 +1,66 @@
+package notification
+
+import (
+	"auth/internal/services/email"
+	"slices"
+	"strings"
+	"time"
+)
+
+// alertTemplates are the templates of the alerts sent one by one.
+var alertTemplates = map[string]string{
+	AlertNewIP: email.TemplateNewIP,
+}
+
+// alertEmail adds the "This wasn't me" link to the data of the alert.
+func alertEmail(alert *Alert, actionURL string) email.Email {
+	data := make(map[string]string, len(alert.Data)+1)
+	for key, value := range alert.Data {
+		data[key] = value
+	}
+	data["actionUrl"] = actionURL
+
+	return email.Email{
+		Template:       alertTemplates[alert.Type],
+		AcceptLanguage: alert.AcceptLanguage,
+		Data:           data,
+	}
+}
+
+// digestEmail lists the alerts by type, one per line, oldest first. Every
+// type has its key so that the template may test for it.
+func digestEmail(alerts []Alert, actionURL string) email.Email {
+	slices.SortFunc(alerts, func(a, b Alert) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	lines := make(map[string][]string)
+	for _, alert := range alerts {
+		lines[alert.Type] = append(lines[alert.Type], digestLine(alert))
+	}
+	data := map[string]string{"actionUrl": actionURL}
+	for _, alertType := range AlertTypes {
+		data[alertType] = strings.Join(lines[alertType], "\n")
+	}
+
+	return email.Email{
+		Template:       email.TemplateAlertDigest,
+		AcceptLanguage: alerts[len(alerts)-1].AcceptLanguage,
+		Data:           data,
+	}
+}
+
+func digestLine(alert Alert) string {
+	switch alert.Type {
+	case AlertNewIP:
+		line := alert.Data["time"] + ", " + alert.Data["ip"]
+		if location := alert.Data["location"]; location != "" {
+			line += " (" + location + ")"
+		}
+		return line
+	default:
+		return alert.Data["time"]
+	}
+}
+
+func formatTime(t time.Time) string {
+	return t.In(time.UTC).Format("2006-01-02 15:04:05") + " (UTC)"
+}
@@ -0,0 +1,21 @@
+package notification
+
+type ValidationError struct {
+	message string
+}
+
+func (err ValidationError) Error() string {
+	return err.message
+}
+
+type NotFoundError struct {
+	message string
+}
+
+func (err NotFoundError) Error() string {
+	return err.message
+}
+
+func NewNotFoundError(message string) NotFoundError {
+	return NotFoundError{message: message}
+}
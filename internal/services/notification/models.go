@@ -0,0 +1,82 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const AlertNewIP = "new_ip"
+
+// AlertTypes are the alerts users may opt out of.
+var AlertTypes = []string{AlertNewIP}
+
+type Preferences struct {
+	UserID uuid.UUID
+	// DisabledAlerts are the alert types the user opted out of.
+	DisabledAlerts []string
+	// Digest sends the alerts together periodically instead of one by one.
+	Digest    bool
+	UpdatedAt time.Time
+}
+
+type PreferencesRequest struct {
+	DisabledAlerts []string
+	Digest         bool
+}
+
+// KnownNetwork is a network the user has signed in from.
+type KnownNetwork struct {
+	UserID      uuid.UUID
+	Network     string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+// Alert is an alert waiting for the digest of the user.
+type Alert struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	Type           string
+	AcceptLanguage string
+	Data           map[string]string
+	CreatedAt      time.Time
+}
+
+const (
+	// RevokeSession ends the session the alert is about, RevokeAll every
+	// session of the user.
+	RevokeSession = "session"
+	RevokeAll     = "all"
+)
+
+// Action is the "This wasn't me" link of an alert. SessionID is the family
+// of refresh tokens of the session the alert is about, which survives
+// refreshes, uuid.Nil for digests.
+type Action struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	IP        string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    time.Time
+}
+
+type ActionRequest struct {
+	Token string
+	// Revoke is RevokeSession or RevokeAll.
+	Revoke string
+	// ResetCredentials asks the owner of the credentials to make the user
+	// choose new ones. It revokes all sessions and API keys.
+	ResetCredentials bool
+	RequestIP        string
+}
+
+type ActionResult struct {
+	Action Action
+	// RevokedSessions and RevokedAPIKeys count the sessions and API keys
+	// revoked with RevokeAll.
+	RevokedSessions int64
+	RevokedAPIKeys  int64
+}
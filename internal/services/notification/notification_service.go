@@ -0,0 +1,339 @@
+package notification
+
+import (
+	"auth/internal/config"
+	"auth/internal/services/audit"
+	"auth/internal/services/email"
+	"auth/internal/services/geoip"
+	"auth/internal/services/webhook"
+	iputils "auth/internal/utils/ip"
+	logutils "auth/internal/utils/log"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// NotificationService decides which security alerts users get, and how.
+type NotificationService struct {
+	storage        Storage
+	emailService   EmailService
+	sessionStorage SessionStorage
+	apiKeyStorage  APIKeyStorage
+	auditLog       AuditLog
+	locator        Locator
+	ipv4Prefix     int
+	ipv6Prefix     int
+	digestInterval time.Duration
+	pollInterval   time.Duration
+	actionURL      string
+	actionDuration time.Duration
+	// actionKey signs the action tokens.
+	actionKey []byte
+	emails    config.Emails
+}
+
+//go:generate mockery --name Storage --filename storage.go
+type Storage interface {
+	// GetPreferences returns NotFoundError for users that kept the defaults.
+	GetPreferences(userID uuid.UUID) (*Preferences, error)
+	SavePreferences(preferences *Preferences) error
+	// AddKnownNetwork records the network, or its last use when the user
+	// already knows it, and returns whether it is new.
+	AddKnownNetwork(userID uuid.UUID, network string, seenAt time.Time) (bool, error)
+	IsKnownNetwork(userID uuid.UUID, network string) (bool, error)
+	ListKnownNetworks(userID uuid.UUID) ([]KnownNetwork, error)
+	// DeleteKnownNetwork returns NotFoundError for networks the user does not
+	// know.
+	DeleteKnownNetwork(userID uuid.UUID, network string) error
+	AddAlert(alert *Alert) error
+	// ClaimDigests removes and returns the alerts of the users whose oldest
+	// alert was created before dueBefore.
+	ClaimDigests(dueBefore time.Time) ([]Alert, error)
+	CreateAction(action *Action) error
+	// GetAction and UseAction return NotFoundError for unknown, used and
+	// expired actions. UseAction marks the action used.
+	GetAction(id uuid.UUID, now time.Time) (*Action, error)
+	UseAction(id uuid.UUID, now time.Time) (*Action, error)
+}
+
+//go:generate mockery --name SessionStorage --filename session_storage.go
+type SessionStorage interface {
+	// DeleteFamily and DeleteByUser store the outbox events in the
+	// transaction of the change and return the number of refresh tokens
+	// deleted. DeleteFamily stores no event when there were none.
+	DeleteFamily(familyID uuid.UUID, event *webhook.Event) (int64, error)
+	DeleteByUser(userID uuid.UUID, events ...*webhook.Event) (int64, error)
+}
+
+//go:generate mockery --name APIKeyStorage --filename api_key_storage.go
+type APIKeyStorage interface {
+	// RevokeByUser revokes the keys of the user that are not revoked yet and
+	// returns their number.
+	RevokeByUser(userID uuid.UUID) (int64, error)
+}
+
+//go:generate mockery --name AuditLog --filename audit_log.go
+type AuditLog interface {
+	Record(event audit.Event) error
+}
+
+//go:generate mockery --name Locator --filename locator.go
+type Locator interface {
+	Locate(ip string) (*geoip.Location, error)
+}
+
+//go:generate mockery --name EmailService --filename email_service.go
+type EmailService interface {
+	SendEmailToUser(from string, userID uuid.UUID, msg email.Email) error
+}
+
+// NewNotificationService signs the action tokens with actionKey, which must
+// not serve another purpose. The locator may be nil, the alerts then name no
+// place.
+func NewNotificationService(
+	storage Storage,
+	emailService EmailService,
+	sessionStorage SessionStorage,
+	apiKeyStorage APIKeyStorage,
+	auditLog AuditLog,
+	locator Locator,
+	actionKey []byte,
+	cfg config.Notifications,
+	emails config.Emails,
+) *NotificationService {
+	return &NotificationService{
+		storage:        storage,
+		emailService:   emailService,
+		sessionStorage: sessionStorage,
+		apiKeyStorage:  apiKeyStorage,
+		auditLog:       auditLog,
+		locator:        locator,
+		ipv4Prefix:     cfg.IPv4Prefix,
+		ipv6Prefix:     cfg.IPv6Prefix,
+		digestInterval: cfg.DigestInterval,
+		pollInterval:   cfg.PollInterval,
+		actionURL:      cfg.ActionURL,
+		actionDuration: cfg.ActionDuration,
+		actionKey:      actionKey,
+		emails:         emails,
+	}
+}
+
+// NotifyNewIP alerts the user of a session that moved from previousIP to ip,
+// unless the user has signed in from the network of ip before. previousIP
+// becomes known as well, so that the networks of existing sessions do not
+// alert. It returns whether an email was sent right away. The email links to
+// an action that revokes the session, sessionID.
+func (s *NotificationService) NotifyNewIP(userID, sessionID uuid.UUID, previousIP, ip, acceptLanguage string) (bool, error) {
+	now := time.Now()
+	if previousIP != "" {
+		if _, err := s.storage.AddKnownNetwork(userID, s.Network(previousIP), now); err != nil {
+			return false, errors.Wrap(err, "add previous network")
+		}
+	}
+	isNew, err := s.storage.AddKnownNetwork(userID, s.Network(ip), now)
+	if err != nil {
+		return false, errors.Wrap(err, "add network")
+	}
+	if !isNew {
+		return false, nil
+	}
+
+	return s.alert(&Alert{
+		ID:             uuid.New(),
+		UserID:         userID,
+		Type:           AlertNewIP,
+		AcceptLanguage: acceptLanguage,
+		Data: map[string]string{
+			"time":     formatTime(now),
+			"ip":       ip,
+			"location": s.locate(ip),
+		},
+		CreatedAt: now,
+	}, sessionID)
+}
+
+// IsKnownIP returns whether ip is in the network of previousIP or in a
+// network the user has signed in from. It records nothing.
+func (s *NotificationService) IsKnownIP(userID uuid.UUID, previousIP, ip string) (bool, error) {
+	network := s.Network(ip)
+	if previousIP != "" && s.Network(previousIP) == network {
+		return true, nil
+	}
+	known, err := s.storage.IsKnownNetwork(userID, network)
+	if err != nil {
+		return false, errors.Wrap(err, "check known network")
+	}
+	return known, nil
+}
+
+// TrustIP makes the network of ip known without alerting, for addresses the
+// user has confirmed.
+func (s *NotificationService) TrustIP(userID uuid.UUID, ip string) error {
+	_, err := s.storage.AddKnownNetwork(userID, s.Network(ip), time.Now())
+	return errors.Wrap(err, "add network")
+}
+
+// SendStepUpCode emails the code that confirms a refresh from ip. The code is
+// sent regardless of the preferences, since the refresh waits for it.
+func (s *NotificationService) SendStepUpCode(userID uuid.UUID, code, ip, acceptLanguage string, expiresAt time.Time) error {
+	msg := email.Email{
+		Template:       email.TemplateStepUpCode,
+		AcceptLanguage: acceptLanguage,
+		Data: map[string]string{
+			"code":      code,
+			"ip":        ip,
+			"expiresAt": formatTime(expiresAt),
+		},
+	}
+	err := s.emailService.SendEmailToUser(s.emails.SupportEmail, userID, msg)
+	return errors.Wrap(err, "send step-up code")
+}
+
+// locate returns the place of ip for people, e.g. "Berlin, DE", or an empty
+// string when it is unknown.
+func (s *NotificationService) locate(ip string) string {
+	if s.locator == nil {
+		return ""
+	}
+	location, err := s.locator.Locate(ip)
+	if err != nil {
+		logutils.Error("locate ip error", err)
+		return ""
+	}
+	return location.String()
+}
+
+// Network returns the network of the address, the address itself when it
+// does not parse.
+func (s *NotificationService) Network(ip string) string {
+	return iputils.Network(ip, s.ipv4Prefix, s.ipv6Prefix)
+}
+
+// alert emails the alert or keeps it for the digest, as the user prefers.
+// The digest links to an action of its own.
+func (s *NotificationService) alert(alert *Alert, sessionID uuid.UUID) (bool, error) {
+	preferences, err := s.GetPreferences(alert.UserID)
+	if err != nil {
+		return false, err
+	}
+	if slices.Contains(preferences.DisabledAlerts, alert.Type) {
+		return false, nil
+	}
+	if preferences.Digest {
+		return false, errors.Wrap(s.storage.AddAlert(alert), "add alert")
+	}
+
+	actionURL, err := s.createAction(alert.UserID, sessionID, alert.Data["ip"])
+	if err != nil {
+		return false, err
+	}
+	err = s.emailService.SendEmailToUser(s.emails.SupportEmail, alert.UserID, alertEmail(alert, actionURL))
+	if err != nil {
+		return false, errors.Wrap(err, "send alert email")
+	}
+	return true, nil
+}
+
+// GetPreferences returns the preferences of the user, the defaults for users
+// that have not changed them.
+func (s *NotificationService) GetPreferences(userID uuid.UUID) (*Preferences, error) {
+	preferences, err := s.storage.GetPreferences(userID)
+	if err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return &Preferences{UserID: userID, DisabledAlerts: []string{}}, nil
+		}
+		return nil, errors.Wrap(err, "get preferences")
+	}
+	return preferences, nil
+}
+
+func (s *NotificationService) UpdatePreferences(userID uuid.UUID, req PreferencesRequest) (*Preferences, error) {
+	disabled := []string{}
+	for _, alertType := range req.DisabledAlerts {
+		if !slices.Contains(AlertTypes, alertType) {
+			return nil, ValidationError{"unknown alert type " + alertType}
+		}
+		if !slices.Contains(disabled, alertType) {
+			disabled = append(disabled, alertType)
+		}
+	}
+
+	preferences := &Preferences{
+		UserID:         userID,
+		DisabledAlerts: disabled,
+		Digest:         req.Digest,
+		UpdatedAt:      time.Now(),
+	}
+	if err := s.storage.SavePreferences(preferences); err != nil {
+		return nil, errors.Wrap(err, "save preferences")
+	}
+	return preferences, nil
+}
+
+func (s *NotificationService) ListKnownNetworks(userID uuid.UUID) ([]KnownNetwork, error) {
+	networks, err := s.storage.ListKnownNetworks(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list known networks")
+	}
+	return networks, nil
+}
+
+// ForgetNetwork makes the next sign-in from the network alert again.
+func (s *NotificationService) ForgetNetwork(userID uuid.UUID, network string) error {
+	return s.storage.DeleteKnownNetwork(userID, network)
+}
+
+// Run sends the digests that are due until the context is done.
+func (s *NotificationService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.SendDigests(); err != nil {
+			logutils.Error("send digests error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDigests emails the alerts of every user whose oldest alert is
+// digestInterval old, and returns the number of digests sent.
+func (s *NotificationService) SendDigests() (int, error) {
+	alerts, err := s.storage.ClaimDigests(time.Now().Add(-s.digestInterval))
+	if err != nil {
+		return 0, errors.Wrap(err, "claim digests")
+	}
+
+	byUser := make(map[uuid.UUID][]Alert)
+	var users []uuid.UUID
+	for _, alert := range alerts {
+		if _, ok := byUser[alert.UserID]; !ok {
+			users = append(users, alert.UserID)
+		}
+		byUser[alert.UserID] = append(byUser[alert.UserID], alert)
+	}
+
+	sent := 0
+	for _, userID := range users {
+		actionURL, err := s.createAction(userID, uuid.Nil, "")
+		if err != nil {
+			logutils.Error("create digest action error", err)
+			continue
+		}
+		err = s.emailService.SendEmailToUser(s.emails.SupportEmail, userID, digestEmail(byUser[userID], actionURL))
+		if err != nil {
+			logutils.Error("send digest error", err)
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
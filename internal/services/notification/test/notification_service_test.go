@@ -0,0 +1,350 @@
+package notification
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/services/audit"
+	"auth/internal/services/email"
+	"auth/internal/services/geoip"
+	"auth/internal/services/notification"
+	"auth/internal/services/notification/mocks"
+	"auth/internal/services/webhook"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	userID    = uuid.MustParse("8798e65e-dc84-4a7d-879e-2a52e67d86da")
+	sessionID = uuid.MustParse("1f0c2b0e-4a53-4a0d-9d8e-6a3b7c1d2e4f")
+	cfg       = config.Notifications{
+		IPv4Prefix:     24,
+		IPv6Prefix:     48,
+		DigestInterval: 24 * time.Hour,
+		PollInterval:   time.Minute,
+		ActionURL:      "https://company.com/security-actions",
+		ActionDuration: time.Hour,
+	}
+	emails = config.Emails{SupportEmail: "support@company.com"}
+)
+
+func TestNetwork(t *testing.T) {
+	service, _, _ := newServiceAndMocks(t)
+
+	assert.Equal(t, "203.0.113.0/24", service.Network("203.0.113.7"))
+	assert.Equal(t, "203.0.113.0/24", service.Network("::ffff:203.0.113.7"))
+	assert.Equal(t, "2001:db8:1::/48", service.Network("[2001:db8:1:2::7]"))
+	assert.Equal(t, "not an ip", service.Network("not an ip"))
+}
+
+func TestNotifyNewIP_KnownNetwork(t *testing.T) {
+	service, storage, _ := newServiceAndMocks(t)
+	storage.On("AddKnownNetwork", userID, "198.51.100.0/24", mock.Anything).Return(false, nil)
+	storage.On("AddKnownNetwork", userID, "203.0.113.0/24", mock.Anything).Return(false, nil)
+
+	sent, err := service.NotifyNewIP(userID, sessionID, "198.51.100.1", "203.0.113.7", "en")
+
+	require.NoError(t, err)
+	assert.False(t, sent)
+}
+
+func TestNotifyNewIP_SameNetwork(t *testing.T) {
+	service, storage, _ := newServiceAndMocks(t)
+	storage.On("AddKnownNetwork", userID, "203.0.113.0/24", mock.Anything).Return(true, nil).Once()
+	storage.On("AddKnownNetwork", userID, "203.0.113.0/24", mock.Anything).Return(false, nil).Once()
+
+	sent, err := service.NotifyNewIP(userID, sessionID, "203.0.113.1", "203.0.113.7", "en")
+
+	require.NoError(t, err)
+	assert.False(t, sent)
+}
+
+func TestNotifyNewIP_NewNetwork(t *testing.T) {
+	service, storage, emailService := newServiceAndMocks(t)
+	storage.On("AddKnownNetwork", userID, "198.51.100.0/24", mock.Anything).Return(false, nil)
+	storage.On("AddKnownNetwork", userID, "203.0.113.0/24", mock.Anything).Return(true, nil)
+	storage.On("GetPreferences", userID).Return(nil, notification.NewNotFoundError("preferences not found"))
+	storage.
+		On("CreateAction", mock.MatchedBy(func(action *notification.Action) bool {
+			return action.UserID == userID && action.SessionID == sessionID && action.IP == "203.0.113.7"
+		})).
+		Return(nil)
+	emailService.
+		On("SendEmailToUser", emails.SupportEmail, userID, mock.MatchedBy(func(msg email.Email) bool {
+			return msg.Template == email.TemplateNewIP && msg.AcceptLanguage == "en" && msg.Data["ip"] == "203.0.113.7" &&
+				msg.Data["location"] == "Berlin, DE" && strings.HasPrefix(msg.Data["actionUrl"], cfg.ActionURL+"?token=")
+		})).
+		Return(nil)
+
+	sent, err := service.NotifyNewIP(userID, sessionID, "198.51.100.1", "203.0.113.7", "en")
+
+	require.NoError(t, err)
+	assert.True(t, sent)
+}
+
+func TestNotifyNewIP_Disabled(t *testing.T) {
+	service, storage, _ := newServiceAndMocks(t)
+	storage.On("AddKnownNetwork", userID, mock.Anything, mock.Anything).Return(true, nil)
+	storage.
+		On("GetPreferences", userID).
+		Return(&notification.Preferences{UserID: userID, DisabledAlerts: []string{notification.AlertNewIP}}, nil)
+
+	sent, err := service.NotifyNewIP(userID, sessionID, "", "203.0.113.7", "en")
+
+	require.NoError(t, err)
+	assert.False(t, sent)
+}
+
+func TestNotifyNewIP_Digest(t *testing.T) {
+	service, storage, _ := newServiceAndMocks(t)
+	storage.On("AddKnownNetwork", userID, mock.Anything, mock.Anything).Return(true, nil)
+	storage.On("GetPreferences", userID).Return(&notification.Preferences{UserID: userID, Digest: true}, nil)
+	storage.
+		On("AddAlert", mock.MatchedBy(func(alert *notification.Alert) bool {
+			return alert.UserID == userID && alert.Type == notification.AlertNewIP && alert.Data["ip"] == "203.0.113.7"
+		})).
+		Return(nil)
+
+	sent, err := service.NotifyNewIP(userID, sessionID, "", "203.0.113.7", "en")
+
+	require.NoError(t, err)
+	assert.False(t, sent)
+}
+
+func TestUpdatePreferences_UnknownAlert(t *testing.T) {
+	service, _, _ := newServiceAndMocks(t)
+
+	_, err := service.UpdatePreferences(userID, notification.PreferencesRequest{DisabledAlerts: []string{"unknown"}})
+
+	assert.ErrorAs(t, err, &notification.ValidationError{})
+}
+
+func TestUpdatePreferences(t *testing.T) {
+	service, storage, _ := newServiceAndMocks(t)
+	storage.
+		On("SavePreferences", mock.MatchedBy(func(preferences *notification.Preferences) bool {
+			return preferences.UserID == userID && preferences.Digest &&
+				assert.ObjectsAreEqual([]string{notification.AlertNewIP}, preferences.DisabledAlerts)
+		})).
+		Return(nil)
+
+	_, err := service.UpdatePreferences(userID, notification.PreferencesRequest{
+		DisabledAlerts: []string{notification.AlertNewIP, notification.AlertNewIP},
+		Digest:         true,
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestSendDigests(t *testing.T) {
+	service, storage, emailService := newServiceAndMocks(t)
+	otherUserID := uuid.New()
+	now := time.Now()
+	storage.
+		On("ClaimDigests", mock.Anything).
+		Return([]notification.Alert{
+			{UserID: userID, Type: notification.AlertNewIP, AcceptLanguage: "ru",
+				Data: map[string]string{"time": "2", "ip": "198.51.100.1"}, CreatedAt: now},
+			{UserID: otherUserID, Type: notification.AlertNewIP,
+				Data: map[string]string{"time": "1", "ip": "192.0.2.1"}, CreatedAt: now},
+			{UserID: userID, Type: notification.AlertNewIP, AcceptLanguage: "en",
+				Data: map[string]string{"time": "1", "ip": "203.0.113.7", "location": "Berlin, DE"}, CreatedAt: now.Add(-time.Hour)},
+		}, nil)
+	storage.
+		On("CreateAction", mock.MatchedBy(func(action *notification.Action) bool { return action.SessionID == uuid.Nil })).
+		Return(nil)
+	emailService.
+		On("SendEmailToUser", emails.SupportEmail, userID, mock.MatchedBy(func(msg email.Email) bool {
+			return msg.Template == email.TemplateAlertDigest && msg.AcceptLanguage == "ru" &&
+				msg.Data[notification.AlertNewIP] == "1, 203.0.113.7 (Berlin, DE)\n2, 198.51.100.1" && msg.Data["actionUrl"] != ""
+		})).
+		Return(nil)
+	emailService.
+		On("SendEmailToUser", emails.SupportEmail, otherUserID, mock.Anything).
+		Return(nil)
+
+	sent, err := service.SendDigests()
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+}
+
+func TestGetAction_Forged(t *testing.T) {
+	service, _, _ := newServiceAndMocks(t)
+	otherService, storage, emailService := newServiceAndMocksWithKey(t, []byte("other key"))
+	token := newActionToken(t, otherService, storage, emailService)
+
+	_, err := service.GetAction(token, "192.0.2.1")
+
+	assert.ErrorAs(t, err, &notification.NotFoundError{})
+}
+
+func TestUseAction_RevokeSession(t *testing.T) {
+	service, storage, emailService, sessionStorage, _, auditLog := newServiceAndAllMocks(t, []byte("key"))
+	token := newActionToken(t, service, storage, emailService)
+	action := &notification.Action{ID: uuid.New(), UserID: userID, SessionID: sessionID}
+	storage.On("GetAction", mock.Anything, mock.Anything).Return(action, nil)
+	storage.On("UseAction", mock.Anything, mock.Anything).Return(action, nil)
+	sessionStorage.
+		On("DeleteFamily", sessionID, mock.MatchedBy(func(event *webhook.Event) bool {
+			return event.Type == webhook.EventSessionRevoked
+		})).
+		Return(int64(1), nil)
+	auditLog.
+		On("Record", mock.MatchedBy(func(event audit.Event) bool {
+			return event.Type == audit.EventSecurityActionUsed && event.Details["revoke"] == notification.RevokeSession
+		})).
+		Return(nil)
+
+	_, err := service.UseAction(notification.ActionRequest{Token: token, Revoke: notification.RevokeSession, RequestIP: "192.0.2.1"})
+
+	assert.NoError(t, err)
+}
+
+func TestUseAction_SessionEnded(t *testing.T) {
+	service, storage, emailService, sessionStorage, _, _ := newServiceAndAllMocks(t, []byte("key"))
+	token := newActionToken(t, service, storage, emailService)
+	action := &notification.Action{ID: uuid.New(), UserID: userID, SessionID: sessionID}
+	storage.On("GetAction", mock.Anything, mock.Anything).Return(action, nil)
+	sessionStorage.On("DeleteFamily", sessionID, mock.Anything).Return(int64(0), nil)
+
+	_, err := service.UseAction(notification.ActionRequest{Token: token, Revoke: notification.RevokeSession})
+
+	assert.ErrorAs(t, err, &notification.NotFoundError{})
+	storage.AssertNotCalled(t, "UseAction", mock.Anything, mock.Anything)
+}
+
+func TestUseAction_ResetCredentials(t *testing.T) {
+	service, storage, emailService, sessionStorage, apiKeyStorage, auditLog := newServiceAndAllMocks(t, []byte("key"))
+	token := newActionToken(t, service, storage, emailService)
+	action := &notification.Action{ID: uuid.New(), UserID: userID}
+	storage.On("GetAction", mock.Anything, mock.Anything).Return(action, nil)
+	storage.On("UseAction", mock.Anything, mock.Anything).Return(action, nil)
+	apiKeyStorage.On("RevokeByUser", userID).Return(int64(2), nil)
+	sessionStorage.
+		On("DeleteByUser", userID, mock.MatchedBy(func(event *webhook.Event) bool {
+			return event.Type == webhook.EventUserSessionsRevoked
+		}), mock.MatchedBy(func(event *webhook.Event) bool {
+			return event.Type == webhook.EventCredentialResetRequired
+		})).
+		Return(int64(3), nil)
+	auditLog.On("Record", mock.Anything).Return(nil)
+
+	result, err := service.UseAction(notification.ActionRequest{
+		Token:            token,
+		Revoke:           notification.RevokeSession,
+		ResetCredentials: true,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.RevokedSessions)
+	assert.Equal(t, int64(2), result.RevokedAPIKeys)
+}
+
+func TestUseAction_RevokeAllRevokesAPIKeys(t *testing.T) {
+	service, storage, emailService, sessionStorage, apiKeyStorage, auditLog := newServiceAndAllMocks(t, []byte("key"))
+	token := newActionToken(t, service, storage, emailService)
+	action := &notification.Action{ID: uuid.New(), UserID: userID, SessionID: sessionID}
+	storage.On("GetAction", mock.Anything, mock.Anything).Return(action, nil)
+	storage.On("UseAction", mock.Anything, mock.Anything).Return(action, nil)
+	apiKeyStorage.On("RevokeByUser", userID).Return(int64(2), nil).Once()
+	sessionStorage.
+		On("DeleteByUser", userID, mock.MatchedBy(func(event *webhook.Event) bool {
+			return event.Type == webhook.EventUserSessionsRevoked
+		})).
+		Return(int64(1), nil)
+	auditLog.
+		On("Record", mock.MatchedBy(func(event audit.Event) bool {
+			return event.Type == audit.EventSecurityActionUsed && event.Details["revokedApiKeys"] == "2"
+		})).
+		Return(nil)
+
+	result, err := service.UseAction(notification.ActionRequest{Token: token, Revoke: notification.RevokeAll})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.RevokedAPIKeys)
+	sessionStorage.AssertNotCalled(t, "DeleteFamily", mock.Anything, mock.Anything)
+}
+
+func TestUseAction_RevokeFailsKeepsAction(t *testing.T) {
+	service, storage, emailService, sessionStorage, apiKeyStorage, _ := newServiceAndAllMocks(t, []byte("key"))
+	token := newActionToken(t, service, storage, emailService)
+	action := &notification.Action{ID: uuid.New(), UserID: userID}
+	storage.On("GetAction", mock.Anything, mock.Anything).Return(action, nil)
+	apiKeyStorage.On("RevokeByUser", userID).Return(int64(0), errors.New("db is down"))
+
+	_, err := service.UseAction(notification.ActionRequest{Token: token, Revoke: notification.RevokeAll})
+
+	assert.Error(t, err)
+	sessionStorage.AssertNotCalled(t, "DeleteByUser", mock.Anything, mock.Anything)
+	storage.AssertNotCalled(t, "UseAction", mock.Anything, mock.Anything)
+}
+
+func TestUseAction_DigestRevokeSession(t *testing.T) {
+	service, storage, emailService := newServiceAndMocks(t)
+	token := newActionToken(t, service, storage, emailService)
+	storage.On("GetAction", mock.Anything, mock.Anything).Return(&notification.Action{UserID: userID}, nil)
+
+	_, err := service.UseAction(notification.ActionRequest{Token: token, Revoke: notification.RevokeSession})
+
+	assert.ErrorAs(t, err, &notification.ValidationError{})
+}
+
+func TestUseAction_Used(t *testing.T) {
+	service, storage, emailService := newServiceAndMocks(t)
+	token := newActionToken(t, service, storage, emailService)
+	storage.On("GetAction", mock.Anything, mock.Anything).Return(nil, notification.NewNotFoundError("action not found"))
+
+	_, err := service.UseAction(notification.ActionRequest{Token: token, Revoke: notification.RevokeAll})
+
+	assert.ErrorAs(t, err, &notification.NotFoundError{})
+}
+
+// newActionToken returns the token of the action link of a new IP alert.
+func newActionToken(t *testing.T, service *notification.NotificationService, storage *mocks.Storage, emailService *mocks.EmailService) string {
+	storage.On("AddKnownNetwork", userID, mock.Anything, mock.Anything).Return(true, nil).Once()
+	storage.On("GetPreferences", userID).Return(nil, notification.NewNotFoundError("preferences not found")).Once()
+	storage.On("CreateAction", mock.Anything).Return(nil).Once()
+	var actionURL string
+	emailService.
+		On("SendEmailToUser", emails.SupportEmail, userID, mock.Anything).
+		Run(func(args mock.Arguments) { actionURL = args.Get(2).(email.Email).Data["actionUrl"] }).
+		Return(nil).
+		Once()
+
+	_, err := service.NotifyNewIP(userID, sessionID, "", "203.0.113.7", "en")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(actionURL)
+	require.NoError(t, err)
+	return parsed.Query().Get("token")
+}
+
+func newServiceAndMocks(t *testing.T) (*notification.NotificationService, *mocks.Storage, *mocks.EmailService) {
+	return newServiceAndMocksWithKey(t, []byte("key"))
+}
+
+func newServiceAndMocksWithKey(t *testing.T, key []byte) (*notification.NotificationService, *mocks.Storage, *mocks.EmailService) {
+	service, storage, emailService, _, _, _ := newServiceAndAllMocks(t, key)
+	return service, storage, emailService
+}
+
+func newServiceAndAllMocks(t *testing.T, key []byte) (
+	*notification.NotificationService, *mocks.Storage, *mocks.EmailService, *mocks.SessionStorage, *mocks.APIKeyStorage, *mocks.AuditLog) {
+	storage := mocks.NewStorage(t)
+	emailService := mocks.NewEmailService(t)
+	sessionStorage := mocks.NewSessionStorage(t)
+	apiKeyStorage := mocks.NewAPIKeyStorage(t)
+	auditLog := mocks.NewAuditLog(t)
+	locator := mocks.NewLocator(t)
+	locator.On("Locate", "203.0.113.7").Return(&geoip.Location{Country: "DE", City: "Berlin"}, nil).Maybe()
+	service := notification.NewNotificationService(storage, emailService, sessionStorage, apiKeyStorage, auditLog, locator, key, cfg, emails)
+	return service, storage, emailService, sessionStorage, apiKeyStorage, auditLog
+}
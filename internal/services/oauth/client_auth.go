@@ -0,0 +1,161 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxClientAssertionLifetime bounds how long a used assertion ID has to be
+// remembered to prevent replays.
+const maxClientAssertionLifetime = 5 * time.Minute
+
+// ClientAssertionSigningAlgs are the accepted algorithms of private_key_jwt
+// assertions.
+var ClientAssertionSigningAlgs = []string{
+	jwt.SigningMethodRS256.Alg(), jwt.SigningMethodRS384.Alg(), jwt.SigningMethodRS512.Alg(),
+	jwt.SigningMethodPS256.Alg(), jwt.SigningMethodPS384.Alg(), jwt.SigningMethodPS512.Alg(),
+	jwt.SigningMethodES256.Alg(), jwt.SigningMethodES384.Alg(), jwt.SigningMethodES512.Alg(),
+	jwt.SigningMethodEdDSA.Alg(),
+}
+
+// authenticateClient identifies the client of a token or device
+// authorization request with the authentication method the client registered.
+func (s *OAuthService) authenticateClient(req ClientCredentials) (*Client, error) {
+	clientID := req.ClientID
+	if clientID == "" && req.ClientAssertion != "" {
+		// RFC 7523 allows omitting client_id, the assertion names the client
+		clientID = unverifiedAssertionSubject(req.ClientAssertion)
+	}
+	if clientID == "" {
+		return nil, Error{ErrInvalidClient, "client authentication required"}
+	}
+
+	client, err := s.clientStorage.Get(clientID)
+	if err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return nil, Error{ErrInvalidClient, "unknown client"}
+		}
+		return nil, errors.Wrap(err, "get client")
+	}
+
+	switch client.TokenEndpointAuthMethod {
+	case AuthMethodNone:
+		if req.ClientSecret != "" || req.ClientAssertion != "" {
+			return nil, Error{ErrInvalidClient, "client is not registered for authentication"}
+		}
+	case AuthMethodClientSecretBasic, AuthMethodClientSecretPost:
+		if req.ClientSecret == "" || bcrypt.CompareHashAndPassword(client.SecretHash, []byte(req.ClientSecret)) != nil {
+			return nil, Error{ErrInvalidClient, "invalid client secret"}
+		}
+	case AuthMethodPrivateKeyJWT:
+		if err := s.verifyClientAssertion(client, req); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("unknown token endpoint auth method %q", client.TokenEndpointAuthMethod)
+	}
+
+	return client, nil
+}
+
+// verifyClientAssertion checks a private_key_jwt assertion (RFC 7523 section
+// 3) and records its ID so that it cannot be used twice.
+func (s *OAuthService) verifyClientAssertion(client *Client, req ClientCredentials) error {
+	if req.ClientAssertionType != ClientAssertionTypeJWTBearer || req.ClientAssertion == "" {
+		return Error{ErrInvalidClient, "client assertion required"}
+	}
+
+	token, err := jwt.Parse(req.ClientAssertion, func(token *jwt.Token) (any, error) {
+		return clientKey(client, token)
+	},
+		jwt.WithValidMethods(ClientAssertionSigningAlgs),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuer(client.ID),
+		jwt.WithSubject(client.ID),
+	)
+	if err != nil {
+		return Error{ErrInvalidClient, "invalid client assertion"}
+	}
+	claims := token.Claims.(jwt.MapClaims)
+
+	audience, err := claims.GetAudience()
+	if err != nil || !s.isAssertionAudience(audience) {
+		return Error{ErrInvalidClient, "client assertion is not addressed to this server"}
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp.After(time.Now().Add(maxClientAssertionLifetime)) {
+		return Error{ErrInvalidClient, "client assertion lifetime is too long"}
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return Error{ErrInvalidClient, "client assertion jti is required"}
+	}
+
+	fresh, err := s.clientAssertionStorage.MarkUsed(client.ID, jti, exp.Time)
+	if err != nil {
+		return errors.Wrap(err, "mark client assertion used")
+	}
+	if !fresh {
+		return Error{ErrInvalidClient, "client assertion was already used"}
+	}
+
+	return nil
+}
+
+func (s *OAuthService) isAssertionAudience(audience []string) bool {
+	for _, aud := range audience {
+		if aud == s.issuer || aud == s.issuer+"/token" {
+			return true
+		}
+	}
+	return false
+}
+
+// clientKey selects the registered key by the kid header. The header may be
+// omitted when the client registered a single key.
+func clientKey(client *Client, token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	for _, jwk := range client.Keys {
+		if kid != "" && jwk.KeyID != kid {
+			continue
+		}
+		if kid == "" && len(client.Keys) > 1 {
+			break
+		}
+		if jwk.Algorithm != "" && jwk.Algorithm != token.Method.Alg() {
+			return nil, errors.Errorf("key %q does not allow %s", jwk.KeyID, token.Method.Alg())
+		}
+		return jwk.PublicKey()
+	}
+	return nil, errors.Errorf("unknown key id %q", kid)
+}
+
+func unverifiedAssertionSubject(assertion string) string {
+	token, _, err := jwt.NewParser().ParseUnverified(assertion, jwt.MapClaims{})
+	if err != nil {
+		return ""
+	}
+	subject, _ := token.Claims.GetSubject()
+	return subject
+}
+
+func generateClientSecret() (string, []byte, error) {
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", nil, errors.Wrap(err, "read random bytes")
+	}
+	secret := base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	secretHash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "hash client secret")
+	}
+
+	return secret, secretHash, nil
+}
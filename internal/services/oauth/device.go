@@ -0,0 +1,210 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// userCodeAlphabet has no vowels, so that user codes do not spell words,
+	// and no characters that are easily confused (RFC 8628 section 6.1).
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength   = 8
+
+	// slowDownIncrement is added to the poll interval of a device that polls
+	// too fast (RFC 8628 section 3.5).
+	slowDownIncrement = 5 * time.Second
+)
+
+// DeviceAuthorization starts the device flow of RFC 8628 for a client that
+// cannot redirect the user, such as a CLI.
+func (s *OAuthService) DeviceAuthorization(req DeviceAuthorizationRequest) (*DeviceAuthorizationResponse, error) {
+	client, err := s.authenticateClient(req.clientCredentials())
+	if err != nil {
+		return nil, err
+	}
+	if !client.HasGrantType(GrantTypeDeviceCode) {
+		return nil, Error{ErrUnauthorizedClient, "client is not allowed to use the device code grant"}
+	}
+	scopes, err := allowedScopes(client, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceCode, deviceCodeHash, err := generateCode()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate device code")
+	}
+	userCode, err := generateUserCode()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate user code")
+	}
+	err = s.deviceCodeStorage.Create(&DeviceCode{
+		DeviceCodeHash: deviceCodeHash,
+		UserCode:       userCode,
+		ClientID:       client.ID,
+		Scopes:         scopes,
+		Status:         DeviceCodeStatusPending,
+		PollInterval:   s.devicePollInterval,
+		ExpiresAt:      time.Now().Add(s.deviceCodeDuration),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create device code")
+	}
+
+	return &DeviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                formatUserCode(userCode),
+		VerificationURI:         s.deviceVerificationURI,
+		VerificationURIComplete: s.deviceVerificationURI + "?" + url.Values{"user_code": {formatUserCode(userCode)}}.Encode(),
+		ExpiresIn:               s.deviceCodeDuration,
+		PollInterval:            s.devicePollInterval,
+	}, nil
+}
+
+// GetDeviceConsent returns the pending device authorization of the user code,
+// so that the user can check which client asks for which scopes.
+func (s *OAuthService) GetDeviceConsent(userCode string) (*DeviceConsent, error) {
+	code, err := s.deviceCodeStorage.GetByUserCode(normalizeUserCode(userCode))
+	if err != nil {
+		return nil, errors.Wrap(err, "get device code")
+	}
+	if code.Status != DeviceCodeStatusPending || time.Now().After(code.ExpiresAt) {
+		return nil, NewNotFoundError("device code not found")
+	}
+
+	client, err := s.clientStorage.Get(code.ClientID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get client")
+	}
+
+	return &DeviceConsent{
+		UserCode:   formatUserCode(code.UserCode),
+		ClientID:   client.ID,
+		ClientName: client.Name,
+		Scopes:     code.Scopes,
+		ExpiresAt:  code.ExpiresAt,
+	}, nil
+}
+
+// DecideDevice records whether the user approved the device authorization.
+func (s *OAuthService) DecideDevice(decision DeviceDecision) error {
+	status := DeviceCodeStatusDenied
+	if decision.Approved {
+		status = DeviceCodeStatusApproved
+	}
+
+	err := s.deviceCodeStorage.Decide(&DeviceCode{
+		UserCode:    normalizeUserCode(decision.UserCode),
+		Status:      status,
+		UserID:      decision.UserID,
+		AuthTime:    decision.AuthTime,
+		AuthMethods: decision.AuthMethods,
+	})
+	if err != nil {
+		return errors.Wrap(err, "decide device code")
+	}
+
+	return nil
+}
+
+// exchangeDeviceCode answers the polling of the device (RFC 8628 section
+// 3.4). Tokens are issued once the user has approved the request.
+func (s *OAuthService) exchangeDeviceCode(req TokenRequest) (*TokenResponse, error) {
+	if req.DeviceCode == "" {
+		return nil, Error{ErrInvalidRequest, "device_code is required"}
+	}
+	client, err := s.authenticateClient(req.clientCredentials())
+	if err != nil {
+		return nil, err
+	}
+	if !client.HasGrantType(GrantTypeDeviceCode) {
+		return nil, Error{ErrUnauthorizedClient, "client is not allowed to use the device code grant"}
+	}
+
+	now := time.Now()
+	deviceCodeHash := hashCode(req.DeviceCode)
+	code, err := s.deviceCodeStorage.Poll(deviceCodeHash, now)
+	if err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return nil, Error{ErrInvalidGrant, "invalid device code"}
+		}
+		return nil, errors.Wrap(err, "poll device code")
+	}
+	if code.ClientID != client.ID {
+		return nil, Error{ErrInvalidGrant, "device code was issued to another client"}
+	}
+	if now.After(code.ExpiresAt) {
+		return nil, Error{ErrExpiredToken, ""}
+	}
+
+	switch code.Status {
+	case DeviceCodeStatusPending:
+		if !code.LastPolledAt.IsZero() && now.Sub(code.LastPolledAt) < code.PollInterval {
+			err := s.deviceCodeStorage.UpdatePollInterval(deviceCodeHash, code.PollInterval+slowDownIncrement)
+			if err != nil {
+				return nil, errors.Wrap(err, "update poll interval")
+			}
+			return nil, Error{ErrSlowDown, ""}
+		}
+		return nil, Error{ErrAuthorizationPending, ""}
+	case DeviceCodeStatusDenied:
+		return nil, Error{ErrAccessDenied, "the user denied the request"}
+	}
+
+	code, err = s.deviceCodeStorage.Consume(deviceCodeHash)
+	if err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			// a concurrent poll got the tokens
+			return nil, Error{ErrInvalidGrant, "invalid device code"}
+		}
+		return nil, errors.Wrap(err, "consume device code")
+	}
+
+	return s.issueUserTokens(userGrant{
+		ClientID:    code.ClientID,
+		UserID:      code.UserID,
+		Scopes:      code.Scopes,
+		AuthTime:    code.AuthTime,
+		AuthMethods: code.AuthMethods,
+	}, req.RequestIP)
+}
+
+func generateUserCode() (string, error) {
+	alphabetSize := big.NewInt(int64(len(userCodeAlphabet)))
+	var code strings.Builder
+	for i := 0; i < userCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", errors.Wrap(err, "read random number")
+		}
+		code.WriteByte(userCodeAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+// formatUserCode splits the code in two halves for readability.
+func formatUserCode(userCode string) string {
+	if len(userCode) != userCodeLength {
+		return userCode
+	}
+	return userCode[:userCodeLength/2] + "-" + userCode[userCodeLength/2:]
+}
+
+// normalizeUserCode makes the comparison of user codes ignore case, the
+// dash and spaces, as users type them in.
+func normalizeUserCode(userCode string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(userCode))
+}
@@ -0,0 +1,58 @@
+package oauth
+
+// Error codes of RFC 6749 sections 4.1.2.1 and 5.2.
+const (
+	ErrInvalidRequest          = "invalid_request"
+	ErrInvalidClient           = "invalid_client"
+	ErrInvalidGrant            = "invalid_grant"
+	ErrUnauthorizedClient      = "unauthorized_client"
+	ErrUnsupportedGrantType    = "unsupported_grant_type"
+	ErrUnsupportedResponseType = "unsupported_response_type"
+	ErrInvalidScope            = "invalid_scope"
+	ErrAccessDenied            = "access_denied"
+	ErrServerError             = "server_error"
+
+	// OpenID Connect Core section 3.1.2.6.
+	ErrLoginRequired = "login_required"
+
+	// RFC 8628 section 3.5.
+	ErrAuthorizationPending = "authorization_pending"
+	ErrSlowDown             = "slow_down"
+	ErrExpiredToken         = "expired_token"
+
+	// RFC 8693 section 2.2.2.
+	ErrInvalidTarget = "invalid_target"
+)
+
+// Error is an OAuth protocol error returned to the client.
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (err Error) Error() string {
+	if err.Description == "" {
+		return err.Code
+	}
+	return err.Code + ": " + err.Description
+}
+
+type NotFoundError struct {
+	message string
+}
+
+func (err NotFoundError) Error() string {
+	return err.message
+}
+
+func NewNotFoundError(message string) NotFoundError {
+	return NotFoundError{message: message}
+}
+
+type ValidationError struct {
+	message string
+}
+
+func (err ValidationError) Error() string {
+	return err.message
+}
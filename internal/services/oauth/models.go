@@ -0,0 +1,269 @@
+package oauth
+
+import (
+	"auth/pkg/authmw"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+	GrantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	// TokenTypeAccessToken is the only token type of RFC 8693 section 3 that
+	// token exchange accepts and issues.
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+
+	ResponseTypeCode = "code"
+
+	CodeChallengeMethodS256 = "S256"
+
+	// Token endpoint authentication methods of RFC 7591. Secrets are accepted
+	// both in the Authorization header and in the form, whichever of the two
+	// secret methods the client registered.
+	AuthMethodNone              = "none"
+	AuthMethodClientSecretBasic = "client_secret_basic"
+	AuthMethodClientSecretPost  = "client_secret_post"
+	AuthMethodPrivateKeyJWT     = "private_key_jwt"
+
+	ClientAssertionTypeJWTBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+	DeviceCodeStatusPending  = "pending"
+	DeviceCodeStatusApproved = "approved"
+	DeviceCodeStatusDenied   = "denied"
+)
+
+type Client struct {
+	ID           string
+	Name         string
+	RedirectURIs []string
+	// Scopes lists the scopes the client may request.
+	Scopes                  []string
+	GrantTypes              []string
+	TokenEndpointAuthMethod string
+	// SecretHash is the bcrypt hash of the secret of clients that
+	// authenticate with one.
+	SecretHash []byte
+	// Keys verify the client assertions of private_key_jwt clients.
+	Keys []authmw.JWK
+	// ExchangeAudiences are the services the client may get tokens for by
+	// token exchange.
+	ExchangeAudiences []string
+	CreatedAt         time.Time
+}
+
+// IsConfidential reports whether the client authenticates at the token
+// endpoint.
+func (c *Client) IsConfidential() bool {
+	return c.TokenEndpointAuthMethod != AuthMethodNone
+}
+
+func (c *Client) HasGrantType(grantType string) bool {
+	for _, g := range c.GrantTypes {
+		if g == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateClientRequest struct {
+	Name         string
+	RedirectURIs []string
+	Scopes       []string
+	// GrantTypes defaults to authorization_code.
+	GrantTypes []string
+	// TokenEndpointAuthMethod defaults to client_secret_basic for clients of
+	// the client credentials or token exchange grant and to none otherwise.
+	TokenEndpointAuthMethod string
+	Keys                    []authmw.JWK
+	ExchangeAudiences       []string
+}
+
+// CreateClientResponse carries the generated secret, which is not stored and
+// cannot be retrieved later.
+type CreateClientResponse struct {
+	Client       Client
+	ClientSecret string
+}
+
+type AuthorizationCode struct {
+	CodeHash            []byte
+	ClientID            string
+	UserID              uuid.UUID
+	RedirectURI         string
+	Scopes              []string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+	AuthTime            time.Time
+	AuthMethods         []string
+	ExpiresAt           time.Time
+}
+
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+	Prompt              string
+	MaxAge              string
+
+	// UserID is uuid.Nil when the user agent has no session. AuthTime and
+	// AuthMethods describe how the session was authenticated.
+	UserID      uuid.UUID
+	AuthTime    time.Time
+	AuthMethods []string
+}
+
+// AuthorizeResponse is where the user agent is sent back to. Code is empty
+// when the request failed after the redirect URI was validated.
+type AuthorizeResponse struct {
+	RedirectURI string
+	Code        string
+	State       string
+}
+
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	DeviceCode   string
+	Scope        string
+	RequestIP    string
+
+	// Token exchange parameters of RFC 8693 section 2.1.
+	SubjectToken       string
+	SubjectTokenType   string
+	RequestedTokenType string
+	Audience           []string
+	ActorToken         string
+
+	ClientID            string
+	ClientSecret        string
+	ClientAssertionType string
+	ClientAssertion     string
+}
+
+func (r TokenRequest) clientCredentials() ClientCredentials {
+	return ClientCredentials{
+		ClientID:            r.ClientID,
+		ClientSecret:        r.ClientSecret,
+		ClientAssertionType: r.ClientAssertionType,
+		ClientAssertion:     r.ClientAssertion,
+	}
+}
+
+// ClientCredentials authenticate the client at the token and device
+// authorization endpoints.
+type ClientCredentials struct {
+	ClientID            string
+	ClientSecret        string
+	ClientAssertionType string
+	ClientAssertion     string
+}
+
+// TokenResponse is the issued token. RefreshToken is empty for the client
+// credentials grant.
+type TokenResponse struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+	Scopes       []string
+	// IDToken is set when the openid scope was granted.
+	IDToken string
+	// IssuedTokenType is set by token exchange.
+	IssuedTokenType string
+}
+
+// UserInfo holds the claims of the userinfo endpoint. Profile and Email are
+// nil unless the matching scope was granted.
+type UserInfo struct {
+	Subject string
+	Profile *ProfileClaims
+	Email   *EmailClaims
+}
+
+type ProfileClaims struct {
+	Name       string
+	GivenName  string
+	FamilyName string
+	UpdatedAt  time.Time
+}
+
+type EmailClaims struct {
+	Email         string
+	EmailVerified bool
+}
+
+// DeviceCode is a device authorization request of RFC 8628. LastPolledAt is
+// zero until the device polls the token endpoint, UserID, AuthTime and
+// AuthMethods are set once the user has approved or denied the request.
+type DeviceCode struct {
+	DeviceCodeHash []byte
+	UserCode       string
+	ClientID       string
+	Scopes         []string
+	Status         string
+	UserID         uuid.UUID
+	AuthTime       time.Time
+	AuthMethods    []string
+	PollInterval   time.Duration
+	LastPolledAt   time.Time
+	ExpiresAt      time.Time
+}
+
+type DeviceAuthorizationRequest struct {
+	Scope string
+
+	ClientID            string
+	ClientSecret        string
+	ClientAssertionType string
+	ClientAssertion     string
+}
+
+func (r DeviceAuthorizationRequest) clientCredentials() ClientCredentials {
+	return ClientCredentials{
+		ClientID:            r.ClientID,
+		ClientSecret:        r.ClientSecret,
+		ClientAssertionType: r.ClientAssertionType,
+		ClientAssertion:     r.ClientAssertion,
+	}
+}
+
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string
+	UserCode                string
+	VerificationURI         string
+	VerificationURIComplete string
+	ExpiresIn               time.Duration
+	PollInterval            time.Duration
+}
+
+// DeviceConsent describes a pending device authorization to the user who
+// entered its user code.
+type DeviceConsent struct {
+	UserCode   string
+	ClientID   string
+	ClientName string
+	Scopes     []string
+	ExpiresAt  time.Time
+}
+
+// DeviceDecision is the answer of the authenticated user to a device
+// authorization.
+type DeviceDecision struct {
+	UserCode    string
+	Approved    bool
+	UserID      uuid.UUID
+	AuthTime    time.Time
+	AuthMethods []string
+}
@@ -0,0 +1,504 @@
+package oauth
+
+import (
+	"auth/internal/config"
+	"auth/internal/services/auth"
+	"auth/internal/services/user"
+	"auth/pkg/authmw"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type OAuthService struct {
+	clientStorage             ClientStorage
+	authorizationCodeStorage  AuthorizationCodeStorage
+	clientAssertionStorage    ClientAssertionStorage
+	deviceCodeStorage         DeviceCodeStorage
+	userStorage               UserStorage
+	tokenIssuer               TokenIssuer
+	signingKey                *SigningKey
+	authorizationCodeDuration time.Duration
+	idTokenDuration           time.Duration
+	deviceCodeDuration        time.Duration
+	devicePollInterval        time.Duration
+	deviceVerificationURI     string
+	issuer                    string
+}
+
+//go:generate mockery --name ClientStorage --filename client_storage.go
+type ClientStorage interface {
+	Create(client *Client) error
+	Get(id string) (*Client, error)
+	List() ([]Client, error)
+	Delete(id string) error
+}
+
+//go:generate mockery --name AuthorizationCodeStorage --filename authorization_code_storage.go
+type AuthorizationCodeStorage interface {
+	Create(code *AuthorizationCode) error
+	// Consume deletes the code and returns it, so that it can only be
+	// exchanged once.
+	Consume(codeHash []byte) (*AuthorizationCode, error)
+}
+
+//go:generate mockery --name ClientAssertionStorage --filename client_assertion_storage.go
+type ClientAssertionStorage interface {
+	// MarkUsed records the assertion ID until it expires. It returns false
+	// when the ID has already been recorded for the client.
+	MarkUsed(clientID, jti string, expiresAt time.Time) (bool, error)
+}
+
+//go:generate mockery --name DeviceCodeStorage --filename device_code_storage.go
+type DeviceCodeStorage interface {
+	Create(code *DeviceCode) error
+	GetByUserCode(userCode string) (*DeviceCode, error)
+	// Decide records the user's answer to a pending, unexpired device code.
+	Decide(code *DeviceCode) error
+	// Poll records the poll time and returns the code with the time of the
+	// previous poll.
+	Poll(deviceCodeHash []byte, polledAt time.Time) (*DeviceCode, error)
+	UpdatePollInterval(deviceCodeHash []byte, interval time.Duration) error
+	// Consume deletes an approved code and returns it, so that tokens are
+	// only issued once.
+	Consume(deviceCodeHash []byte) (*DeviceCode, error)
+}
+
+//go:generate mockery --name UserStorage --filename user_storage.go
+type UserStorage interface {
+	Get(id uuid.UUID) (*user.User, error)
+}
+
+//go:generate mockery --name TokenIssuer --filename token_issuer.go
+type TokenIssuer interface {
+	CreateAccessAndRefreshTokens(userID uuid.UUID, requestIP string, opts ...auth.TokenOption) (string, string, error)
+	CreateClientAccessToken(clientID string, scopes []string, opts ...auth.TokenOption) (string, error)
+	VerifyAccessToken(accessToken string) (*authmw.Claims, error)
+	CreateDelegatedAccessToken(delegation auth.Delegation) (string, time.Time, error)
+	AccessTokenDuration() time.Duration
+}
+
+func NewOAuthService(
+	clientStorage ClientStorage,
+	authorizationCodeStorage AuthorizationCodeStorage,
+	clientAssertionStorage ClientAssertionStorage,
+	deviceCodeStorage DeviceCodeStorage,
+	userStorage UserStorage,
+	tokenIssuer TokenIssuer,
+	signingKey *SigningKey,
+	cfg config.OAuth,
+) *OAuthService {
+	issuer := strings.TrimSuffix(cfg.Issuer, "/")
+	deviceVerificationURI := cfg.DeviceVerificationURI
+	if deviceVerificationURI == "" {
+		deviceVerificationURI = issuer + "/device"
+	}
+
+	return &OAuthService{
+		clientStorage:             clientStorage,
+		authorizationCodeStorage:  authorizationCodeStorage,
+		clientAssertionStorage:    clientAssertionStorage,
+		deviceCodeStorage:         deviceCodeStorage,
+		userStorage:               userStorage,
+		tokenIssuer:               tokenIssuer,
+		signingKey:                signingKey,
+		authorizationCodeDuration: cfg.AuthorizationCodeDuration,
+		idTokenDuration:           cfg.IDTokenDuration,
+		deviceCodeDuration:        cfg.DeviceCodeDuration,
+		devicePollInterval:        cfg.DevicePollInterval,
+		deviceVerificationURI:     deviceVerificationURI,
+		issuer:                    issuer,
+	}
+}
+
+func (s *OAuthService) Issuer() string {
+	return s.issuer
+}
+
+func (s *OAuthService) JWKS() authmw.JWKSet {
+	return s.signingKey.JWKS()
+}
+
+// Authorize handles an authorization request of the authenticated user. Errors
+// about the client or redirect URI are returned without a response, since the
+// user agent must not be redirected to an unverified URI.
+func (s *OAuthService) Authorize(req AuthorizeRequest) (*AuthorizeResponse, error) {
+	client, err := s.clientStorage.Get(req.ClientID)
+	if err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return nil, Error{ErrInvalidClient, "unknown client_id"}
+		}
+		return nil, errors.Wrap(err, "get client")
+	}
+	redirectURI, ok := matchRedirectURI(client, req.RedirectURI)
+	if !ok {
+		return nil, Error{ErrInvalidRequest, "redirect_uri is not registered for the client"}
+	}
+
+	resp := &AuthorizeResponse{RedirectURI: redirectURI, State: req.State}
+	if req.ResponseType != ResponseTypeCode {
+		return resp, Error{ErrUnsupportedResponseType, "only the code response type is supported"}
+	}
+	if !client.HasGrantType(GrantTypeAuthorizationCode) {
+		return resp, Error{ErrUnauthorizedClient, "client is not allowed to use the authorization code grant"}
+	}
+	if err := checkAuthentication(req, strings.Fields(req.Prompt)); err != nil {
+		return resp, err
+	}
+	if req.CodeChallengeMethod != CodeChallengeMethodS256 {
+		return resp, Error{ErrInvalidRequest, "code_challenge_method must be S256"}
+	}
+	if !codeChallengeRegexp.MatchString(req.CodeChallenge) {
+		return resp, Error{ErrInvalidRequest, "code_challenge is required"}
+	}
+	scopes, err := allowedScopes(client, req.Scope)
+	if err != nil {
+		return resp, err
+	}
+
+	code, codeHash, err := generateCode()
+	if err != nil {
+		return nil, errors.Wrap(err, "generate code")
+	}
+	// the redirect URI is stored as sent: the token request must repeat it
+	// exactly, including its absence (RFC 6749 section 4.1.3)
+	err = s.authorizationCodeStorage.Create(&AuthorizationCode{
+		CodeHash:            codeHash,
+		ClientID:            client.ID,
+		UserID:              req.UserID,
+		RedirectURI:         req.RedirectURI,
+		Scopes:              scopes,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
+		AuthTime:            req.AuthTime,
+		AuthMethods:         req.AuthMethods,
+		ExpiresAt:           time.Now().Add(s.authorizationCodeDuration),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create authorization code")
+	}
+
+	resp.Code = code
+	return resp, nil
+}
+
+// Token handles a token request.
+func (s *OAuthService) Token(req TokenRequest) (*TokenResponse, error) {
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		return s.exchangeAuthorizationCode(req)
+	case GrantTypeClientCredentials:
+		return s.issueClientCredentialsToken(req)
+	case GrantTypeDeviceCode:
+		return s.exchangeDeviceCode(req)
+	case GrantTypeTokenExchange:
+		return s.exchangeToken(req)
+	case "":
+		return nil, Error{ErrInvalidRequest, "grant_type is required"}
+	default:
+		return nil, Error{ErrUnsupportedGrantType, ""}
+	}
+}
+
+func (s *OAuthService) exchangeAuthorizationCode(req TokenRequest) (*TokenResponse, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, Error{ErrInvalidRequest, "code and code_verifier are required"}
+	}
+	client, err := s.authenticateClient(req.clientCredentials())
+	if err != nil {
+		return nil, err
+	}
+	if !client.HasGrantType(GrantTypeAuthorizationCode) {
+		return nil, Error{ErrUnauthorizedClient, "client is not allowed to use the authorization code grant"}
+	}
+
+	code, err := s.authorizationCodeStorage.Consume(hashCode(req.Code))
+	if err != nil {
+		var notFoundErr NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return nil, Error{ErrInvalidGrant, "invalid authorization code"}
+		}
+		return nil, errors.Wrap(err, "consume authorization code")
+	}
+	if time.Now().After(code.ExpiresAt) {
+		return nil, Error{ErrInvalidGrant, "authorization code expired"}
+	}
+	if code.ClientID != client.ID {
+		return nil, Error{ErrInvalidGrant, "authorization code was issued to another client"}
+	}
+	if code.RedirectURI != req.RedirectURI {
+		return nil, Error{ErrInvalidGrant, "redirect_uri does not match the authorization request"}
+	}
+	if !verifyCodeChallenge(code.CodeChallenge, req.CodeVerifier) {
+		return nil, Error{ErrInvalidGrant, "code_verifier does not match code_challenge"}
+	}
+
+	return s.issueUserTokens(userGrant{
+		ClientID:    code.ClientID,
+		UserID:      code.UserID,
+		Scopes:      code.Scopes,
+		Nonce:       code.Nonce,
+		AuthTime:    code.AuthTime,
+		AuthMethods: code.AuthMethods,
+	}, req.RequestIP)
+}
+
+// userGrant is what a user authorized a client to do, with an authorization
+// code or a device code.
+type userGrant struct {
+	ClientID    string
+	UserID      uuid.UUID
+	Scopes      []string
+	Nonce       string
+	AuthTime    time.Time
+	AuthMethods []string
+}
+
+func (s *OAuthService) issueUserTokens(grant userGrant, requestIP string) (*TokenResponse, error) {
+	accessToken, refreshToken, err := s.tokenIssuer.CreateAccessAndRefreshTokens(
+		grant.UserID,
+		requestIP,
+		auth.WithScopes(grant.Scopes),
+		auth.WithAudience(grant.ClientID),
+		auth.WithAuthTime(grant.AuthTime),
+		auth.WithAuthMethods(grant.AuthMethods...),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "create access and refresh tokens")
+	}
+
+	resp := &TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    s.tokenIssuer.AccessTokenDuration(),
+		Scopes:       grant.Scopes,
+	}
+	if contains(grant.Scopes, ScopeOpenID) {
+		resp.IDToken, err = s.newIDToken(grant, accessToken)
+		if err != nil {
+			return nil, errors.Wrap(err, "create id token")
+		}
+	}
+
+	return resp, nil
+}
+
+// issueClientCredentialsToken issues a token that represents the client
+// itself (RFC 6749 section 4.4). No refresh token is issued, the client can
+// always authenticate again.
+func (s *OAuthService) issueClientCredentialsToken(req TokenRequest) (*TokenResponse, error) {
+	client, err := s.authenticateClient(req.clientCredentials())
+	if err != nil {
+		return nil, err
+	}
+	if !client.IsConfidential() || !client.HasGrantType(GrantTypeClientCredentials) {
+		return nil, Error{ErrUnauthorizedClient, "client is not allowed to use the client credentials grant"}
+	}
+	scopes, err := allowedScopes(client, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.tokenIssuer.CreateClientAccessToken(client.ID, scopes)
+	if err != nil {
+		return nil, errors.Wrap(err, "create client access token")
+	}
+
+	return &TokenResponse{
+		AccessToken: accessToken,
+		ExpiresIn:   s.tokenIssuer.AccessTokenDuration(),
+		Scopes:      scopes,
+	}, nil
+}
+
+func (s *OAuthService) CreateClient(req CreateClientRequest) (*CreateClientResponse, error) {
+	client := Client{
+		ID:                      uuid.NewString(),
+		Name:                    req.Name,
+		RedirectURIs:            req.RedirectURIs,
+		Scopes:                  req.Scopes,
+		GrantTypes:              req.GrantTypes,
+		TokenEndpointAuthMethod: req.TokenEndpointAuthMethod,
+		Keys:                    req.Keys,
+		ExchangeAudiences:       req.ExchangeAudiences,
+	}
+	if client.RedirectURIs == nil {
+		client.RedirectURIs = []string{}
+	}
+	if client.Scopes == nil {
+		client.Scopes = []string{}
+	}
+	if client.ExchangeAudiences == nil {
+		client.ExchangeAudiences = []string{}
+	}
+	if len(client.GrantTypes) == 0 {
+		client.GrantTypes = []string{GrantTypeAuthorizationCode}
+	}
+	if client.TokenEndpointAuthMethod == "" {
+		client.TokenEndpointAuthMethod = AuthMethodNone
+		if client.HasGrantType(GrantTypeClientCredentials) || client.HasGrantType(GrantTypeTokenExchange) {
+			client.TokenEndpointAuthMethod = AuthMethodClientSecretBasic
+		}
+	}
+	if err := validateClient(&client); err != nil {
+		return nil, err
+	}
+
+	resp := &CreateClientResponse{}
+	switch client.TokenEndpointAuthMethod {
+	case AuthMethodClientSecretBasic, AuthMethodClientSecretPost:
+		secret, secretHash, err := generateClientSecret()
+		if err != nil {
+			return nil, errors.Wrap(err, "generate client secret")
+		}
+		client.SecretHash = secretHash
+		resp.ClientSecret = secret
+	}
+
+	if err := s.clientStorage.Create(&client); err != nil {
+		return nil, errors.Wrap(err, "create client")
+	}
+
+	resp.Client = client
+	return resp, nil
+}
+
+func (s *OAuthService) GetClient(id string) (*Client, error) {
+	client, err := s.clientStorage.Get(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get client")
+	}
+
+	return client, nil
+}
+
+func (s *OAuthService) ListClients() ([]Client, error) {
+	clients, err := s.clientStorage.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "list clients")
+	}
+
+	return clients, nil
+}
+
+func (s *OAuthService) DeleteClient(id string) error {
+	return errors.Wrap(s.clientStorage.Delete(id), "delete client")
+}
+
+func validateClient(client *Client) error {
+	if client.Name == "" {
+		return ValidationError{"name is required"}
+	}
+	for _, grantType := range client.GrantTypes {
+		switch grantType {
+		case GrantTypeAuthorizationCode, GrantTypeClientCredentials, GrantTypeDeviceCode, GrantTypeTokenExchange:
+		default:
+			return ValidationError{"unsupported grant type " + grantType}
+		}
+	}
+	if client.HasGrantType(GrantTypeAuthorizationCode) && len(client.RedirectURIs) == 0 {
+		return ValidationError{"at least one redirect URI is required"}
+	}
+	for _, redirectURI := range client.RedirectURIs {
+		if err := validateRedirectURI(redirectURI); err != nil {
+			return err
+		}
+	}
+
+	switch client.TokenEndpointAuthMethod {
+	case AuthMethodNone:
+		if client.HasGrantType(GrantTypeClientCredentials) {
+			return ValidationError{"the client credentials grant requires client authentication"}
+		}
+		if client.HasGrantType(GrantTypeTokenExchange) {
+			return ValidationError{"token exchange requires client authentication"}
+		}
+	case AuthMethodClientSecretBasic, AuthMethodClientSecretPost:
+	case AuthMethodPrivateKeyJWT:
+		if len(client.Keys) == 0 {
+			return ValidationError{"private_key_jwt requires at least one key"}
+		}
+	default:
+		return ValidationError{"unsupported token endpoint auth method " + client.TokenEndpointAuthMethod}
+	}
+	if client.HasGrantType(GrantTypeTokenExchange) != (len(client.ExchangeAudiences) > 0) {
+		return ValidationError{"exchange audiences are required for and only used by token exchange"}
+	}
+	if client.TokenEndpointAuthMethod != AuthMethodPrivateKeyJWT && len(client.Keys) > 0 {
+		return ValidationError{"keys are only used by private_key_jwt clients"}
+	}
+	keyIDs := make(map[string]bool, len(client.Keys))
+	for _, jwk := range client.Keys {
+		if _, err := jwk.PublicKey(); err != nil {
+			return ValidationError{"invalid key " + jwk.KeyID + ": " + err.Error()}
+		}
+		if len(client.Keys) > 1 && (jwk.KeyID == "" || keyIDs[jwk.KeyID]) {
+			return ValidationError{"keys must have distinct key IDs"}
+		}
+		keyIDs[jwk.KeyID] = true
+	}
+
+	return nil
+}
+
+// matchRedirectURI compares URIs exactly, as required by RFC 9700. The URI
+// may be omitted when the client has registered only one.
+func matchRedirectURI(client *Client, redirectURI string) (string, bool) {
+	if redirectURI == "" {
+		if len(client.RedirectURIs) == 1 {
+			return client.RedirectURIs[0], true
+		}
+		return "", false
+	}
+	for _, registered := range client.RedirectURIs {
+		if registered == redirectURI {
+			return redirectURI, true
+		}
+	}
+	return "", false
+}
+
+// allowedScopes parses the requested scopes. Omitting the scope grants
+// everything the client is registered for.
+func allowedScopes(client *Client, scope string) ([]string, error) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return client.Scopes, nil
+	}
+
+	allowed := make(map[string]bool, len(client.Scopes))
+	for _, s := range client.Scopes {
+		allowed[s] = true
+	}
+	for _, s := range requested {
+		if !allowed[s] {
+			return nil, Error{ErrInvalidScope, "scope " + s + " is not allowed for the client"}
+		}
+	}
+	return requested, nil
+}
+
+func generateCode() (string, []byte, error) {
+	codeBytes := make([]byte, 32)
+	if _, err := rand.Read(codeBytes); err != nil {
+		return "", nil, errors.Wrap(err, "read random bytes")
+	}
+	code := base64.RawURLEncoding.EncodeToString(codeBytes)
+
+	return code, hashCode(code), nil
+}
+
+// hashCode hashes authorization codes for storage. Codes have 256 bits of
+// entropy and live for a minute, so a fast hash that allows lookups is
+// enough, unlike refresh tokens.
+func hashCode(code string) []byte {
+	hash := sha256.Sum256([]byte(code))
+	return hash[:]
+}
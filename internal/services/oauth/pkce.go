@@ -0,0 +1,24 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// codeVerifierRegexp follows RFC 7636 section 4.1.
+var codeVerifierRegexp = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// codeChallengeRegexp matches a base64url encoded SHA-256 hash.
+var codeChallengeRegexp = regexp.MustCompile(`^[A-Za-z0-9\-_]{43}$`)
+
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if !codeVerifierRegexp.MatchString(verifier) {
+		return false
+	}
+	hash := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(hash[:])
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
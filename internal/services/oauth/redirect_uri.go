@@ -0,0 +1,26 @@
+package oauth
+
+import (
+	"net/url"
+)
+
+// validateRedirectURI accepts absolute URIs without fragments. Plain http is
+// allowed only for loopback addresses used by native apps (RFC 8252); custom
+// schemes are allowed for mobile apps.
+func validateRedirectURI(redirectURI string) error {
+	u, err := url.Parse(redirectURI)
+	if err != nil || !u.IsAbs() {
+		return ValidationError{"redirect URI " + redirectURI + " is not an absolute URI"}
+	}
+	if u.Fragment != "" {
+		return ValidationError{"redirect URI " + redirectURI + " must not contain a fragment"}
+	}
+	if u.Scheme == "http" {
+		switch u.Hostname() {
+		case "localhost", "127.0.0.1", "::1":
+		default:
+			return ValidationError{"redirect URI " + redirectURI + " must use https"}
+		}
+	}
+	return nil
+}
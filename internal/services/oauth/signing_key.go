@@ -0,0 +1,88 @@
+package oauth
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	"auth/pkg/authmw"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// SigningKey signs ID tokens with RS256. Relying parties verify them with the
+// public key published in the JWK set.
+type SigningKey struct {
+	privateKey *rsa.PrivateKey
+	jwk        authmw.JWK
+}
+
+// NewSigningKey parses a PEM encoded RSA private key in PKCS #1 or PKCS #8
+// form.
+func NewSigningKey(pemKey []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var privateKey *rsa.PrivateKey
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse PKCS #1 key")
+		}
+		privateKey = key
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse PKCS #8 key")
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported key type %T", key)
+		}
+		privateKey = rsaKey
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	return NewSigningKeyFromRSA(privateKey)
+}
+
+// NewSigningKeyFromRSA wraps a parsed or generated key.
+func NewSigningKeyFromRSA(privateKey *rsa.PrivateKey) (*SigningKey, error) {
+	jwk, err := authmw.NewJWK(&privateKey.PublicKey, "", jwt.SigningMethodRS256.Alg())
+	if err != nil {
+		return nil, errors.Wrap(err, "create jwk")
+	}
+	// the RFC 7638 thumbprint changes with the key, so relying parties that
+	// cache the JWK set refetch it after a rotation
+	thumbprint := sha256.Sum256([]byte(fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)))
+	jwk.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+
+	return &SigningKey{
+		privateKey: privateKey,
+		jwk:        jwk,
+	}, nil
+}
+
+func (k *SigningKey) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = k.jwk.KeyID
+
+	signed, err := token.SignedString(k.privateKey)
+	if err != nil {
+		return "", errors.Wrap(err, "sign token")
+	}
+
+	return signed, nil
+}
+
+func (k *SigningKey) JWKS() authmw.JWKSet {
+	return authmw.JWKSet{Keys: []authmw.JWK{k.jwk}}
+}
@@ -0,0 +1,141 @@
+package oauth
+
+import (
+	"testing"
+	"time"
+
+	"auth/internal/services/oauth"
+	"auth/pkg/authmw/authmwtest"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const clientSecret = "secret"
+
+var serviceClient = oauth.Client{
+	ID:                      "batch-job",
+	Name:                    "Batch job",
+	Scopes:                  []string{"invoices:read", "invoices:write"},
+	GrantTypes:              []string{oauth.GrantTypeClientCredentials},
+	TokenEndpointAuthMethod: oauth.AuthMethodClientSecretBasic,
+}
+
+func TestClientCredentials_Secret(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	secretClient := serviceClient
+	secretClient.SecretHash, _ = bcrypt.GenerateFromPassword([]byte(clientSecret), bcrypt.MinCost)
+	m.clientStorage.On("Get", secretClient.ID).Return(&secretClient, nil)
+	m.tokenIssuer.On("CreateClientAccessToken", secretClient.ID, []string{"invoices:read"}).Return("access", nil)
+	m.tokenIssuer.On("AccessTokenDuration").Return(accessTokenDuration)
+
+	resp, err := service.Token(oauth.TokenRequest{
+		GrantType:    oauth.GrantTypeClientCredentials,
+		Scope:        "invoices:read",
+		ClientID:     secretClient.ID,
+		ClientSecret: clientSecret,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "access", resp.AccessToken)
+	assert.Empty(t, resp.RefreshToken)
+	assert.Equal(t, []string{"invoices:read"}, resp.Scopes)
+}
+
+func TestClientCredentials_WrongSecret(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	secretClient := serviceClient
+	secretClient.SecretHash, _ = bcrypt.GenerateFromPassword([]byte(clientSecret), bcrypt.MinCost)
+	m.clientStorage.On("Get", secretClient.ID).Return(&secretClient, nil)
+
+	_, err := service.Token(oauth.TokenRequest{
+		GrantType:    oauth.GrantTypeClientCredentials,
+		ClientID:     secretClient.ID,
+		ClientSecret: "wrong",
+	})
+
+	assert.Equal(t, oauth.ErrInvalidClient, err.(oauth.Error).Code)
+}
+
+func TestClientCredentials_PublicClient(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	m.clientStorage.On("Get", client.ID).Return(&client, nil)
+
+	_, err := service.Token(oauth.TokenRequest{
+		GrantType: oauth.GrantTypeClientCredentials,
+		ClientID:  client.ID,
+	})
+
+	assert.Equal(t, oauth.ErrUnauthorizedClient, err.(oauth.Error).Code)
+}
+
+func TestClientCredentials_PrivateKeyJWT(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	minter := authmwtest.NewRSAMinter(t)
+	keyClient := serviceClient
+	keyClient.TokenEndpointAuthMethod = oauth.AuthMethodPrivateKeyJWT
+	keyClient.Keys = append(keyClient.Keys, minter.JWK(t))
+	m.clientStorage.On("Get", keyClient.ID).Return(&keyClient, nil)
+	m.assertionStorage.On("MarkUsed", keyClient.ID, mock.Anything, mock.Anything).Return(true, nil).Once()
+	m.assertionStorage.On("MarkUsed", keyClient.ID, mock.Anything, mock.Anything).Return(false, nil).Once()
+	m.tokenIssuer.On("CreateClientAccessToken", keyClient.ID, keyClient.Scopes).Return("access", nil)
+	m.tokenIssuer.On("AccessTokenDuration").Return(accessTokenDuration)
+
+	req := oauth.TokenRequest{
+		GrantType:           oauth.GrantTypeClientCredentials,
+		ClientAssertionType: oauth.ClientAssertionTypeJWTBearer,
+		ClientAssertion: minter.Mint(t, authmwtest.Token{
+			Subject:  keyClient.ID,
+			Audience: []string{issuer + "/token"},
+			TTL:      time.Minute,
+			Extra:    map[string]any{"iss": keyClient.ID, "jti": uuid.NewString()},
+		}),
+	}
+	resp, err := service.Token(req)
+	require.NoError(t, err)
+	assert.Equal(t, "access", resp.AccessToken)
+
+	_, err = service.Token(req)
+	assert.Equal(t, oauth.Error{Code: oauth.ErrInvalidClient, Description: "client assertion was already used"}, err)
+}
+
+func TestClientCredentials_AssertionForAnotherServer(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	minter := authmwtest.NewRSAMinter(t)
+	keyClient := serviceClient
+	keyClient.TokenEndpointAuthMethod = oauth.AuthMethodPrivateKeyJWT
+	keyClient.Keys = append(keyClient.Keys, minter.JWK(t))
+	m.clientStorage.On("Get", keyClient.ID).Return(&keyClient, nil)
+
+	_, err := service.Token(oauth.TokenRequest{
+		GrantType:           oauth.GrantTypeClientCredentials,
+		ClientID:            keyClient.ID,
+		ClientAssertionType: oauth.ClientAssertionTypeJWTBearer,
+		ClientAssertion: minter.Mint(t, authmwtest.Token{
+			Subject:  keyClient.ID,
+			Audience: []string{"https://other.example.com/token"},
+			TTL:      time.Minute,
+			Extra:    map[string]any{"iss": keyClient.ID, "jti": uuid.NewString()},
+		}),
+	})
+
+	assert.Equal(t, oauth.ErrInvalidClient, err.(oauth.Error).Code)
+}
+
+func TestCreateClient_ReturnsSecretOnce(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	m.clientStorage.On("Create", mock.AnythingOfType("*oauth.Client")).Return(nil)
+
+	resp, err := service.CreateClient(oauth.CreateClientRequest{
+		Name:       "Batch job",
+		GrantTypes: []string{oauth.GrantTypeClientCredentials},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, oauth.AuthMethodClientSecretBasic, resp.Client.TokenEndpointAuthMethod)
+	assert.NotEmpty(t, resp.ClientSecret)
+	assert.NoError(t, bcrypt.CompareHashAndPassword(resp.Client.SecretHash, []byte(resp.ClientSecret)))
+}
@@ -0,0 +1,134 @@
+package oauth
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"auth/internal/services/oauth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var deviceClient = oauth.Client{
+	ID:                      "cli",
+	Name:                    "CLI",
+	Scopes:                  []string{"openid", "invoices:read"},
+	GrantTypes:              []string{oauth.GrantTypeDeviceCode},
+	TokenEndpointAuthMethod: oauth.AuthMethodNone,
+}
+
+func TestDeviceFlow(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	m.clientStorage.On("Get", deviceClient.ID).Return(&deviceClient, nil)
+	var stored *oauth.DeviceCode
+	m.deviceStorage.
+		On("Create", mock.AnythingOfType("*oauth.DeviceCode")).
+		Run(func(args mock.Arguments) { stored = args.Get(0).(*oauth.DeviceCode) }).
+		Return(nil)
+
+	resp, err := service.DeviceAuthorization(oauth.DeviceAuthorizationRequest{ClientID: deviceClient.ID, Scope: "invoices:read"})
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[B-Z]{4}-[B-Z]{4}$`), resp.UserCode)
+	assert.Equal(t, issuer+"/device", resp.VerificationURI)
+	assert.Equal(t, oauth.DeviceCodeStatusPending, stored.Status)
+	assert.Equal(t, []string{"invoices:read"}, stored.Scopes)
+
+	pollReq := oauth.TokenRequest{GrantType: oauth.GrantTypeDeviceCode, DeviceCode: resp.DeviceCode, ClientID: deviceClient.ID, RequestIP: ip}
+	pending := *stored
+	m.deviceStorage.On("Poll", stored.DeviceCodeHash, mock.Anything).Return(&pending, nil).Once()
+	_, err = service.Token(pollReq)
+	assert.Equal(t, oauth.Error{Code: oauth.ErrAuthorizationPending}, err)
+
+	m.deviceStorage.
+		On("Decide", mock.MatchedBy(func(code *oauth.DeviceCode) bool {
+			return code.UserCode == stored.UserCode && code.Status == oauth.DeviceCodeStatusApproved && code.UserID == userID
+		})).
+		Return(nil)
+	err = service.DecideDevice(oauth.DeviceDecision{UserCode: " " + resp.UserCode[:4] + "-" + resp.UserCode[5:], Approved: true, UserID: userID})
+	require.NoError(t, err)
+
+	approved := *stored
+	approved.Status = oauth.DeviceCodeStatusApproved
+	approved.UserID = userID
+	approved.LastPolledAt = time.Now().Add(-10 * time.Second)
+	m.deviceStorage.On("Poll", stored.DeviceCodeHash, mock.Anything).Return(&approved, nil).Once()
+	m.deviceStorage.On("Consume", stored.DeviceCodeHash).Return(&approved, nil)
+	m.tokenIssuer.
+		On("CreateAccessAndRefreshTokens", userID, ip, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return("access", "refresh", nil)
+	m.tokenIssuer.On("AccessTokenDuration").Return(accessTokenDuration)
+
+	tokenResp, err := service.Token(pollReq)
+	require.NoError(t, err)
+	assert.Equal(t, "access", tokenResp.AccessToken)
+	assert.Empty(t, tokenResp.IDToken)
+}
+
+func TestDeviceToken_SlowDown(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	m.clientStorage.On("Get", deviceClient.ID).Return(&deviceClient, nil)
+	code := newStoredDeviceCode()
+	code.LastPolledAt = time.Now().Add(-time.Second)
+	m.deviceStorage.On("Poll", mock.Anything, mock.Anything).Return(code, nil)
+	m.deviceStorage.On("UpdatePollInterval", mock.Anything, 10*time.Second).Return(nil)
+
+	_, err := service.Token(newDeviceTokenRequest())
+
+	assert.Equal(t, oauth.Error{Code: oauth.ErrSlowDown}, err)
+}
+
+func TestDeviceToken_Denied(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	m.clientStorage.On("Get", deviceClient.ID).Return(&deviceClient, nil)
+	code := newStoredDeviceCode()
+	code.Status = oauth.DeviceCodeStatusDenied
+	m.deviceStorage.On("Poll", mock.Anything, mock.Anything).Return(code, nil)
+
+	_, err := service.Token(newDeviceTokenRequest())
+
+	assert.Equal(t, oauth.ErrAccessDenied, err.(oauth.Error).Code)
+}
+
+func TestDeviceToken_Expired(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	m.clientStorage.On("Get", deviceClient.ID).Return(&deviceClient, nil)
+	code := newStoredDeviceCode()
+	code.ExpiresAt = time.Now().Add(-time.Second)
+	m.deviceStorage.On("Poll", mock.Anything, mock.Anything).Return(code, nil)
+
+	_, err := service.Token(newDeviceTokenRequest())
+
+	assert.Equal(t, oauth.Error{Code: oauth.ErrExpiredToken}, err)
+}
+
+func TestDeviceAuthorization_GrantTypeNotAllowed(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	m.clientStorage.On("Get", client.ID).Return(&client, nil)
+
+	_, err := service.DeviceAuthorization(oauth.DeviceAuthorizationRequest{ClientID: client.ID})
+
+	assert.Equal(t, oauth.ErrUnauthorizedClient, err.(oauth.Error).Code)
+}
+
+func newDeviceTokenRequest() oauth.TokenRequest {
+	return oauth.TokenRequest{
+		GrantType:  oauth.GrantTypeDeviceCode,
+		DeviceCode: "device-code",
+		ClientID:   deviceClient.ID,
+		RequestIP:  ip,
+	}
+}
+
+func newStoredDeviceCode() *oauth.DeviceCode {
+	return &oauth.DeviceCode{
+		UserCode:     "BCDFGHJK",
+		ClientID:     deviceClient.ID,
+		Scopes:       []string{"invoices:read"},
+		Status:       oauth.DeviceCodeStatusPending,
+		PollInterval: 5 * time.Second,
+		ExpiresAt:    time.Now().Add(10 * time.Minute),
+	}
+}
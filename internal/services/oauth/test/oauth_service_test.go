@@ -0,0 +1,243 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/services/oauth"
+	"auth/internal/services/oauth/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	userID, _           = uuid.Parse("8798e65e-dc84-4a7d-879e-2a52e67d86da")
+	redirectURI         = "https://app.example.com/callback"
+	codeVerifier        = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	codeChallenge       = newCodeChallenge(codeVerifier)
+	ip                  = "127.0.0.1"
+	accessTokenDuration = time.Hour
+	signingKey          = mustGenerateSigningKey()
+	issuer              = "https://auth.example.com"
+	client              = oauth.Client{
+		ID:                      "client-1",
+		Name:                    "App",
+		RedirectURIs:            []string{redirectURI},
+		Scopes:                  []string{"openid", "profile", "email", "invoices:read", "invoices:write"},
+		GrantTypes:              []string{oauth.GrantTypeAuthorizationCode},
+		TokenEndpointAuthMethod: oauth.AuthMethodNone,
+	}
+)
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	service, clientStorage, codeStorage, tokenIssuer := newServiceAndMocks(t)
+	clientStorage.On("Get", client.ID).Return(&client, nil)
+	var storedCode *oauth.AuthorizationCode
+	codeStorage.
+		On("Create", mock.AnythingOfType("*oauth.AuthorizationCode")).
+		Run(func(args mock.Arguments) { storedCode = args.Get(0).(*oauth.AuthorizationCode) }).
+		Return(nil)
+
+	resp, err := service.Authorize(newAuthorizeRequest())
+	require.NoError(t, err)
+	assert.Equal(t, redirectURI, resp.RedirectURI)
+	assert.Equal(t, "state-1", resp.State)
+	assert.NotEmpty(t, resp.Code)
+	assert.Equal(t, []string{"invoices:read"}, storedCode.Scopes)
+	assert.NotEqual(t, []byte(resp.Code), storedCode.CodeHash)
+
+	codeStorage.On("Consume", storedCode.CodeHash).Return(storedCode, nil)
+	tokenIssuer.
+		On("CreateAccessAndRefreshTokens", userID, ip, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return("access", "refresh", nil)
+	tokenIssuer.On("AccessTokenDuration").Return(accessTokenDuration)
+
+	tokenResp, err := service.Token(newTokenRequest(resp.Code))
+	require.NoError(t, err)
+	assert.Equal(t, "access", tokenResp.AccessToken)
+	assert.Equal(t, "refresh", tokenResp.RefreshToken)
+	assert.Equal(t, accessTokenDuration, tokenResp.ExpiresIn)
+}
+
+func TestAuthorize_UnknownRedirectURI(t *testing.T) {
+	service, clientStorage, _, _ := newServiceAndMocks(t)
+	clientStorage.On("Get", client.ID).Return(&client, nil)
+
+	req := newAuthorizeRequest()
+	req.RedirectURI = "https://evil.example.com/callback"
+	resp, err := service.Authorize(req)
+
+	assert.Nil(t, resp, "must not redirect to an unregistered uri")
+	assert.Equal(t, oauth.Error{Code: oauth.ErrInvalidRequest, Description: "redirect_uri is not registered for the client"}, err)
+}
+
+func TestAuthorize_PKCERequired(t *testing.T) {
+	service, clientStorage, _, _ := newServiceAndMocks(t)
+	clientStorage.On("Get", client.ID).Return(&client, nil)
+
+	req := newAuthorizeRequest()
+	req.CodeChallengeMethod = "plain"
+	req.CodeChallenge = codeVerifier
+	resp, err := service.Authorize(req)
+
+	assert.Equal(t, redirectURI, resp.RedirectURI)
+	assert.ErrorAs(t, err, &oauth.Error{})
+	assert.Empty(t, resp.Code)
+}
+
+func TestAuthorize_ScopeNotAllowed(t *testing.T) {
+	service, clientStorage, _, _ := newServiceAndMocks(t)
+	clientStorage.On("Get", client.ID).Return(&client, nil)
+
+	req := newAuthorizeRequest()
+	req.Scope = "auth:admin"
+	_, err := service.Authorize(req)
+
+	assert.Equal(t, oauth.ErrInvalidScope, err.(oauth.Error).Code)
+}
+
+func TestToken_WrongCodeVerifier(t *testing.T) {
+	service, clientStorage, codeStorage, _ := newServiceAndMocks(t)
+	clientStorage.On("Get", client.ID).Return(&client, nil)
+	code := newStoredCode()
+	codeStorage.On("Consume", mock.Anything).Return(code, nil)
+
+	req := newTokenRequest("code")
+	req.CodeVerifier = "wrong-verifier-wrong-verifier-wrong-verifier"
+	_, err := service.Token(req)
+
+	assert.Equal(t, oauth.ErrInvalidGrant, err.(oauth.Error).Code)
+}
+
+func TestToken_ExpiredCode(t *testing.T) {
+	service, clientStorage, codeStorage, _ := newServiceAndMocks(t)
+	clientStorage.On("Get", client.ID).Return(&client, nil)
+	code := newStoredCode()
+	code.ExpiresAt = time.Now().Add(-time.Second)
+	codeStorage.On("Consume", mock.Anything).Return(code, nil)
+
+	_, err := service.Token(newTokenRequest("code"))
+
+	assert.Equal(t, oauth.ErrInvalidGrant, err.(oauth.Error).Code)
+}
+
+func TestToken_UsedCode(t *testing.T) {
+	service, clientStorage, codeStorage, _ := newServiceAndMocks(t)
+	clientStorage.On("Get", client.ID).Return(&client, nil)
+	codeStorage.On("Consume", mock.Anything).Return(nil, oauth.NewNotFoundError("authorization code not found"))
+
+	_, err := service.Token(newTokenRequest("code"))
+
+	assert.Equal(t, oauth.ErrInvalidGrant, err.(oauth.Error).Code)
+}
+
+func TestToken_RedirectURIMismatch(t *testing.T) {
+	service, clientStorage, codeStorage, _ := newServiceAndMocks(t)
+	clientStorage.On("Get", client.ID).Return(&client, nil)
+	codeStorage.On("Consume", mock.Anything).Return(newStoredCode(), nil)
+
+	req := newTokenRequest("code")
+	req.RedirectURI = ""
+	_, err := service.Token(req)
+
+	assert.Equal(t, oauth.ErrInvalidGrant, err.(oauth.Error).Code)
+}
+
+type serviceMocks struct {
+	clientStorage    *mocks.ClientStorage
+	codeStorage      *mocks.AuthorizationCodeStorage
+	assertionStorage *mocks.ClientAssertionStorage
+	deviceStorage    *mocks.DeviceCodeStorage
+	userStorage      *mocks.UserStorage
+	tokenIssuer      *mocks.TokenIssuer
+}
+
+func newServiceAndMocks(t *testing.T) (*oauth.OAuthService, *mocks.ClientStorage, *mocks.AuthorizationCodeStorage, *mocks.TokenIssuer) {
+	service, m := newServiceAndAllMocks(t)
+	return service, m.clientStorage, m.codeStorage, m.tokenIssuer
+}
+
+func newServiceAndAllMocks(t *testing.T) (*oauth.OAuthService, serviceMocks) {
+	m := serviceMocks{
+		clientStorage:    mocks.NewClientStorage(t),
+		codeStorage:      mocks.NewAuthorizationCodeStorage(t),
+		assertionStorage: mocks.NewClientAssertionStorage(t),
+		deviceStorage:    mocks.NewDeviceCodeStorage(t),
+		userStorage:      mocks.NewUserStorage(t),
+		tokenIssuer:      mocks.NewTokenIssuer(t),
+	}
+	service := oauth.NewOAuthService(
+		m.clientStorage, m.codeStorage, m.assertionStorage, m.deviceStorage, m.userStorage, m.tokenIssuer, signingKey,
+		config.OAuth{
+			AuthorizationCodeDuration: time.Minute,
+			IDTokenDuration:           time.Hour,
+			DeviceCodeDuration:        10 * time.Minute,
+			DevicePollInterval:        5 * time.Second,
+			Issuer:                    issuer,
+		},
+	)
+
+	return service, m
+}
+
+func newAuthorizeRequest() oauth.AuthorizeRequest {
+	return oauth.AuthorizeRequest{
+		ResponseType:        oauth.ResponseTypeCode,
+		ClientID:            client.ID,
+		RedirectURI:         redirectURI,
+		Scope:               "invoices:read",
+		State:               "state-1",
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: oauth.CodeChallengeMethodS256,
+		UserID:              userID,
+		AuthTime:            time.Now(),
+	}
+}
+
+func newTokenRequest(code string) oauth.TokenRequest {
+	return oauth.TokenRequest{
+		GrantType:    oauth.GrantTypeAuthorizationCode,
+		Code:         code,
+		RedirectURI:  redirectURI,
+		ClientID:     client.ID,
+		CodeVerifier: codeVerifier,
+		RequestIP:    ip,
+	}
+}
+
+func newStoredCode() *oauth.AuthorizationCode {
+	return &oauth.AuthorizationCode{
+		ClientID:            client.ID,
+		UserID:              userID,
+		RedirectURI:         redirectURI,
+		Scopes:              []string{"invoices:read"},
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: oauth.CodeChallengeMethodS256,
+		ExpiresAt:           time.Now().Add(time.Minute),
+	}
+}
+
+func mustGenerateSigningKey() *oauth.SigningKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	key, err := oauth.NewSigningKeyFromRSA(privateKey)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func newCodeChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
@@ -0,0 +1,139 @@
+package oauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"auth/internal/services/oauth"
+	"auth/internal/services/user"
+	"auth/pkg/authmw"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testUser = user.User{
+	ID:            userID,
+	Email:         "jane@example.com",
+	EmailVerified: true,
+	Name:          "Jane Doe",
+	GivenName:     "Jane",
+	FamilyName:    "Doe",
+	UpdatedAt:     time.Unix(1700000000, 0),
+}
+
+func TestAuthorizationCodeFlow_IDToken(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	m.clientStorage.On("Get", client.ID).Return(&client, nil)
+	var storedCode *oauth.AuthorizationCode
+	m.codeStorage.
+		On("Create", mock.AnythingOfType("*oauth.AuthorizationCode")).
+		Run(func(args mock.Arguments) { storedCode = args.Get(0).(*oauth.AuthorizationCode) }).
+		Return(nil)
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	req := newAuthorizeRequest()
+	req.Scope = "openid invoices:read"
+	req.Nonce = "nonce-1"
+	req.AuthTime = authTime
+	req.AuthMethods = []string{"otp"}
+	resp, err := service.Authorize(req)
+	require.NoError(t, err)
+
+	m.codeStorage.On("Consume", storedCode.CodeHash).Return(storedCode, nil)
+	m.tokenIssuer.
+		On("CreateAccessAndRefreshTokens", userID, ip, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return("access", "refresh", nil)
+	m.tokenIssuer.On("AccessTokenDuration").Return(accessTokenDuration)
+
+	tokenResp, err := service.Token(newTokenRequest(resp.Code))
+	require.NoError(t, err)
+
+	claims := mustVerifyIDToken(t, service, tokenResp.IDToken)
+	assert.Equal(t, issuer, claims["iss"])
+	assert.Equal(t, userID.String(), claims["sub"])
+	assert.Equal(t, client.ID, claims["aud"])
+	assert.Equal(t, "nonce-1", claims["nonce"])
+	assert.Equal(t, float64(authTime.Unix()), claims["auth_time"])
+	assert.Equal(t, []any{"otp"}, claims["amr"])
+	assert.Equal(t, oauth.ACRDefault, claims["acr"])
+	// at_hash of "access": left half of its SHA-256, base64url encoded
+	assert.Equal(t, "oFYf1knNtrqnhAVfBRuteQ", claims["at_hash"])
+}
+
+func TestAuthorize_LoginRequired(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(req *oauth.AuthorizeRequest)
+	}{
+		{"no session", func(req *oauth.AuthorizeRequest) { req.UserID = uuid.Nil }},
+		{"prompt login", func(req *oauth.AuthorizeRequest) { req.Prompt = "login" }},
+		{"max age exceeded", func(req *oauth.AuthorizeRequest) {
+			req.MaxAge = "60"
+			req.AuthTime = time.Now().Add(-time.Hour)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, clientStorage, _, _ := newServiceAndMocks(t)
+			clientStorage.On("Get", client.ID).Return(&client, nil)
+
+			req := newAuthorizeRequest()
+			req.Scope = "openid"
+			tt.modify(&req)
+			resp, err := service.Authorize(req)
+
+			require.NotNil(t, resp, "login_required is returned to the client")
+			assert.Equal(t, redirectURI, resp.RedirectURI)
+			assert.Equal(t, oauth.ErrLoginRequired, err.(oauth.Error).Code)
+		})
+	}
+}
+
+func TestUserInfo_ClaimsByScope(t *testing.T) {
+	service, m := newServiceAndAllMocks(t)
+	m.userStorage.On("Get", userID).Return(&testUser, nil)
+
+	userInfo, err := service.UserInfo(userID, []string{"openid", "email"})
+
+	require.NoError(t, err)
+	assert.Equal(t, userID.String(), userInfo.Subject)
+	assert.Equal(t, &oauth.EmailClaims{Email: testUser.Email, EmailVerified: true}, userInfo.Email)
+	assert.Nil(t, userInfo.Profile)
+}
+
+func TestUserInfo_OpenIDScopeRequired(t *testing.T) {
+	service, _ := newServiceAndAllMocks(t)
+
+	_, err := service.UserInfo(userID, []string{"email"})
+
+	assert.Equal(t, oauth.ErrInvalidScope, err.(oauth.Error).Code)
+}
+
+func mustVerifyIDToken(t *testing.T, service *oauth.OAuthService, idToken string) jwt.MapClaims {
+	jwk := service.JWKS().Keys[0]
+	publicKey, err := jwk.PublicKey()
+	require.NoError(t, err)
+
+	verifier := authmw.NewVerifier(staticKey{publicKey}, client.ID, 0)
+	claims, err := verifier.Verify(context.Background(), idToken)
+	require.NoError(t, err)
+
+	return claims.Raw
+}
+
+type staticKey struct {
+	key any
+}
+
+func (k staticKey) Key(context.Context, *jwt.Token) (any, error) {
+	return k.key, nil
+}
+
+func (k staticKey) Methods() []string {
+	return []string{jwt.SigningMethodRS256.Alg()}
+}
@@ -0,0 +1,115 @@
+package oauth
+
+import (
+	"testing"
+	"time"
+
+	"auth/internal/services/auth"
+	"auth/internal/services/oauth"
+	"auth/pkg/authmw"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var exchangeClient = oauth.Client{
+	ID:                      "billing",
+	Name:                    "Billing",
+	Scopes:                  []string{"invoices:read", "tickets:read"},
+	GrantTypes:              []string{oauth.GrantTypeTokenExchange},
+	TokenEndpointAuthMethod: oauth.AuthMethodClientSecretBasic,
+	ExchangeAudiences:       []string{"tickets"},
+}
+
+func TestTokenExchange(t *testing.T) {
+	service, m := newServiceAndExchangeClient(t)
+	subject := &authmw.Claims{
+		Subject:  userID.String(),
+		Audience: []string{exchangeClient.ID},
+		Scopes:   []string{"invoices:read", "invoices:write", "tickets:read"},
+	}
+	m.tokenIssuer.On("VerifyAccessToken", "subject").Return(subject, nil)
+	expiresAt := time.Now().Add(time.Minute)
+	m.tokenIssuer.
+		On("CreateDelegatedAccessToken", auth.Delegation{
+			Subject:  subject,
+			Actor:    exchangeClient.ID,
+			Audience: "tickets",
+			Scopes:   []string{"invoices:read", "tickets:read"},
+		}).
+		Return("delegated", expiresAt, nil)
+
+	resp, err := service.Token(newExchangeRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, "delegated", resp.AccessToken)
+	assert.Equal(t, oauth.TokenTypeAccessToken, resp.IssuedTokenType)
+	assert.Empty(t, resp.RefreshToken)
+	assert.InDelta(t, time.Minute, resp.ExpiresIn, float64(time.Second))
+}
+
+func TestTokenExchange_AudienceNotAllowed(t *testing.T) {
+	service, _ := newServiceAndExchangeClient(t)
+
+	req := newExchangeRequest()
+	req.Audience = []string{"payroll"}
+	_, err := service.Token(req)
+
+	assert.Equal(t, oauth.ErrInvalidTarget, err.(oauth.Error).Code)
+}
+
+func TestTokenExchange_ScopeNotInSubjectToken(t *testing.T) {
+	service, m := newServiceAndExchangeClient(t)
+	m.tokenIssuer.On("VerifyAccessToken", "subject").Return(&authmw.Claims{
+		Subject: userID.String(),
+		Scopes:  []string{"tickets:read"},
+	}, nil)
+
+	req := newExchangeRequest()
+	req.Scope = "invoices:read"
+	_, err := service.Token(req)
+
+	assert.Equal(t, oauth.ErrInvalidScope, err.(oauth.Error).Code)
+}
+
+func TestTokenExchange_SubjectTokenOfAnotherClient(t *testing.T) {
+	service, m := newServiceAndExchangeClient(t)
+	m.tokenIssuer.On("VerifyAccessToken", "subject").Return(&authmw.Claims{
+		Subject:  userID.String(),
+		Audience: []string{client.ID},
+	}, nil)
+
+	_, err := service.Token(newExchangeRequest())
+
+	assert.Equal(t, oauth.ErrInvalidGrant, err.(oauth.Error).Code)
+}
+
+func TestTokenExchange_InvalidSubjectToken(t *testing.T) {
+	service, m := newServiceAndExchangeClient(t)
+	m.tokenIssuer.On("VerifyAccessToken", "subject").Return(nil, auth.UnauthorizedError{})
+
+	_, err := service.Token(newExchangeRequest())
+
+	assert.Equal(t, oauth.ErrInvalidGrant, err.(oauth.Error).Code)
+}
+
+func newServiceAndExchangeClient(t *testing.T) (*oauth.OAuthService, serviceMocks) {
+	service, m := newServiceAndAllMocks(t)
+	secretClient := exchangeClient
+	secretClient.SecretHash, _ = bcrypt.GenerateFromPassword([]byte(clientSecret), bcrypt.MinCost)
+	m.clientStorage.On("Get", secretClient.ID).Return(&secretClient, nil)
+
+	return service, m
+}
+
+func newExchangeRequest() oauth.TokenRequest {
+	return oauth.TokenRequest{
+		GrantType:        oauth.GrantTypeTokenExchange,
+		SubjectToken:     "subject",
+		SubjectTokenType: oauth.TokenTypeAccessToken,
+		Audience:         []string{"tickets"},
+		ClientID:         exchangeClient.ID,
+		ClientSecret:     clientSecret,
+	}
+}
@@ -0,0 +1,102 @@
+package oauth
+
+import (
+	"strings"
+	"time"
+
+	"auth/internal/services/auth"
+
+	"github.com/pkg/errors"
+)
+
+// exchangeToken implements the token exchange of RFC 8693 for delegation: a
+// service presents the access token of a user it received and gets a token
+// for another service with at most the same scopes. The authenticated client
+// is the actor, actor tokens are not accepted.
+func (s *OAuthService) exchangeToken(req TokenRequest) (*TokenResponse, error) {
+	if req.SubjectToken == "" || req.SubjectTokenType == "" {
+		return nil, Error{ErrInvalidRequest, "subject_token and subject_token_type are required"}
+	}
+	if req.SubjectTokenType != TokenTypeAccessToken {
+		return nil, Error{ErrInvalidRequest, "unsupported subject_token_type"}
+	}
+	if req.RequestedTokenType != "" && req.RequestedTokenType != TokenTypeAccessToken {
+		return nil, Error{ErrInvalidRequest, "unsupported requested_token_type"}
+	}
+	if req.ActorToken != "" {
+		return nil, Error{ErrInvalidRequest, "actor tokens are not supported, the client is the actor"}
+	}
+	client, err := s.authenticateClient(req.clientCredentials())
+	if err != nil {
+		return nil, err
+	}
+	if !client.IsConfidential() || !client.HasGrantType(GrantTypeTokenExchange) {
+		return nil, Error{ErrUnauthorizedClient, "client is not allowed to use token exchange"}
+	}
+	if len(req.Audience) != 1 {
+		return nil, Error{ErrInvalidTarget, "exactly one audience is required"}
+	}
+	audience := req.Audience[0]
+	if !contains(client.ExchangeAudiences, audience) {
+		return nil, Error{ErrInvalidTarget, "client is not allowed to get tokens for " + audience}
+	}
+
+	subject, err := s.tokenIssuer.VerifyAccessToken(req.SubjectToken)
+	if err != nil {
+		var unauthorizedErr auth.UnauthorizedError
+		if errors.As(err, &unauthorizedErr) {
+			return nil, Error{ErrInvalidGrant, "invalid subject token"}
+		}
+		return nil, errors.Wrap(err, "verify subject token")
+	}
+	if subject.ClientID != "" {
+		return nil, Error{ErrInvalidGrant, "subject token must represent a user"}
+	}
+	if len(subject.Audience) > 0 && !contains(subject.Audience, client.ID) {
+		return nil, Error{ErrInvalidGrant, "subject token was not issued to the client"}
+	}
+	scopes, err := exchangeScopes(client, subject.Scopes, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, expiresAt, err := s.tokenIssuer.CreateDelegatedAccessToken(auth.Delegation{
+		Subject:  subject,
+		Actor:    client.ID,
+		Audience: audience,
+		Scopes:   scopes,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create delegated access token")
+	}
+
+	return &TokenResponse{
+		AccessToken:     accessToken,
+		ExpiresIn:       time.Until(expiresAt),
+		Scopes:          scopes,
+		IssuedTokenType: TokenTypeAccessToken,
+	}, nil
+}
+
+// exchangeScopes downscopes a token: the scopes must have been granted to the
+// subject token and be allowed for the client. Omitting the scope keeps every
+// scope that satisfies both.
+func exchangeScopes(client *Client, subjectScopes []string, scope string) ([]string, error) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		scopes := []string{}
+		for _, s := range subjectScopes {
+			if contains(client.Scopes, s) {
+				scopes = append(scopes, s)
+			}
+		}
+		return scopes, nil
+	}
+
+	for _, s := range requested {
+		if !contains(subjectScopes, s) || !contains(client.Scopes, s) {
+			return nil, Error{ErrInvalidScope, "scope " + s + " cannot be delegated"}
+		}
+	}
+	return requested, nil
+}
@@ -0,0 +1,13 @@
+package ratelimit
+
+import "time"
+
+// ExceededError is returned for requests over the limit. RetryAfter is the
+// time until the next request is allowed.
+type ExceededError struct {
+	RetryAfter time.Duration
+}
+
+func (err ExceededError) Error() string {
+	return "rate limit exceeded"
+}
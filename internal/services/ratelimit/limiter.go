@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"auth/internal/config"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Limiter struct {
+	store  Store
+	limits map[Scope]Limit
+}
+
+// Store keeps the buckets. MemoryStore serves a single instance, the Postgres
+// storage shares the limits between the instances of a cluster.
+//
+//go:generate mockery --name Store --filename store.go
+type Store interface {
+	// Take applies limit.Take to the bucket of the key atomically.
+	Take(key string, limit Limit, now time.Time) (time.Duration, error)
+}
+
+func NewLimiter(store Store, cfg config.RateLimits) *Limiter {
+	return &Limiter{
+		store: store,
+		limits: map[Scope]Limit{
+			ScopeIP:           newLimit(cfg.PerIP),
+			ScopeUser:         newLimit(cfg.PerUser),
+			ScopeRefreshToken: newLimit(cfg.PerRefreshToken),
+		},
+	}
+}
+
+// Allow counts a request of the key against the limit of the scope. It
+// returns ExceededError when the limit is reached.
+func (l *Limiter) Allow(scope Scope, key string) error {
+	limit := l.limits[scope]
+	if limit.Burst <= 0 {
+		return nil
+	}
+
+	retryAfter, err := l.store.Take(string(scope)+":"+key, limit, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "take from bucket")
+	}
+	if retryAfter > 0 {
+		return ExceededError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+func newLimit(cfg config.RateLimit) Limit {
+	return Limit{Burst: cfg.Burst, Interval: cfg.Interval}
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// pruneInterval is how often stores drop the buckets that are full again.
+const pruneInterval = time.Minute
+
+type bucketEntry struct {
+	bucket Bucket
+	fullAt time.Time
+}
+
+// MemoryStore keeps the buckets in the process. Each instance of a cluster
+// has its own limits with it.
+type MemoryStore struct {
+	mu         sync.Mutex
+	buckets    map[string]*bucketEntry
+	lastPruned time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucketEntry),
+	}
+}
+
+func (s *MemoryStore) Take(key string, limit Limit, now time.Time) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastPruned) > pruneInterval {
+		for k, entry := range s.buckets {
+			if !entry.fullAt.After(now) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastPruned = now
+	}
+
+	entry, ok := s.buckets[key]
+	if !ok {
+		entry = &bucketEntry{}
+		s.buckets[key] = entry
+	}
+	retryAfter := limit.Take(&entry.bucket, now)
+	entry.fullAt = limit.FullAt(entry.bucket)
+
+	return retryAfter, nil
+}
+
+var _ Store = &MemoryStore{}
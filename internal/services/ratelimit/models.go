@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+type Scope string
+
+const (
+	ScopeIP           Scope = "ip"
+	ScopeUser         Scope = "user"
+	ScopeRefreshToken Scope = "refresh_token"
+)
+
+// Limit is a token bucket that holds up to Burst requests and regains one
+// request every Interval. A zero Burst disables the limit.
+type Limit struct {
+	Burst    int
+	Interval time.Duration
+}
+
+// Bucket is the state of a key. The zero Bucket is full.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// Take refills the bucket up to now and takes one request from it. It returns
+// zero when the request is allowed, otherwise the time until it would be.
+func (l Limit) Take(bucket *Bucket, now time.Time) time.Duration {
+	if bucket.UpdatedAt.IsZero() {
+		bucket.Tokens = float64(l.Burst)
+	} else if elapsed := now.Sub(bucket.UpdatedAt); elapsed > 0 {
+		bucket.Tokens = math.Min(float64(l.Burst), bucket.Tokens+float64(elapsed)/float64(l.Interval))
+	}
+	bucket.UpdatedAt = now
+
+	if bucket.Tokens >= 1 {
+		bucket.Tokens--
+		return 0
+	}
+	return time.Duration((1 - bucket.Tokens) * float64(l.Interval))
+}
+
+// FullAt is the time the bucket is full again. From then on it is the same
+// as no bucket at all and can be dropped.
+func (l Limit) FullAt(bucket Bucket) time.Time {
+	return bucket.UpdatedAt.Add(time.Duration((float64(l.Burst) - bucket.Tokens) * float64(l.Interval)))
+}
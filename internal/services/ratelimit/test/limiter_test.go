@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/services/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitTake(t *testing.T) {
+	limit := ratelimit.Limit{Burst: 2, Interval: 10 * time.Second}
+	now := time.Now()
+	var bucket ratelimit.Bucket
+
+	assert.Zero(t, limit.Take(&bucket, now))
+	assert.Zero(t, limit.Take(&bucket, now))
+	assert.Equal(t, 10*time.Second, limit.Take(&bucket, now))
+	assert.Equal(t, 5*time.Second, limit.Take(&bucket, now.Add(5*time.Second)))
+	assert.Zero(t, limit.Take(&bucket, now.Add(10*time.Second)))
+	assert.Equal(t, now.Add(30*time.Second), limit.FullAt(bucket))
+}
+
+func TestLimiterAllow(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), config.RateLimits{
+		PerIP: config.RateLimit{Burst: 1, Interval: time.Minute},
+	})
+
+	require.NoError(t, limiter.Allow(ratelimit.ScopeIP, "10.0.0.1"))
+	err := limiter.Allow(ratelimit.ScopeIP, "10.0.0.1")
+	var exceededErr ratelimit.ExceededError
+	require.ErrorAs(t, err, &exceededErr)
+	assert.InDelta(t, time.Minute, exceededErr.RetryAfter, float64(time.Second))
+
+	assert.NoError(t, limiter.Allow(ratelimit.ScopeIP, "10.0.0.2"), "limits are per key")
+	assert.NoError(t, limiter.Allow(ratelimit.ScopeUser, "10.0.0.1"), "scopes without a limit are not limited")
+}
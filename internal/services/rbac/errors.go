@@ -0,0 +1,33 @@
+package rbac
+
+type NotFoundError struct {
+	message string
+}
+
+func (err NotFoundError) Error() string {
+	return err.message
+}
+
+type AlreadyExistsError struct {
+	message string
+}
+
+func (err AlreadyExistsError) Error() string {
+	return err.message
+}
+
+type ValidationError struct {
+	message string
+}
+
+func (err ValidationError) Error() string {
+	return err.message
+}
+
+func NewNotFoundError(message string) NotFoundError {
+	return NotFoundError{message: message}
+}
+
+func NewAlreadyExistsError(message string) AlreadyExistsError {
+	return AlreadyExistsError{message: message}
+}
@@ -0,0 +1,29 @@
+package rbac
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminPermission grants access to the admin API of the auth service.
+const AdminPermission = "auth:admin"
+
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+	CreatedAt   time.Time
+}
+
+type Permission struct {
+	Name        string
+	Description string
+	CreatedAt   time.Time
+}
+
+type UserRole struct {
+	UserID    uuid.UUID
+	RoleName  string
+	CreatedAt time.Time
+}
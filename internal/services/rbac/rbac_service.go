@@ -0,0 +1,136 @@
+package rbac
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Names end up space-delimited in the "scope" claim, so they are restricted
+// to a safe charset.
+var nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9:._-]{1,64}$`)
+
+type RBACService struct {
+	storage Storage
+}
+
+//go:generate mockery --name Storage --filename storage.go
+type Storage interface {
+	CreateRole(role *Role) error
+	GetRole(name string) (*Role, error)
+	ListRoles() ([]Role, error)
+	DeleteRole(name string) error
+	CreatePermission(permission *Permission) error
+	ListPermissions() ([]Permission, error)
+	DeletePermission(name string) error
+	AddRolePermission(roleName, permissionName string) error
+	RemoveRolePermission(roleName, permissionName string) error
+	AssignUserRole(userID uuid.UUID, roleName string) error
+	UnassignUserRole(userID uuid.UUID, roleName string) error
+	ListUserRoles(userID uuid.UUID) ([]UserRole, error)
+}
+
+func NewRBACService(storage Storage) *RBACService {
+	return &RBACService{
+		storage: storage,
+	}
+}
+
+func (s *RBACService) CreateRole(name, description string) (*Role, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	role := &Role{Name: name, Description: description}
+	if err := s.storage.CreateRole(role); err != nil {
+		return nil, errors.Wrap(err, "create role")
+	}
+
+	return role, nil
+}
+
+func (s *RBACService) GetRole(name string) (*Role, error) {
+	role, err := s.storage.GetRole(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "get role")
+	}
+
+	return role, nil
+}
+
+func (s *RBACService) ListRoles() ([]Role, error) {
+	roles, err := s.storage.ListRoles()
+	if err != nil {
+		return nil, errors.Wrap(err, "list roles")
+	}
+
+	return roles, nil
+}
+
+func (s *RBACService) DeleteRole(name string) error {
+	return errors.Wrap(s.storage.DeleteRole(name), "delete role")
+}
+
+func (s *RBACService) CreatePermission(name, description string) (*Permission, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	permission := &Permission{Name: name, Description: description}
+	if err := s.storage.CreatePermission(permission); err != nil {
+		return nil, errors.Wrap(err, "create permission")
+	}
+
+	return permission, nil
+}
+
+func (s *RBACService) ListPermissions() ([]Permission, error) {
+	permissions, err := s.storage.ListPermissions()
+	if err != nil {
+		return nil, errors.Wrap(err, "list permissions")
+	}
+
+	return permissions, nil
+}
+
+func (s *RBACService) DeletePermission(name string) error {
+	return errors.Wrap(s.storage.DeletePermission(name), "delete permission")
+}
+
+func (s *RBACService) GrantPermission(roleName, permissionName string) error {
+	return errors.Wrap(s.storage.AddRolePermission(roleName, permissionName), "add role permission")
+}
+
+func (s *RBACService) RevokePermission(roleName, permissionName string) error {
+	return errors.Wrap(s.storage.RemoveRolePermission(roleName, permissionName), "remove role permission")
+}
+
+// AssignRole gives the role to the user. Access tokens pick the change up at
+// the next refresh.
+func (s *RBACService) AssignRole(userID uuid.UUID, roleName string) error {
+	return errors.Wrap(s.storage.AssignUserRole(userID, roleName), "assign user role")
+}
+
+// UnassignRole takes the role from the user. Access tokens that are already
+// issued keep the role until they are refreshed.
+func (s *RBACService) UnassignRole(userID uuid.UUID, roleName string) error {
+	return errors.Wrap(s.storage.UnassignUserRole(userID, roleName), "unassign user role")
+}
+
+func (s *RBACService) ListUserRoles(userID uuid.UUID) ([]UserRole, error) {
+	userRoles, err := s.storage.ListUserRoles(userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list user roles")
+	}
+
+	return userRoles, nil
+}
+
+func validateName(name string) error {
+	if !nameRegexp.MatchString(name) {
+		return ValidationError{fmt.Sprintf("invalid name %q: use up to 64 letters, digits and :._-", name)}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package rbac
+
+import (
+	"testing"
+
+	"auth/internal/services/rbac"
+	"auth/internal/services/rbac/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var userID = uuid.MustParse("5b0d8c3e-39a4-4b8e-9a53-6f3f0c2f7d41")
+
+func TestCreatePermission(t *testing.T) {
+	service, storage := newServiceAndMocks(t)
+	storage.On("CreatePermission", &rbac.Permission{Name: "invoices:read", Description: "read invoices"}).Return(nil)
+
+	permission, err := service.CreatePermission("invoices:read", "read invoices")
+
+	require.NoError(t, err)
+	assert.Equal(t, "invoices:read", permission.Name)
+}
+
+func TestCreatePermission_InvalidName(t *testing.T) {
+	service, _ := newServiceAndMocks(t)
+
+	for _, name := range []string{"", "invoices read", "invoices/read"} {
+		_, err := service.CreatePermission(name, "")
+		assert.ErrorAs(t, err, &rbac.ValidationError{}, "name %q goes into the scope claim", name)
+	}
+}
+
+func TestGrantPermission(t *testing.T) {
+	service, storage := newServiceAndMocks(t)
+	storage.On("AddRolePermission", "accountant", "invoices:read").Return(nil)
+
+	assert.NoError(t, service.GrantPermission("accountant", "invoices:read"))
+}
+
+func TestGrantPermission_NotFound(t *testing.T) {
+	service, storage := newServiceAndMocks(t)
+	storage.
+		On("AddRolePermission", "accountant", "invoices:read").
+		Return(rbac.NewNotFoundError("role accountant or permission invoices:read not found"))
+
+	err := service.GrantPermission("accountant", "invoices:read")
+
+	assert.ErrorAs(t, err, &rbac.NotFoundError{})
+}
+
+func TestRevokePermission(t *testing.T) {
+	service, storage := newServiceAndMocks(t)
+	storage.On("RemoveRolePermission", "accountant", "invoices:read").Return(nil)
+
+	assert.NoError(t, service.RevokePermission("accountant", "invoices:read"))
+}
+
+func TestRevokePermission_NotGranted(t *testing.T) {
+	service, storage := newServiceAndMocks(t)
+	storage.
+		On("RemoveRolePermission", "accountant", "invoices:read").
+		Return(rbac.NewNotFoundError("role accountant has no permission invoices:read"))
+
+	err := service.RevokePermission("accountant", "invoices:read")
+
+	assert.ErrorAs(t, err, &rbac.NotFoundError{})
+}
+
+func TestGetRole_Permissions(t *testing.T) {
+	service, storage := newServiceAndMocks(t)
+	storage.
+		On("GetRole", "accountant").
+		Return(&rbac.Role{Name: "accountant", Permissions: []string{"invoices:read", "invoices:write"}}, nil)
+
+	role, err := service.GetRole("accountant")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"invoices:read", "invoices:write"}, role.Permissions)
+}
+
+func TestAssignRole_NotFound(t *testing.T) {
+	service, storage := newServiceAndMocks(t)
+	storage.On("AssignUserRole", userID, "accountant").Return(rbac.NewNotFoundError("role accountant not found"))
+
+	err := service.AssignRole(userID, "accountant")
+
+	assert.ErrorAs(t, err, &rbac.NotFoundError{})
+}
+
+func TestCreateRole_InvalidName(t *testing.T) {
+	service, storage := newServiceAndMocks(t)
+
+	_, err := service.CreateRole("account ant", "")
+
+	assert.ErrorAs(t, err, &rbac.ValidationError{})
+	storage.AssertNotCalled(t, "CreateRole", mock.Anything)
+}
+
+func newServiceAndMocks(t *testing.T) (*rbac.RBACService, *mocks.Storage) {
+	storage := mocks.NewStorage(t)
+
+	return rbac.NewRBACService(storage), storage
+}
@@ -0,0 +1,81 @@
+package risk
+
+import (
+	"auth/internal/services/geoip"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The decisions, by increasing score.
+const (
+	DecisionAllow     = "allow"
+	DecisionNotify    = "notify"
+	DecisionChallenge = "challenge"
+	DecisionDeny      = "deny"
+)
+
+const (
+	EventCreate  = "create"
+	EventRefresh = "refresh"
+)
+
+// Request is a session creation or refresh to assess.
+type Request struct {
+	UserID uuid.UUID
+	// SessionID is the refresh token the session gets, PreviousSessionID the
+	// one it rotates from, uuid.Nil for new sessions.
+	SessionID         uuid.UUID
+	PreviousSessionID uuid.UUID
+	Event             string
+	IP                string
+	// PreviousIP is the address of the session, if known.
+	PreviousIP string
+	UserAgent  string
+}
+
+// Factor is the contribution of a signal to the score.
+type Factor struct {
+	Signal string `json:"signal"`
+	Score  int    `json:"score"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Assessment is the logged decision on a request. Assessments that allowed or
+// notified, and confirmed challenges, are accepted and make up the history
+// later requests are compared to.
+type Assessment struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	SessionID   uuid.UUID
+	Event       string
+	IP          string
+	Network     string
+	UserAgent   string
+	Location    *geoip.Location
+	Score       int
+	Decision    string
+	Factors     []Factor
+	ConfirmedAt time.Time
+	CreatedAt   time.Time
+}
+
+// Context is what the signals evaluate: the request and the history of the
+// user.
+type Context struct {
+	Request         Request
+	Now             time.Time
+	Network         string
+	Location        *geoip.Location
+	PreviousIP      string
+	PreviousNetwork string
+	// PreviousLocation is the location of PreviousIP.
+	PreviousLocation *geoip.Location
+	// Previous is the last accepted assessment of the session, or of the user
+	// for sessions without one. It is nil for users without history.
+	Previous *Assessment
+	// RecentRequests counts the assessments of the user within the velocity
+	// window, this one included.
+	RecentRequests int
+	KnownDevice    bool
+}
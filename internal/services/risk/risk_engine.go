@@ -0,0 +1,221 @@
+package risk
+
+import (
+	"auth/internal/config"
+	"auth/internal/services/audit"
+	"auth/internal/services/geoip"
+	iputils "auth/internal/utils/ip"
+	logutils "auth/internal/utils/log"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// maxUserAgentLength bounds the stored User-Agent header.
+const maxUserAgentLength = 512
+
+// Engine scores requests by their signals and logs every decision.
+type Engine struct {
+	storage        Storage
+	locator        Locator
+	auditLog       AuditLog
+	signals        []Signal
+	enabled        bool
+	notifyScore    int
+	challengeScore int
+	denyScore      int
+	ipv4Prefix     int
+	ipv6Prefix     int
+	velocityWindow time.Duration
+}
+
+//go:generate mockery --name Storage --filename storage.go
+type Storage interface {
+	Create(assessment *Assessment) error
+	// Previous returns the last accepted assessment of the session, or of the
+	// user when the session has none. It returns NotFoundError for users
+	// without accepted assessments.
+	Previous(userID, sessionID uuid.UUID) (*Assessment, error)
+	CountSince(userID uuid.UUID, since time.Time) (int, error)
+	// IsKnownDevice returns whether an accepted assessment of the user has
+	// the user agent.
+	IsKnownDevice(userID uuid.UUID, userAgent string) (bool, error)
+	// Confirm accepts the assessment of a confirmed challenge. It returns
+	// NotFoundError for unknown assessments.
+	Confirm(id uuid.UUID, confirmedAt time.Time) error
+}
+
+// Locator resolves addresses from a local database.
+//
+//go:generate mockery --name Locator --filename locator.go
+type Locator interface {
+	// Locate returns nil for addresses the database does not know.
+	Locate(ip string) (*geoip.Location, error)
+}
+
+//go:generate mockery --name AuditLog --filename audit_log.go
+type AuditLog interface {
+	Record(event audit.Event) error
+}
+
+// NewEngine scores with DefaultSignals unless signals are given. The locator
+// may be nil, the signals that need locations do not apply then.
+func NewEngine(storage Storage, locator Locator, auditLog AuditLog, cfg config.Risk, signals ...Signal) *Engine {
+	if len(signals) == 0 {
+		signals = DefaultSignals(cfg)
+	}
+	return &Engine{
+		storage:        storage,
+		locator:        locator,
+		auditLog:       auditLog,
+		signals:        signals,
+		enabled:        cfg.Enabled,
+		notifyScore:    cfg.NotifyScore,
+		challengeScore: cfg.ChallengeScore,
+		denyScore:      cfg.DenyScore,
+		ipv4Prefix:     cfg.IPv4Prefix,
+		ipv6Prefix:     cfg.IPv6Prefix,
+		velocityWindow: cfg.VelocityWindow,
+	}
+}
+
+// Assess scores the request, stores the assessment and records it in the
+// audit log with its factors. It returns nil when the engine is disabled.
+func (e *Engine) Assess(req Request) (*Assessment, error) {
+	if !e.enabled {
+		return nil, nil
+	}
+	if len(req.UserAgent) > maxUserAgentLength {
+		req.UserAgent = req.UserAgent[:maxUserAgentLength]
+	}
+
+	ctx, err := e.newContext(req)
+	if err != nil {
+		return nil, err
+	}
+	assessment := &Assessment{
+		ID:        uuid.New(),
+		UserID:    req.UserID,
+		SessionID: req.SessionID,
+		Event:     req.Event,
+		IP:        req.IP,
+		Network:   ctx.Network,
+		UserAgent: req.UserAgent,
+		Location:  ctx.Location,
+		Factors:   []Factor{},
+		CreatedAt: ctx.Now,
+	}
+	for _, signal := range e.signals {
+		score, detail := signal.Evaluate(ctx)
+		if score == 0 {
+			continue
+		}
+		assessment.Score += score
+		assessment.Factors = append(assessment.Factors, Factor{Signal: signal.Name(), Score: score, Detail: detail})
+	}
+	assessment.Decision = e.decide(assessment.Score)
+
+	if err := e.storage.Create(assessment); err != nil {
+		return nil, errors.Wrap(err, "create assessment")
+	}
+	e.recordEvent(assessment)
+	return assessment, nil
+}
+
+// newContext looks up the history the signals compare the request to.
+func (e *Engine) newContext(req Request) (*Context, error) {
+	ctx := &Context{
+		Request:    req,
+		Now:        time.Now(),
+		Network:    iputils.Network(req.IP, e.ipv4Prefix, e.ipv6Prefix),
+		Location:   e.locate(req.IP),
+		PreviousIP: req.PreviousIP,
+	}
+
+	previous, err := e.storage.Previous(req.UserID, req.PreviousSessionID)
+	if err != nil {
+		var notFoundErr NotFoundError
+		if !errors.As(err, &notFoundErr) {
+			return nil, errors.Wrap(err, "get previous assessment")
+		}
+	}
+	ctx.Previous = previous
+	if ctx.PreviousIP == "" && previous != nil {
+		ctx.PreviousIP = previous.IP
+	}
+	if ctx.PreviousIP != "" {
+		ctx.PreviousNetwork = iputils.Network(ctx.PreviousIP, e.ipv4Prefix, e.ipv6Prefix)
+		ctx.PreviousLocation = e.locate(ctx.PreviousIP)
+	}
+
+	// the request being assessed counts too
+	ctx.RecentRequests, err = e.storage.CountSince(req.UserID, ctx.Now.Add(-e.velocityWindow))
+	if err != nil {
+		return nil, errors.Wrap(err, "count recent assessments")
+	}
+	ctx.RecentRequests++
+
+	ctx.KnownDevice, err = e.storage.IsKnownDevice(req.UserID, req.UserAgent)
+	if err != nil {
+		return nil, errors.Wrap(err, "check known device")
+	}
+	return ctx, nil
+}
+
+// locate returns nil without a locator. Lookup errors are logged, the
+// location is unknown then.
+func (e *Engine) locate(ip string) *geoip.Location {
+	if e.locator == nil {
+		return nil
+	}
+	location, err := e.locator.Locate(ip)
+	if err != nil {
+		logutils.Error("locate ip error", err)
+		return nil
+	}
+	return location
+}
+
+func (e *Engine) decide(score int) string {
+	switch {
+	case score >= e.denyScore:
+		return DecisionDeny
+	case score >= e.challengeScore:
+		return DecisionChallenge
+	case score >= e.notifyScore:
+		return DecisionNotify
+	default:
+		return DecisionAllow
+	}
+}
+
+// Confirm accepts the assessment once the user confirmed its challenge, so
+// that its network and device become known.
+func (e *Engine) Confirm(id uuid.UUID) error {
+	return e.storage.Confirm(id, time.Now())
+}
+
+func (e *Engine) recordEvent(assessment *Assessment) {
+	factors, err := json.Marshal(assessment.Factors)
+	if err != nil {
+		logutils.Error("marshal factors error", err)
+	}
+	err = e.auditLog.Record(audit.Event{
+		Type:   audit.EventRiskAssessed,
+		UserID: assessment.UserID,
+		IP:     assessment.IP,
+		Details: map[string]string{
+			"assessmentId": assessment.ID.String(),
+			"event":        assessment.Event,
+			"score":        strconv.Itoa(assessment.Score),
+			"decision":     assessment.Decision,
+			"factors":      string(factors),
+		},
+	})
+	if err != nil {
+		logutils.Error("record audit event error", err)
+	}
+}
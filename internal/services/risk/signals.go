@@ -0,0 +1,156 @@
+package risk
+
+import (
+	"auth/internal/config"
+	"auth/internal/services/geoip"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Signal scores one aspect of a request. Custom signals may be passed to
+// NewEngine.
+type Signal interface {
+	Name() string
+	// Evaluate returns the score of the signal, zero when it does not apply,
+	// and a detail for the log.
+	Evaluate(ctx *Context) (int, string)
+}
+
+// DefaultSignals are the signals weighted in the configuration.
+func DefaultSignals(cfg config.Risk) []Signal {
+	return []Signal{
+		IPChange{Weight: cfg.Weights.IPChange},
+		NetworkChange{Weight: cfg.Weights.NetworkChange},
+		ASNChange{Weight: cfg.Weights.ASNChange},
+		UserAgentChange{Weight: cfg.Weights.UserAgentChange},
+		ImpossibleTravel{Weight: cfg.Weights.ImpossibleTravel, MaxSpeed: cfg.MaxTravelSpeed},
+		Velocity{Weight: cfg.Weights.Velocity, Limit: cfg.VelocityLimit},
+		UnknownDevice{Weight: cfg.Weights.UnknownDevice},
+	}
+}
+
+type IPChange struct {
+	Weight int
+}
+
+func (IPChange) Name() string { return "ip_change" }
+
+func (s IPChange) Evaluate(ctx *Context) (int, string) {
+	if ctx.PreviousIP == "" || ctx.PreviousIP == ctx.Request.IP {
+		return 0, ""
+	}
+	return s.Weight, "from " + ctx.PreviousIP
+}
+
+type NetworkChange struct {
+	Weight int
+}
+
+func (NetworkChange) Name() string { return "network_change" }
+
+func (s NetworkChange) Evaluate(ctx *Context) (int, string) {
+	if ctx.PreviousNetwork == "" || ctx.PreviousNetwork == ctx.Network {
+		return 0, ""
+	}
+	return s.Weight, "from " + ctx.PreviousNetwork
+}
+
+// ASNChange applies when the autonomous systems of both addresses are known
+// and differ, e.g. a switch from a home ISP to a hosting provider.
+type ASNChange struct {
+	Weight int
+}
+
+func (ASNChange) Name() string { return "asn_change" }
+
+func (s ASNChange) Evaluate(ctx *Context) (int, string) {
+	previous, current := ctx.PreviousLocation, ctx.Location
+	if previous == nil || current == nil || previous.ASN == 0 || current.ASN == 0 || previous.ASN == current.ASN {
+		return 0, ""
+	}
+	return s.Weight, fmt.Sprintf("from AS%d to AS%d", previous.ASN, current.ASN)
+}
+
+type UserAgentChange struct {
+	Weight int
+}
+
+func (UserAgentChange) Name() string { return "user_agent_change" }
+
+func (s UserAgentChange) Evaluate(ctx *Context) (int, string) {
+	if ctx.Previous == nil || ctx.Previous.UserAgent == ctx.Request.UserAgent {
+		return 0, ""
+	}
+	return s.Weight, "from " + ctx.Previous.UserAgent
+}
+
+// minTravelDistance ignores the inaccuracy of geolocation.
+const minTravelDistance = 100
+
+// ImpossibleTravel applies when the user would have moved from the location
+// of the previous accepted request faster than MaxSpeed km/h.
+type ImpossibleTravel struct {
+	Weight   int
+	MaxSpeed float64
+}
+
+func (ImpossibleTravel) Name() string { return "impossible_travel" }
+
+func (s ImpossibleTravel) Evaluate(ctx *Context) (int, string) {
+	if ctx.Previous == nil || ctx.Previous.Location == nil || ctx.Previous.Location.Coordinates == nil ||
+		ctx.Location == nil || ctx.Location.Coordinates == nil {
+		return 0, ""
+	}
+	km := distance(*ctx.Previous.Location.Coordinates, *ctx.Location.Coordinates)
+	if km < minTravelDistance {
+		return 0, ""
+	}
+	elapsed := ctx.Now.Sub(ctx.Previous.CreatedAt)
+	if elapsed > 0 && km/elapsed.Hours() <= s.MaxSpeed {
+		return 0, ""
+	}
+	return s.Weight, fmt.Sprintf("%.0f km in %s", km, elapsed.Round(time.Second))
+}
+
+// Velocity applies to users with more than Limit requests within the window.
+type Velocity struct {
+	Weight int
+	Limit  int
+}
+
+func (Velocity) Name() string { return "velocity" }
+
+func (s Velocity) Evaluate(ctx *Context) (int, string) {
+	if ctx.RecentRequests <= s.Limit {
+		return 0, ""
+	}
+	return s.Weight, fmt.Sprintf("%d requests", ctx.RecentRequests)
+}
+
+// UnknownDevice applies to user agents the user has no accepted request
+// from. The first request of a user does not count.
+type UnknownDevice struct {
+	Weight int
+}
+
+func (UnknownDevice) Name() string { return "unknown_device" }
+
+func (s UnknownDevice) Evaluate(ctx *Context) (int, string) {
+	if ctx.Previous == nil || ctx.KnownDevice {
+		return 0, ""
+	}
+	return s.Weight, ctx.Request.UserAgent
+}
+
+// earthRadius in km.
+const earthRadius = 6371
+
+// distance is the great-circle distance in km.
+func distance(a, b geoip.Coordinates) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
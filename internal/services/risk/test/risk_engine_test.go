@@ -0,0 +1,192 @@
+package risk
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/services/audit"
+	"auth/internal/services/geoip"
+	"auth/internal/services/risk"
+	"auth/internal/services/risk/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	userID    = uuid.New()
+	sessionID = uuid.New()
+	cfg       = config.Risk{
+		Enabled:        true,
+		NotifyScore:    20,
+		ChallengeScore: 50,
+		DenyScore:      90,
+		Weights: config.RiskWeights{
+			IPChange:         10,
+			NetworkChange:    10,
+			ASNChange:        20,
+			UserAgentChange:  20,
+			ImpossibleTravel: 60,
+			Velocity:         40,
+			UnknownDevice:    20,
+		},
+		IPv4Prefix:     24,
+		IPv6Prefix:     48,
+		MaxTravelSpeed: 1000,
+		VelocityLimit:  10,
+		VelocityWindow: 10 * time.Minute,
+	}
+	berlin  = &geoip.Location{Country: "DE", City: "Berlin", ASN: 3320, Coordinates: &geoip.Coordinates{Latitude: 52.52, Longitude: 13.40}}
+	newYork = &geoip.Location{Country: "US", City: "New York", ASN: 7922, Coordinates: &geoip.Coordinates{Latitude: 40.71, Longitude: -74.01}}
+)
+
+func TestAssess_FirstSession(t *testing.T) {
+	engine, storage, locator, auditLog := newEngineAndMocks(t, cfg)
+	locator.On("Locate", mock.AnythingOfType("string")).Return(nil, nil)
+	storage.On("Previous", userID, uuid.Nil).Return(nil, risk.NewNotFoundError("assessment not found"))
+	storage.On("CountSince", userID, mock.AnythingOfType("time.Time")).Return(0, nil)
+	storage.On("IsKnownDevice", userID, "Firefox").Return(false, nil)
+	storage.On("Create", mock.AnythingOfType("*risk.Assessment")).Return(nil)
+	auditLog.On("Record", mock.AnythingOfType("audit.Event")).Return(nil)
+
+	assessment, err := engine.Assess(risk.Request{
+		UserID:    userID,
+		SessionID: sessionID,
+		Event:     risk.EventCreate,
+		IP:        "203.0.113.7",
+		UserAgent: "Firefox",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, risk.DecisionAllow, assessment.Decision)
+	assert.Zero(t, assessment.Score)
+	assert.Empty(t, assessment.Factors)
+	assert.Equal(t, "203.0.113.0/24", assessment.Network)
+}
+
+func TestAssess_Factors(t *testing.T) {
+	engine, storage, locator, auditLog := newEngineAndMocks(t, cfg)
+	locator.On("Locate", mock.AnythingOfType("string")).Return(nil, nil)
+	previousSessionID := uuid.New()
+	previous := &risk.Assessment{
+		ID:        uuid.New(),
+		UserID:    userID,
+		IP:        "203.0.113.7",
+		Network:   "203.0.113.0/24",
+		UserAgent: "Firefox",
+		Decision:  risk.DecisionAllow,
+		CreatedAt: time.Now().Add(-time.Hour),
+	}
+	storage.On("Previous", userID, previousSessionID).Return(previous, nil)
+	storage.On("CountSince", userID, mock.AnythingOfType("time.Time")).Return(1, nil)
+	storage.On("IsKnownDevice", userID, "curl").Return(false, nil)
+	var created *risk.Assessment
+	storage.
+		On("Create", mock.AnythingOfType("*risk.Assessment")).
+		Run(func(args mock.Arguments) { created = args.Get(0).(*risk.Assessment) }).
+		Return(nil)
+	var event audit.Event
+	auditLog.
+		On("Record", mock.AnythingOfType("audit.Event")).
+		Run(func(args mock.Arguments) { event = args.Get(0).(audit.Event) }).
+		Return(nil)
+
+	assessment, err := engine.Assess(risk.Request{
+		UserID:            userID,
+		SessionID:         sessionID,
+		PreviousSessionID: previousSessionID,
+		Event:             risk.EventRefresh,
+		IP:                "198.51.100.9",
+		PreviousIP:        "203.0.113.7",
+		UserAgent:         "curl",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, created, assessment)
+	assert.Equal(t, 60, assessment.Score)
+	assert.Equal(t, risk.DecisionChallenge, assessment.Decision)
+	assert.Equal(t, []risk.Factor{
+		{Signal: "ip_change", Score: 10, Detail: "from 203.0.113.7"},
+		{Signal: "network_change", Score: 10, Detail: "from 203.0.113.0/24"},
+		{Signal: "user_agent_change", Score: 20, Detail: "from Firefox"},
+		{Signal: "unknown_device", Score: 20, Detail: "curl"},
+	}, assessment.Factors)
+
+	assert.Equal(t, audit.EventRiskAssessed, event.Type)
+	assert.Equal(t, "challenge", event.Details["decision"])
+	var factors []risk.Factor
+	require.NoError(t, json.Unmarshal([]byte(event.Details["factors"]), &factors))
+	assert.Equal(t, assessment.Factors, factors)
+}
+
+func TestAssess_ImpossibleTravel(t *testing.T) {
+	engine, storage, locator, auditLog := newEngineAndMocks(t, cfg)
+	previous := &risk.Assessment{
+		ID:        uuid.New(),
+		UserID:    userID,
+		IP:        "203.0.113.7",
+		Network:   "203.0.113.0/24",
+		UserAgent: "Firefox",
+		Location:  berlin,
+		Decision:  risk.DecisionAllow,
+		CreatedAt: time.Now().Add(-30 * time.Minute),
+	}
+	storage.On("Previous", userID, uuid.Nil).Return(previous, nil)
+	storage.On("CountSince", userID, mock.AnythingOfType("time.Time")).Return(1, nil)
+	storage.On("IsKnownDevice", userID, "Firefox").Return(true, nil)
+	storage.On("Create", mock.AnythingOfType("*risk.Assessment")).Return(nil)
+	locator.On("Locate", "198.51.100.9").Return(newYork, nil)
+	locator.On("Locate", "203.0.113.7").Return(berlin, nil)
+	auditLog.On("Record", mock.AnythingOfType("audit.Event")).Return(nil)
+
+	assessment, err := engine.Assess(risk.Request{
+		UserID:    userID,
+		SessionID: sessionID,
+		Event:     risk.EventCreate,
+		IP:        "198.51.100.9",
+		UserAgent: "Firefox",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, newYork, assessment.Location)
+	assert.Equal(t, 100, assessment.Score)
+	assert.Equal(t, risk.DecisionDeny, assessment.Decision)
+	signals := make([]string, 0, len(assessment.Factors))
+	for _, factor := range assessment.Factors {
+		signals = append(signals, factor.Signal)
+	}
+	assert.Equal(t, []string{"ip_change", "network_change", "asn_change", "impossible_travel"}, signals)
+}
+
+func TestAssess_Velocity(t *testing.T) {
+	engine, storage, locator, auditLog := newEngineAndMocks(t, cfg)
+	locator.On("Locate", mock.AnythingOfType("string")).Return(nil, nil)
+	storage.On("Previous", userID, uuid.Nil).Return(nil, risk.NewNotFoundError("assessment not found"))
+	storage.On("CountSince", userID, mock.AnythingOfType("time.Time")).Return(cfg.VelocityLimit, nil)
+	storage.On("IsKnownDevice", userID, "").Return(false, nil)
+	storage.On("Create", mock.AnythingOfType("*risk.Assessment")).Return(nil)
+	auditLog.On("Record", mock.AnythingOfType("audit.Event")).Return(nil)
+
+	assessment, err := engine.Assess(risk.Request{UserID: userID, SessionID: sessionID, IP: "203.0.113.7"})
+	require.NoError(t, err)
+	assert.Equal(t, []risk.Factor{{Signal: "velocity", Score: 40, Detail: "11 requests"}}, assessment.Factors)
+	assert.Equal(t, risk.DecisionNotify, assessment.Decision)
+}
+
+func TestAssess_Disabled(t *testing.T) {
+	disabled := cfg
+	disabled.Enabled = false
+	engine, _, _, _ := newEngineAndMocks(t, disabled)
+
+	assessment, err := engine.Assess(risk.Request{UserID: userID, IP: "203.0.113.7"})
+	require.NoError(t, err)
+	assert.Nil(t, assessment)
+}
+
+func newEngineAndMocks(t *testing.T, cfg config.Risk) (*risk.Engine, *mocks.Storage, *mocks.Locator, *mocks.AuditLog) {
+	storage := mocks.NewStorage(t)
+	locator := mocks.NewLocator(t)
+	auditLog := mocks.NewAuditLog(t)
+	return risk.NewEngine(storage, locator, auditLog, cfg), storage, locator, auditLog
+}
@@ -0,0 +1,32 @@
+package user
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID            uuid.UUID
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+	// Locale is the language of the emails to the user, a BCP 47 tag. Empty
+	// means the language of the session.
+	Locale    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// UserRequest holds the editable fields of a user. Updates replace all of
+// them.
+type UserRequest struct {
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+	Locale        string
+}
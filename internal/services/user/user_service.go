@@ -0,0 +1,101 @@
+package user
+
+import (
+	"net/mail"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"golang.org/x/text/language"
+)
+
+type UserService struct {
+	storage Storage
+}
+
+//go:generate mockery --name Storage --filename storage.go
+type Storage interface {
+	Create(user *User) error
+	Get(id uuid.UUID) (*User, error)
+	List() ([]User, error)
+	Update(user *User) error
+	Delete(id uuid.UUID) error
+}
+
+func NewUserService(storage Storage) *UserService {
+	return &UserService{
+		storage: storage,
+	}
+}
+
+func (s *UserService) CreateUser(req UserRequest) (*User, error) {
+	if err := validateUserRequest(req); err != nil {
+		return nil, err
+	}
+
+	user := newUser(uuid.New(), req)
+	if err := s.storage.Create(user); err != nil {
+		return nil, errors.Wrap(err, "create user")
+	}
+
+	return user, nil
+}
+
+func (s *UserService) GetUser(id uuid.UUID) (*User, error) {
+	user, err := s.storage.Get(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "get user")
+	}
+
+	return user, nil
+}
+
+func (s *UserService) ListUsers() ([]User, error) {
+	users, err := s.storage.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "list users")
+	}
+
+	return users, nil
+}
+
+func (s *UserService) UpdateUser(id uuid.UUID, req UserRequest) (*User, error) {
+	if err := validateUserRequest(req); err != nil {
+		return nil, err
+	}
+
+	user := newUser(id, req)
+	if err := s.storage.Update(user); err != nil {
+		return nil, errors.Wrap(err, "update user")
+	}
+
+	return user, nil
+}
+
+func (s *UserService) DeleteUser(id uuid.UUID) error {
+	return errors.Wrap(s.storage.Delete(id), "delete user")
+}
+
+func newUser(id uuid.UUID, req UserRequest) *User {
+	return &User{
+		ID:            id,
+		Email:         req.Email,
+		EmailVerified: req.EmailVerified,
+		Name:          req.Name,
+		GivenName:     req.GivenName,
+		FamilyName:    req.FamilyName,
+		Locale:        req.Locale,
+	}
+}
+
+func validateUserRequest(req UserRequest) error {
+	address, err := mail.ParseAddress(req.Email)
+	if err != nil || address.Address != req.Email {
+		return ValidationError{"email must be a plain email address"}
+	}
+	if req.Locale != "" {
+		if _, err := language.Parse(req.Locale); err != nil {
+			return ValidationError{"locale must be a BCP 47 language tag"}
+		}
+	}
+	return nil
+}
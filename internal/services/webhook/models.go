@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	EventSessionCreated   = "session.created"
+	EventSessionRefreshed = "session.refreshed"
+	EventSessionRevoked   = "session.revoked"
+	// EventUserSessionsRevoked is sent when all sessions of a user end at
+	// once.
+	EventUserSessionsRevoked = "user.sessions_revoked"
+	// EventCredentialResetRequired asks the owner of the user's credentials
+	// to make the user choose new ones.
+	EventCredentialResetRequired = "user.credential_reset_required"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	// DeliveryStatusDead is set after the last failed attempt. Dead
+	// deliveries are only retried when replayed.
+	DeliveryStatusDead = "dead"
+)
+
+// Event is a domain event of the outbox. It is written in the transaction of
+// the change it describes and delivered to the subscribed endpoints later.
+type Event struct {
+	ID        uuid.UUID
+	Type      string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
+
+func NewEvent(eventType string, payload any) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal payload")
+	}
+
+	return &Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		Payload:   data,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// SessionPayload is the payload of the session events. SessionID is the ID of
+// the refresh token.
+type SessionPayload struct {
+	UserID            uuid.UUID  `json:"userId"`
+	SessionID         uuid.UUID  `json:"sessionId"`
+	PreviousSessionID *uuid.UUID `json:"previousSessionId,omitempty"`
+	IP                string     `json:"ip"`
+}
+
+// UserPayload is the payload of the user events.
+type UserPayload struct {
+	UserID uuid.UUID `json:"userId"`
+	IP     string    `json:"ip"`
+	Reason string    `json:"reason"`
+}
+
+// Delivery is the delivery of an event to an endpoint.
+type Delivery struct {
+	ID            uuid.UUID
+	Event         Event
+	Endpoint      string
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	DeliveredAt   time.Time
+	CreatedAt     time.Time
+}
+
+// DeliveryFilter selects deliveries, newest first. Zero fields do not
+// filter.
+type DeliveryFilter struct {
+	Status   string
+	Endpoint string
+	Limit    int
+}
+
+// Endpoint receives the events of the listed types, or all events when
+// Events is empty.
+type Endpoint struct {
+	Name   string
+	URL    string
+	Secret string
+	Events []string
+}
+
+// message is the body posted to endpoints.
+type message struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
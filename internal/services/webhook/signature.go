@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+const (
+	// IDHeader carries the event ID, which receivers use to drop duplicate
+	// deliveries.
+	IDHeader        = "Webhook-Id"
+	TimestampHeader = "Webhook-Timestamp"
+	SignatureHeader = "Webhook-Signature"
+
+	signatureVersion = "v1"
+)
+
+// Signature signs the body of a delivery. It covers the event ID and the
+// timestamp, so that receivers can reject replayed requests by their age.
+func Signature(secret []byte, eventID string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(eventID + "." + strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+}
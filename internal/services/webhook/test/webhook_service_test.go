@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"auth/internal/config"
+	"auth/internal/services/webhook"
+	"auth/internal/services/webhook/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "whsec"
+
+func TestDispatch_Delivered(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+	service, storage := newServiceAndMocks(t, server.URL, 3)
+	delivery := newDelivery(t, 0)
+	storage.On("CreateDeliveries", mock.Anything, mock.Anything).Return(0, nil)
+	storage.On("ClaimDeliveries", mock.Anything, mock.Anything, mock.Anything).Return([]webhook.Delivery{delivery}, nil)
+	storage.
+		On("UpdateDelivery", mock.MatchedBy(func(d *webhook.Delivery) bool {
+			return d.Status == webhook.DeliveryStatusDelivered && d.Attempts == 1 && !d.DeliveredAt.IsZero()
+		})).
+		Return(nil)
+
+	require.NoError(t, service.Dispatch(context.Background()))
+
+	require.NotNil(t, received)
+	eventID := received.Header.Get(webhook.IDHeader)
+	assert.Equal(t, delivery.Event.ID.String(), eventID)
+	timestamp, err := strconv.ParseInt(received.Header.Get(webhook.TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.Signature([]byte(secret), eventID, timestamp, body), received.Header.Get(webhook.SignatureHeader))
+	var msg map[string]any
+	require.NoError(t, json.Unmarshal(body, &msg))
+	assert.Equal(t, webhook.EventSessionRevoked, msg["type"])
+}
+
+func TestDispatch_RetriedThenDead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	service, storage := newServiceAndMocks(t, server.URL, 3)
+	storage.On("CreateDeliveries", mock.Anything, mock.Anything).Return(0, nil)
+	storage.
+		On("ClaimDeliveries", mock.Anything, mock.Anything, mock.Anything).
+		Return([]webhook.Delivery{newDelivery(t, 0), newDelivery(t, 2)}, nil)
+	storage.
+		On("UpdateDelivery", mock.MatchedBy(func(d *webhook.Delivery) bool {
+			return d.Attempts == 1 && d.Status == webhook.DeliveryStatusPending &&
+				d.NextAttemptAt.After(time.Now().Add(50*time.Second)) && d.LastError != ""
+		})).
+		Return(nil).
+		Once()
+	storage.
+		On("UpdateDelivery", mock.MatchedBy(func(d *webhook.Delivery) bool {
+			return d.Attempts == 3 && d.Status == webhook.DeliveryStatusDead
+		})).
+		Return(nil).
+		Once()
+
+	require.NoError(t, service.Dispatch(context.Background()))
+}
+
+func TestDispatch_Subscriptions(t *testing.T) {
+	service, storage := newServiceAndMocks(t, "http://localhost", 3)
+	storage.
+		On("CreateDeliveries", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			endpoints := args.Get(1).(func(string) []string)
+			assert.Equal(t, []string{"crm"}, endpoints(webhook.EventSessionRevoked))
+			assert.Empty(t, endpoints(webhook.EventSessionCreated))
+		}).
+		Return(0, nil)
+	storage.On("ClaimDeliveries", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+
+	require.NoError(t, service.Dispatch(context.Background()))
+}
+
+func newDelivery(t *testing.T, attempts int) webhook.Delivery {
+	event, err := webhook.NewEvent(webhook.EventSessionRevoked, webhook.SessionPayload{UserID: uuid.New(), SessionID: uuid.New()})
+	require.NoError(t, err)
+	return webhook.Delivery{
+		ID:       uuid.New(),
+		Event:    *event,
+		Endpoint: "crm",
+		Status:   webhook.DeliveryStatusPending,
+		Attempts: attempts,
+	}
+}
+
+func newServiceAndMocks(t *testing.T, url string, maxAttempts int) (*webhook.WebhookService, *mocks.Storage) {
+	storage := mocks.NewStorage(t)
+	service := webhook.NewWebhookService(storage, config.Webhooks{
+		Timeout:     time.Second,
+		MaxAttempts: maxAttempts,
+		BaseBackoff: time.Minute,
+		MaxBackoff:  time.Hour,
+		Endpoints: []config.WebhookEndpoint{
+			{Name: "crm", URL: url, Secret: secret, Events: []string{webhook.EventSessionRevoked}},
+		},
+	})
+	return service, storage
+}
@@ -0,0 +1,232 @@
+package webhook
+
+import (
+	"auth/internal/config"
+	logutils "auth/internal/utils/log"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	batchSize = 100
+	// maxErrorLength bounds the response body kept as the error of a
+	// delivery.
+	maxErrorLength = 512
+)
+
+type WebhookService struct {
+	storage      Storage
+	httpClient   *http.Client
+	endpoints    map[string]Endpoint
+	pollInterval time.Duration
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+}
+
+//go:generate mockery --name Storage --filename storage.go
+type Storage interface {
+	// CreateDeliveries turns up to limit events of the outbox into one
+	// delivery per endpoint that endpoints returns for the event type. The
+	// events are marked dispatched in the same transaction.
+	CreateDeliveries(limit int, endpoints func(eventType string) []string) (int, error)
+	// ClaimDeliveries returns up to limit pending deliveries that are due and
+	// postpones them by lease, so that other instances skip them while they
+	// are sent.
+	ClaimDeliveries(now time.Time, limit int, lease time.Duration) ([]Delivery, error)
+	UpdateDelivery(delivery *Delivery) error
+	ListDeliveries(filter DeliveryFilter) ([]Delivery, error)
+	// Replay makes a dead delivery pending again with no attempts. It
+	// returns NotFoundError for deliveries that are not dead.
+	Replay(id uuid.UUID, at time.Time) error
+}
+
+func NewWebhookService(storage Storage, cfg config.Webhooks) *WebhookService {
+	endpoints := make(map[string]Endpoint, len(cfg.Endpoints))
+	for _, endpoint := range cfg.Endpoints {
+		endpoints[endpoint.Name] = Endpoint{
+			Name:   endpoint.Name,
+			URL:    endpoint.URL,
+			Secret: endpoint.Secret,
+			Events: endpoint.Events,
+		}
+	}
+
+	return &WebhookService{
+		storage:      storage,
+		httpClient:   &http.Client{Timeout: cfg.Timeout},
+		endpoints:    endpoints,
+		pollInterval: cfg.PollInterval,
+		maxAttempts:  cfg.MaxAttempts,
+		baseBackoff:  cfg.BaseBackoff,
+		maxBackoff:   cfg.MaxBackoff,
+	}
+}
+
+// Run dispatches the outbox until the context is done.
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.Dispatch(ctx); err != nil {
+			logutils.Error("dispatch webhooks error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch fans the new events of the outbox out to the endpoints and sends
+// the deliveries that are due.
+func (s *WebhookService) Dispatch(ctx context.Context) error {
+	for {
+		n, err := s.storage.CreateDeliveries(batchSize, s.subscribers)
+		if err != nil {
+			return errors.Wrap(err, "create deliveries")
+		}
+		if n < batchSize {
+			break
+		}
+	}
+
+	// twice the timeout covers the request and the update of the delivery
+	lease := 2 * s.httpClient.Timeout
+	for {
+		deliveries, err := s.storage.ClaimDeliveries(time.Now(), batchSize, lease)
+		if err != nil {
+			return errors.Wrap(err, "claim deliveries")
+		}
+		for i := range deliveries {
+			if err := s.deliver(ctx, &deliveries[i]); err != nil {
+				return errors.Wrap(err, "deliver")
+			}
+		}
+		if len(deliveries) < batchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (s *WebhookService) ListDeliveries(filter DeliveryFilter) ([]Delivery, error) {
+	if filter.Limit == 0 {
+		filter.Limit = batchSize
+	}
+	if filter.Limit < 0 || filter.Limit > 1000 {
+		return nil, ValidationError{"limit must be between 1 and 1000"}
+	}
+
+	deliveries, err := s.storage.ListDeliveries(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "list deliveries")
+	}
+
+	return deliveries, nil
+}
+
+// Replay sends a dead delivery again with the next dispatch.
+func (s *WebhookService) Replay(id uuid.UUID) error {
+	return errors.Wrap(s.storage.Replay(id, time.Now()), "replay delivery")
+}
+
+// deliver sends the delivery once and records the outcome. Only storage
+// errors are returned, failed requests are retried later.
+func (s *WebhookService) deliver(ctx context.Context, delivery *Delivery) error {
+	delivery.Attempts++
+	sendErr := s.send(ctx, delivery)
+	switch {
+	case sendErr == nil:
+		delivery.Status = DeliveryStatusDelivered
+		delivery.DeliveredAt = time.Now()
+		delivery.LastError = ""
+	case delivery.Attempts >= s.maxAttempts:
+		delivery.Status = DeliveryStatusDead
+		delivery.LastError = sendErr.Error()
+	default:
+		delivery.NextAttemptAt = time.Now().Add(s.backoff(delivery.Attempts))
+		delivery.LastError = sendErr.Error()
+	}
+
+	return errors.Wrap(s.storage.UpdateDelivery(delivery), "update delivery")
+}
+
+func (s *WebhookService) send(ctx context.Context, delivery *Delivery) error {
+	endpoint, ok := s.endpoints[delivery.Endpoint]
+	if !ok {
+		return errors.Errorf("endpoint %s is not configured", delivery.Endpoint)
+	}
+
+	body, err := json.Marshal(message{
+		ID:        delivery.Event.ID,
+		Type:      delivery.Event.Type,
+		CreatedAt: delivery.Event.CreatedAt,
+		Data:      delivery.Event.Payload,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal message")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "create request")
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, delivery.Event.ID.String())
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Signature([]byte(endpoint.Secret), delivery.Event.ID.String(), timestamp, body))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "send request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+		return fmt.Errorf("endpoint responded %d: %s", resp.StatusCode, respBody)
+	}
+
+	return nil
+}
+
+// subscribers returns the endpoints that receive events of the type.
+func (s *WebhookService) subscribers(eventType string) []string {
+	var names []string
+	for name, endpoint := range s.endpoints {
+		if len(endpoint.Events) == 0 || contains(endpoint.Events, eventType) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// backoff doubles the delay after each attempt up to maxBackoff.
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	delay := s.baseBackoff
+	for i := 1; i < attempts && delay < s.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.maxBackoff {
+		delay = s.maxBackoff
+	}
+	return delay
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
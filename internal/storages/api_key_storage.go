@@ -0,0 +1,213 @@
+package storages
+
+import (
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/apikey"
+	"auth/internal/services/notification"
+)
+
+type APIKeyStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewAPIKeyStorage(db *sqlx.DB) *APIKeyStorage {
+	return &APIKeyStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+const apiKeyColumns = "id, prefix, secret_hash, name, user_id, client_id, scopes, expires_at, last_used_at, " +
+	"revoked_at, created_at"
+
+func (s *APIKeyStorage) Create(key *apikey.APIKey) error {
+	builder := s.builder.
+		Insert("api_keys").
+		Columns("prefix, secret_hash, name, user_id, client_id, scopes, expires_at").
+		Values(key.Prefix, key.SecretHash, key.Name, uuid.NullUUID{UUID: key.UserID, Valid: key.UserID != uuid.Nil},
+			sql.NullString{String: key.ClientID, Valid: key.ClientID != ""}, pq.Array(key.Scopes), key.ExpiresAt).
+		Suffix("RETURNING id, created_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	err = s.db.QueryRow(query, args...).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *APIKeyStorage) Get(id uuid.UUID) (*apikey.APIKey, error) {
+	return s.get(sq.Eq{"id": id})
+}
+
+func (s *APIKeyStorage) GetByPrefix(prefix string) (*apikey.APIKey, error) {
+	return s.get(sq.Eq{"prefix": prefix})
+}
+
+func (s *APIKeyStorage) get(where sq.Eq) (*apikey.APIKey, error) {
+	builder := s.builder.
+		Select(apiKeyColumns).
+		From("api_keys").
+		Where(where)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	key, err := scanAPIKey(s.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apikey.NewNotFoundError("api key not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+
+	return key, nil
+}
+
+func (s *APIKeyStorage) List(filter apikey.Filter) ([]apikey.APIKey, error) {
+	builder := s.builder.
+		Select(apiKeyColumns).
+		From("api_keys").
+		OrderBy("created_at DESC")
+	if filter.UserID != uuid.Nil {
+		builder = builder.Where(sq.Eq{"user_id": filter.UserID})
+	}
+	if filter.ClientID != "" {
+		builder = builder.Where(sq.Eq{"client_id": filter.ClientID})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var keys []apikey.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return keys, nil
+}
+
+func (s *APIKeyStorage) Revoke(id uuid.UUID) error {
+	builder := s.builder.
+		Update("api_keys").
+		Set("revoked_at", time.Now()).
+		Where(sq.Eq{"id": id, "revoked_at": nil})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+	if affected == 0 {
+		return apikey.NewNotFoundError("api key not found")
+	}
+
+	return nil
+}
+
+// RevokeByUser revokes the keys of the user that are not revoked yet and
+// returns their number.
+func (s *APIKeyStorage) RevokeByUser(userID uuid.UUID) (int64, error) {
+	builder := s.builder.
+		Update("api_keys").
+		Set("revoked_at", time.Now()).
+		Where(sq.Eq{"user_id": userID, "revoked_at": nil})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "build query")
+	}
+
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "execute query")
+	}
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "get affected rows")
+	}
+
+	return revoked, nil
+}
+
+func (s *APIKeyStorage) MarkUsed(id uuid.UUID, usedAt time.Time) error {
+	builder := s.builder.
+		Update("api_keys").
+		Set("last_used_at", usedAt).
+		Where(sq.Eq{"id": id})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (*apikey.APIKey, error) {
+	var key apikey.APIKey
+	var userID uuid.NullUUID
+	var clientID sql.NullString
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(
+		&key.ID, &key.Prefix, &key.SecretHash, &key.Name, &userID, &clientID, pq.Array(&key.Scopes),
+		&key.ExpiresAt, &lastUsedAt, &revokedAt, &key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	key.UserID = userID.UUID
+	key.ClientID = clientID.String
+	key.LastUsedAt = lastUsedAt.Time
+	key.RevokedAt = revokedAt.Time
+
+	return &key, nil
+}
+
+var (
+	_ apikey.Storage             = &APIKeyStorage{}
+	_ notification.APIKeyStorage = &APIKeyStorage{}
+)
@@ -0,0 +1,127 @@
+package storages
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/audit"
+)
+
+// auditChainLock is the advisory lock that serializes appends to the chain.
+const auditChainLock = 7061736
+
+type AuditStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewAuditStorage(db *sqlx.DB) *AuditStorage {
+	return &AuditStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (s *AuditStorage) Append(event *audit.Event) error {
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return errors.Wrap(err, "marshal details")
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", auditChainLock); err != nil {
+		return errors.Wrap(err, "lock chain")
+	}
+
+	prevHash := []byte{}
+	err = tx.QueryRow("SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1").Scan(&prevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return errors.Wrap(err, "select last hash")
+	}
+	event.PrevHash = prevHash
+	event.Hash = event.ComputeHash(prevHash)
+
+	builder := s.builder.
+		Insert("audit_events").
+		Columns("type, user_id, ip, details, created_at, prev_hash, hash").
+		Values(event.Type, event.UserID, event.IP, string(details), event.CreatedAt, event.PrevHash, event.Hash).
+		Suffix("RETURNING id")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	if err := tx.QueryRow(query, args...).Scan(&event.ID); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "commit transaction")
+	}
+
+	return nil
+}
+
+func (s *AuditStorage) List(filter audit.Filter) ([]audit.Event, error) {
+	builder := s.builder.
+		Select("id, type, user_id, ip, details, created_at, prev_hash, hash").
+		From("audit_events").
+		Where(sq.Gt{"id": filter.AfterID}).
+		OrderBy("id").
+		Limit(uint64(filter.Limit))
+	if filter.UserID != uuid.Nil {
+		builder = builder.Where(sq.Eq{"user_id": filter.UserID})
+	}
+	if len(filter.Types) > 0 {
+		builder = builder.Where(sq.Eq{"type": filter.Types})
+	}
+	if !filter.From.IsZero() {
+		builder = builder.Where(sq.GtOrEq{"created_at": filter.From})
+	}
+	if !filter.To.IsZero() {
+		builder = builder.Where(sq.Lt{"created_at": filter.To})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var events []audit.Event
+	for rows.Next() {
+		var event audit.Event
+		var details []byte
+		err := rows.Scan(&event.ID, &event.Type, &event.UserID, &event.IP, &details, &event.CreatedAt,
+			&event.PrevHash, &event.Hash)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		if err := json.Unmarshal(details, &event.Details); err != nil {
+			return nil, errors.Wrap(err, "unmarshal details")
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return events, nil
+}
+
+var _ audit.Storage = &AuditStorage{}
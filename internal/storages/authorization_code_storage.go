@@ -0,0 +1,76 @@
+package storages
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/oauth"
+)
+
+type AuthorizationCodeStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewAuthorizationCodeStorage(db *sqlx.DB) *AuthorizationCodeStorage {
+	return &AuthorizationCodeStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (s *AuthorizationCodeStorage) Create(code *oauth.AuthorizationCode) error {
+	builder := s.builder.
+		Insert("authorization_codes").
+		Columns("code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, code_challenge_method, "+
+			"nonce, auth_time, amr, expires_at").
+		Values(code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, pq.Array(code.Scopes),
+			code.CodeChallenge, code.CodeChallengeMethod, code.Nonce, code.AuthTime, pq.Array(code.AuthMethods),
+			code.ExpiresAt)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	_, err = s.db.Exec(query, args...)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *AuthorizationCodeStorage) Consume(codeHash []byte) (*oauth.AuthorizationCode, error) {
+	builder := s.builder.
+		Delete("authorization_codes").
+		Where(sq.Eq{"code_hash": codeHash}).
+		Suffix("RETURNING code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, code_challenge_method, " +
+			"nonce, auth_time, amr, expires_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	var code oauth.AuthorizationCode
+	err = s.db.QueryRow(query, args...).Scan(
+		&code.CodeHash, &code.ClientID, &code.UserID, &code.RedirectURI, pq.Array(&code.Scopes),
+		&code.CodeChallenge, &code.CodeChallengeMethod, &code.Nonce, &code.AuthTime, pq.Array(&code.AuthMethods),
+		&code.ExpiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oauth.NewNotFoundError("authorization code not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+
+	return &code, nil
+}
+
+var _ oauth.AuthorizationCodeStorage = &AuthorizationCodeStorage{}
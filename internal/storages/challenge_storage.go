@@ -0,0 +1,136 @@
+package storages
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/auth"
+)
+
+type ChallengeStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewChallengeStorage(db *sqlx.DB) *ChallengeStorage {
+	return &ChallengeStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// Create stores the challenge. Expired challenges are removed first.
+func (s *ChallengeStorage) Create(challenge *auth.Challenge) error {
+	var session []byte
+	if challenge.Session != nil {
+		var err error
+		session, err = json.Marshal(challenge.Session)
+		if err != nil {
+			return errors.Wrap(err, "marshal session")
+		}
+	}
+
+	deleteBuilder := s.builder.
+		Delete("step_up_challenges").
+		Where(sq.Lt{"expires_at": time.Now()})
+
+	query, args, err := deleteBuilder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build delete query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "delete expired challenges")
+	}
+
+	insertBuilder := s.builder.
+		Insert("step_up_challenges").
+		Columns("id, user_id, refresh_token_id, session, session_id, assessment_id, code_hash, ip, attempts, "+
+			"expires_at, created_at").
+		Values(challenge.ID, challenge.UserID,
+			uuid.NullUUID{UUID: challenge.RefreshTokenID, Valid: challenge.RefreshTokenID != uuid.Nil},
+			sql.NullString{String: string(session), Valid: session != nil}, challenge.SessionID,
+			uuid.NullUUID{UUID: challenge.AssessmentID, Valid: challenge.AssessmentID != uuid.Nil},
+			challenge.CodeHash, challenge.IP, challenge.Attempts, challenge.ExpiresAt, challenge.CreatedAt)
+
+	query, args, err = insertBuilder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build insert query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+// UseAttempt counts the attempt in the update, so that concurrent attempts
+// cannot exceed maxAttempts.
+func (s *ChallengeStorage) UseAttempt(id uuid.UUID, maxAttempts int, now time.Time) (*auth.Challenge, error) {
+	builder := s.builder.
+		Update("step_up_challenges").
+		Set("attempts", sq.Expr("attempts + 1")).
+		Where(sq.Eq{"id": id}).
+		Where(sq.Lt{"attempts": maxAttempts}).
+		Where(sq.Gt{"expires_at": now}).
+		Suffix("RETURNING id, user_id, refresh_token_id, session, session_id, assessment_id, code_hash, ip, " +
+			"attempts, expires_at, created_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	var challenge auth.Challenge
+	var refreshTokenID, assessmentID uuid.NullUUID
+	var session []byte
+	err = s.db.QueryRow(query, args...).Scan(&challenge.ID, &challenge.UserID, &refreshTokenID, &session,
+		&challenge.SessionID, &assessmentID, &challenge.CodeHash, &challenge.IP, &challenge.Attempts,
+		&challenge.ExpiresAt, &challenge.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.NewNotFoundError("challenge not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	challenge.RefreshTokenID = refreshTokenID.UUID
+	challenge.AssessmentID = assessmentID.UUID
+	if session != nil {
+		if err := json.Unmarshal(session, &challenge.Session); err != nil {
+			return nil, errors.Wrap(err, "unmarshal session")
+		}
+	}
+
+	return &challenge, nil
+}
+
+func (s *ChallengeStorage) Delete(id uuid.UUID) error {
+	builder := s.builder.
+		Delete("step_up_challenges").
+		Where(sq.Eq{"id": id})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+	if affected == 0 {
+		return auth.NewNotFoundError("challenge not found")
+	}
+
+	return nil
+}
+
+var _ auth.ChallengeStorage = &ChallengeStorage{}
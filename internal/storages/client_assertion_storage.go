@@ -0,0 +1,63 @@
+package storages
+
+import (
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/oauth"
+)
+
+type ClientAssertionStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewClientAssertionStorage(db *sqlx.DB) *ClientAssertionStorage {
+	return &ClientAssertionStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// MarkUsed records the assertion ID. Expired IDs of the client are removed
+// first, assertions are short-lived so the table stays small.
+func (s *ClientAssertionStorage) MarkUsed(clientID, jti string, expiresAt time.Time) (bool, error) {
+	deleteBuilder := s.builder.
+		Delete("client_assertions").
+		Where(sq.Eq{"client_id": clientID}).
+		Where(sq.Lt{"expires_at": time.Now()})
+
+	query, args, err := deleteBuilder.ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "build delete query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return false, errors.Wrap(err, "delete expired assertions")
+	}
+
+	insertBuilder := s.builder.
+		Insert("client_assertions").
+		Columns("client_id, jti, expires_at").
+		Values(clientID, jti, expiresAt).
+		Suffix("ON CONFLICT DO NOTHING")
+
+	query, args, err = insertBuilder.ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "build insert query")
+	}
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "execute query")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "get affected rows")
+	}
+
+	return affected == 1, nil
+}
+
+var _ oauth.ClientAssertionStorage = &ClientAssertionStorage{}
@@ -0,0 +1,208 @@
+package storages
+
+import (
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/oauth"
+)
+
+type DeviceCodeStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewDeviceCodeStorage(db *sqlx.DB) *DeviceCodeStorage {
+	return &DeviceCodeStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+const deviceCodeColumns = "device_code_hash, user_code, client_id, scopes, status, user_id, auth_time, amr, " +
+	"poll_interval, last_polled_at, expires_at"
+
+// Create stores the code. Expired codes are removed first, so that their user
+// codes can be issued again.
+func (s *DeviceCodeStorage) Create(code *oauth.DeviceCode) error {
+	deleteBuilder := s.builder.
+		Delete("device_codes").
+		Where(sq.Lt{"expires_at": time.Now()})
+
+	query, args, err := deleteBuilder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build delete query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "delete expired codes")
+	}
+
+	insertBuilder := s.builder.
+		Insert("device_codes").
+		Columns("device_code_hash, user_code, client_id, scopes, status, poll_interval, expires_at").
+		Values(code.DeviceCodeHash, code.UserCode, code.ClientID, pq.Array(code.Scopes), code.Status,
+			int(code.PollInterval.Seconds()), code.ExpiresAt)
+
+	query, args, err = insertBuilder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build insert query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *DeviceCodeStorage) GetByUserCode(userCode string) (*oauth.DeviceCode, error) {
+	builder := s.builder.
+		Select(deviceCodeColumns).
+		From("device_codes").
+		Where(sq.Eq{"user_code": userCode})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	code, err := scanDeviceCode(s.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oauth.NewNotFoundError("device code not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+
+	return code, nil
+}
+
+func (s *DeviceCodeStorage) Decide(code *oauth.DeviceCode) error {
+	builder := s.builder.
+		Update("device_codes").
+		Set("status", code.Status).
+		Set("user_id", code.UserID).
+		Set("auth_time", code.AuthTime).
+		Set("amr", pq.Array(code.AuthMethods)).
+		Where(sq.Eq{"user_code": code.UserCode, "status": oauth.DeviceCodeStatusPending}).
+		Where(sq.Gt{"expires_at": time.Now()})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+	if affected == 0 {
+		return oauth.NewNotFoundError("device code not found")
+	}
+
+	return nil
+}
+
+// Poll locks the row to read the previous poll time, so that concurrent polls
+// are seen one after the other.
+func (s *DeviceCodeStorage) Poll(deviceCodeHash []byte, polledAt time.Time) (*oauth.DeviceCode, error) {
+	previous := s.builder.
+		Select("device_code_hash, last_polled_at").
+		From("device_codes").
+		Where(sq.Eq{"device_code_hash": deviceCodeHash}).
+		Suffix("FOR UPDATE")
+
+	builder := s.builder.
+		Update("device_codes d").
+		Set("last_polled_at", polledAt).
+		FromSelect(previous, "previous").
+		Where("d.device_code_hash = previous.device_code_hash").
+		Suffix("RETURNING d.device_code_hash, d.user_code, d.client_id, d.scopes, d.status, d.user_id, " +
+			"d.auth_time, d.amr, d.poll_interval, previous.last_polled_at, d.expires_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	code, err := scanDeviceCode(s.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oauth.NewNotFoundError("device code not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+
+	return code, nil
+}
+
+func (s *DeviceCodeStorage) UpdatePollInterval(deviceCodeHash []byte, interval time.Duration) error {
+	builder := s.builder.
+		Update("device_codes").
+		Set("poll_interval", int(interval.Seconds())).
+		Where(sq.Eq{"device_code_hash": deviceCodeHash})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *DeviceCodeStorage) Consume(deviceCodeHash []byte) (*oauth.DeviceCode, error) {
+	builder := s.builder.
+		Delete("device_codes").
+		Where(sq.Eq{"device_code_hash": deviceCodeHash, "status": oauth.DeviceCodeStatusApproved}).
+		Suffix("RETURNING " + deviceCodeColumns)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	code, err := scanDeviceCode(s.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oauth.NewNotFoundError("device code not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+
+	return code, nil
+}
+
+func scanDeviceCode(row rowScanner) (*oauth.DeviceCode, error) {
+	var code oauth.DeviceCode
+	var userID uuid.NullUUID
+	var authTime, lastPolledAt sql.NullTime
+	var pollInterval int
+	err := row.Scan(
+		&code.DeviceCodeHash, &code.UserCode, &code.ClientID, pq.Array(&code.Scopes), &code.Status, &userID,
+		&authTime, pq.Array(&code.AuthMethods), &pollInterval, &lastPolledAt, &code.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	code.UserID = userID.UUID
+	code.AuthTime = authTime.Time
+	code.LastPolledAt = lastPolledAt.Time
+	code.PollInterval = time.Duration(pollInterval) * time.Second
+
+	return &code, nil
+}
+
+var _ oauth.DeviceCodeStorage = &DeviceCodeStorage{}
@@ -0,0 +1,165 @@
+package storages
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/email"
+)
+
+type EmailQueueStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewEmailQueueStorage(db *sqlx.DB) *EmailQueueStorage {
+	return &EmailQueueStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+const emailMessageColumns = "m.id, m.sender, m.user_id, m.template, m.accept_language, m.data, m.status, " +
+	"m.attempts, m.next_attempt_at, m.last_error, m.sent_at, m.created_at"
+
+func (s *EmailQueueStorage) Enqueue(message *email.Message) error {
+	data, err := marshalEmailData(message.Email.Data)
+	if err != nil {
+		return err
+	}
+	builder := s.builder.
+		Insert("email_messages").
+		Columns("id, sender, user_id, template, accept_language, data, status, next_attempt_at, created_at").
+		Values(message.ID, message.From, message.UserID, message.Email.Template, message.Email.AcceptLanguage,
+			data, message.Status, message.NextAttemptAt, message.CreatedAt)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *EmailQueueStorage) Claim(now time.Time, limit int, lease time.Duration) ([]email.Message, error) {
+	due := s.builder.
+		Select("id").
+		From("email_messages").
+		Where(sq.Eq{"status": email.StatusPending}).
+		Where(sq.LtOrEq{"next_attempt_at": now}).
+		OrderBy("next_attempt_at").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	builder := s.builder.
+		Update("email_messages m").
+		Set("next_attempt_at", now.Add(lease)).
+		FromSelect(due, "due").
+		Where("m.id = due.id").
+		Suffix("RETURNING " + emailMessageColumns)
+
+	return s.list(builder)
+}
+
+func (s *EmailQueueStorage) Update(message *email.Message) error {
+	data, err := marshalEmailData(message.Email.Data)
+	if err != nil {
+		return err
+	}
+	builder := s.builder.
+		Update("email_messages").
+		Set("data", data).
+		Set("status", message.Status).
+		Set("attempts", message.Attempts).
+		Set("next_attempt_at", message.NextAttemptAt).
+		Set("last_error", message.LastError).
+		Set("sent_at", sql.NullTime{Time: message.SentAt, Valid: !message.SentAt.IsZero()}).
+		Where(sq.Eq{"id": message.ID})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *EmailQueueStorage) List(filter email.Filter) ([]email.Message, error) {
+	builder := s.builder.
+		Select(emailMessageColumns).
+		From("email_messages m").
+		OrderBy("m.created_at DESC").
+		Limit(uint64(filter.Limit))
+	if filter.Status != "" {
+		builder = builder.Where(sq.Eq{"m.status": filter.Status})
+	}
+	if filter.UserID != uuid.Nil {
+		builder = builder.Where(sq.Eq{"m.user_id": filter.UserID})
+	}
+
+	return s.list(builder)
+}
+
+func (s *EmailQueueStorage) list(builder sq.Sqlizer) ([]email.Message, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var messages []email.Message
+	for rows.Next() {
+		var message email.Message
+		var data []byte
+		var sentAt sql.NullTime
+		err := rows.Scan(&message.ID, &message.From, &message.UserID, &message.Email.Template,
+			&message.Email.AcceptLanguage, &data, &message.Status, &message.Attempts, &message.NextAttemptAt,
+			&message.LastError, &sentAt, &message.CreatedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		if data != nil {
+			if err := json.Unmarshal(data, &message.Email.Data); err != nil {
+				return nil, errors.Wrap(err, "unmarshal data")
+			}
+		}
+		message.SentAt = sentAt.Time
+		messages = append(messages, message)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return messages, nil
+}
+
+// marshalEmailData returns the template data as JSON, nil for no data.
+func marshalEmailData(data map[string]string) (any, error) {
+	if data == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal data")
+	}
+	return string(b), nil
+}
+
+var _ email.QueueStorage = &EmailQueueStorage{}
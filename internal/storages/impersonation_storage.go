@@ -0,0 +1,81 @@
+package storages
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/impersonation"
+)
+
+type ImpersonationStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewImpersonationStorage(db *sqlx.DB) *ImpersonationStorage {
+	return &ImpersonationStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (s *ImpersonationStorage) Create(record *impersonation.Impersonation) error {
+	builder := s.builder.
+		Insert("impersonations").
+		Columns("admin_id, user_id, reason, admin_ip, expires_at").
+		Values(record.AdminID, record.UserID, record.Reason, record.AdminIP, record.ExpiresAt).
+		Suffix("RETURNING id, created_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	err = s.db.QueryRow(query, args...).Scan(&record.ID, &record.CreatedAt)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *ImpersonationStorage) List(userID uuid.UUID) ([]impersonation.Impersonation, error) {
+	builder := s.builder.
+		Select("id, admin_id, user_id, reason, admin_ip, created_at, expires_at").
+		From("impersonations").
+		OrderBy("created_at DESC")
+	if userID != uuid.Nil {
+		builder = builder.Where(sq.Eq{"user_id": userID})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var records []impersonation.Impersonation
+	for rows.Next() {
+		var record impersonation.Impersonation
+		err := rows.Scan(&record.ID, &record.AdminID, &record.UserID, &record.Reason, &record.AdminIP,
+			&record.CreatedAt, &record.ExpiresAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return records, nil
+}
+
+var _ impersonation.Storage = &ImpersonationStorage{}
@@ -0,0 +1,188 @@
+package storages
+
+import (
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/lockout"
+)
+
+type LockoutStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewLockoutStorage(db *sqlx.DB) *LockoutStorage {
+	return &LockoutStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+const lockoutColumns = "user_id, failures, last_failure_at, locked_until"
+
+func (s *LockoutStorage) Get(userID uuid.UUID) (*lockout.Lockout, error) {
+	builder := s.builder.
+		Select(lockoutColumns).
+		From("account_lockouts").
+		Where(sq.Eq{"user_id": userID})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	record, err := scanLockout(s.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, lockout.NewNotFoundError("lockout not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+
+	return record, nil
+}
+
+func (s *LockoutStorage) RecordFailure(userID uuid.UUID, failedAt time.Time) (*lockout.Lockout, error) {
+	builder := s.builder.
+		Insert("account_lockouts").
+		Columns("user_id, failures, last_failure_at").
+		Values(userID, 1, failedAt).
+		Suffix(`ON CONFLICT (user_id) DO UPDATE SET
+			failures = CASE WHEN account_lockouts.locked_until <= excluded.last_failure_at
+				THEN 1 ELSE account_lockouts.failures + 1 END,
+			locked_until = CASE WHEN account_lockouts.locked_until <= excluded.last_failure_at
+				THEN NULL ELSE account_lockouts.locked_until END,
+			unlock_token_hash = CASE WHEN account_lockouts.locked_until <= excluded.last_failure_at
+				THEN NULL ELSE account_lockouts.unlock_token_hash END,
+			last_failure_at = excluded.last_failure_at
+			RETURNING ` + lockoutColumns)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	record, err := scanLockout(s.db.QueryRow(query, args...))
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+
+	return record, nil
+}
+
+// Lock only locks accounts that are not locked yet, so that concurrent
+// failures at the threshold do not extend the lock.
+func (s *LockoutStorage) Lock(userID uuid.UUID, lockedUntil time.Time, unlockTokenHash []byte) error {
+	builder := s.builder.
+		Update("account_lockouts").
+		Set("locked_until", lockedUntil).
+		Set("unlock_token_hash", unlockTokenHash).
+		Where(sq.Eq{"user_id": userID, "locked_until": nil})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *LockoutStorage) Delete(userID uuid.UUID) error {
+	builder := s.builder.
+		Delete("account_lockouts").
+		Where(sq.Eq{"user_id": userID})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *LockoutStorage) DeleteByUnlockToken(unlockTokenHash []byte, now time.Time) error {
+	builder := s.builder.
+		Delete("account_lockouts").
+		Where(sq.Eq{"unlock_token_hash": unlockTokenHash}).
+		Where(sq.Gt{"locked_until": now})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+	if affected == 0 {
+		return lockout.NewNotFoundError("unlock token not found")
+	}
+
+	return nil
+}
+
+func (s *LockoutStorage) ListLocked(now time.Time) ([]lockout.Lockout, error) {
+	builder := s.builder.
+		Select(lockoutColumns).
+		From("account_lockouts").
+		Where(sq.Gt{"locked_until": now}).
+		OrderBy("locked_until DESC")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var lockouts []lockout.Lockout
+	for rows.Next() {
+		record, err := scanLockout(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		lockouts = append(lockouts, *record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return lockouts, nil
+}
+
+func scanLockout(row rowScanner) (*lockout.Lockout, error) {
+	var record lockout.Lockout
+	var lockedUntil sql.NullTime
+	err := row.Scan(&record.UserID, &record.Failures, &record.LastFailureAt, &lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	record.LockedUntil = lockedUntil.Time
+
+	return &record, nil
+}
+
+var _ lockout.Storage = &LockoutStorage{}
@@ -0,0 +1,329 @@
+package storages
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/notification"
+)
+
+type NotificationStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewNotificationStorage(db *sqlx.DB) *NotificationStorage {
+	return &NotificationStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+const notificationAlertColumns = "id, user_id, type, accept_language, data, created_at"
+
+func (s *NotificationStorage) GetPreferences(userID uuid.UUID) (*notification.Preferences, error) {
+	builder := s.builder.
+		Select("user_id, disabled_alerts, digest, updated_at").
+		From("notification_preferences").
+		Where(sq.Eq{"user_id": userID})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	var preferences notification.Preferences
+	err = s.db.QueryRow(query, args...).Scan(&preferences.UserID, pq.Array(&preferences.DisabledAlerts),
+		&preferences.Digest, &preferences.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notification.NewNotFoundError("preferences not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+
+	return &preferences, nil
+}
+
+func (s *NotificationStorage) SavePreferences(preferences *notification.Preferences) error {
+	builder := s.builder.
+		Insert("notification_preferences").
+		Columns("user_id, disabled_alerts, digest, updated_at").
+		Values(preferences.UserID, pq.Array(preferences.DisabledAlerts), preferences.Digest, preferences.UpdatedAt).
+		Suffix(`ON CONFLICT (user_id) DO UPDATE SET
+			disabled_alerts = excluded.disabled_alerts,
+			digest = excluded.digest,
+			updated_at = excluded.updated_at`)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+// AddKnownNetwork inserts the network first, so that only one of concurrent
+// sign-ins from a new network finds it new.
+func (s *NotificationStorage) AddKnownNetwork(userID uuid.UUID, network string, seenAt time.Time) (bool, error) {
+	insert := s.builder.
+		Insert("known_networks").
+		Columns("user_id, network, first_seen_at, last_seen_at").
+		Values(userID, network, seenAt, seenAt).
+		Suffix("ON CONFLICT (user_id, network) DO NOTHING")
+
+	query, args, err := insert.ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "build query")
+	}
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "execute query")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "get affected rows")
+	}
+	if affected > 0 {
+		return true, nil
+	}
+
+	update := s.builder.
+		Update("known_networks").
+		Set("last_seen_at", seenAt).
+		Where(sq.Eq{"user_id": userID, "network": network})
+
+	query, args, err = update.ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "build query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return false, errors.Wrap(err, "execute query")
+	}
+
+	return false, nil
+}
+
+func (s *NotificationStorage) IsKnownNetwork(userID uuid.UUID, network string) (bool, error) {
+	builder := s.builder.
+		Select("1").
+		From("known_networks").
+		Where(sq.Eq{"user_id": userID, "network": network})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "build query")
+	}
+
+	var one int
+	err = s.db.QueryRow(query, args...).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "execute query")
+	}
+
+	return true, nil
+}
+
+func (s *NotificationStorage) ListKnownNetworks(userID uuid.UUID) ([]notification.KnownNetwork, error) {
+	builder := s.builder.
+		Select("user_id, network, first_seen_at, last_seen_at").
+		From("known_networks").
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("last_seen_at DESC")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	var networks []notification.KnownNetwork
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var network notification.KnownNetwork
+		err := rows.Scan(&network.UserID, &network.Network, &network.FirstSeenAt, &network.LastSeenAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		networks = append(networks, network)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return networks, nil
+}
+
+func (s *NotificationStorage) DeleteKnownNetwork(userID uuid.UUID, network string) error {
+	builder := s.builder.
+		Delete("known_networks").
+		Where(sq.Eq{"user_id": userID, "network": network})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+	if affected == 0 {
+		return notification.NewNotFoundError("network not found")
+	}
+
+	return nil
+}
+
+func (s *NotificationStorage) AddAlert(alert *notification.Alert) error {
+	data, err := json.Marshal(alert.Data)
+	if err != nil {
+		return errors.Wrap(err, "marshal data")
+	}
+	builder := s.builder.
+		Insert("notification_alerts").
+		Columns(notificationAlertColumns).
+		Values(alert.ID, alert.UserID, alert.Type, alert.AcceptLanguage, string(data), alert.CreatedAt)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+// ClaimDigests deletes the alerts it returns, so that concurrent instances
+// do not send the same digest.
+func (s *NotificationStorage) ClaimDigests(dueBefore time.Time) ([]notification.Alert, error) {
+	due := s.builder.
+		Select("user_id").
+		From("notification_alerts").
+		GroupBy("user_id").
+		Having(sq.LtOrEq{"min(created_at)": dueBefore})
+
+	builder := s.builder.
+		Delete("notification_alerts").
+		Where(sq.Expr("user_id IN (?)", due)).
+		Suffix("RETURNING " + notificationAlertColumns)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var alerts []notification.Alert
+	for rows.Next() {
+		var alert notification.Alert
+		var data []byte
+		err := rows.Scan(&alert.ID, &alert.UserID, &alert.Type, &alert.AcceptLanguage, &data, &alert.CreatedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		if err := json.Unmarshal(data, &alert.Data); err != nil {
+			return nil, errors.Wrap(err, "unmarshal data")
+		}
+		alerts = append(alerts, alert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return alerts, nil
+}
+
+func (s *NotificationStorage) CreateAction(action *notification.Action) error {
+	builder := s.builder.
+		Insert("security_actions").
+		Columns("id, user_id, session_id, ip, created_at, expires_at").
+		Values(action.ID, action.UserID, uuid.NullUUID{UUID: action.SessionID, Valid: action.SessionID != uuid.Nil},
+			action.IP, action.CreatedAt, action.ExpiresAt)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *NotificationStorage) GetAction(id uuid.UUID, now time.Time) (*notification.Action, error) {
+	builder := s.builder.
+		Select(securityActionColumns).
+		From("security_actions").
+		Where(sq.Eq{"id": id, "used_at": nil}).
+		Where(sq.Gt{"expires_at": now})
+
+	return s.scanAction(builder)
+}
+
+// UseAction only updates unused actions, so that concurrent requests use an
+// action once.
+func (s *NotificationStorage) UseAction(id uuid.UUID, now time.Time) (*notification.Action, error) {
+	builder := s.builder.
+		Update("security_actions").
+		Set("used_at", now).
+		Where(sq.Eq{"id": id, "used_at": nil}).
+		Where(sq.Gt{"expires_at": now}).
+		Suffix("RETURNING " + securityActionColumns)
+
+	return s.scanAction(builder)
+}
+
+const securityActionColumns = "id, user_id, session_id, ip, created_at, expires_at, used_at"
+
+func (s *NotificationStorage) scanAction(builder sq.Sqlizer) (*notification.Action, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	var action notification.Action
+	var sessionID uuid.NullUUID
+	var usedAt sql.NullTime
+	err = s.db.QueryRow(query, args...).Scan(&action.ID, &action.UserID, &sessionID, &action.IP,
+		&action.CreatedAt, &action.ExpiresAt, &usedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notification.NewNotFoundError("action not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	action.SessionID = sessionID.UUID
+	action.UsedAt = usedAt.Time
+
+	return &action, nil
+}
+
+var _ notification.Storage = &NotificationStorage{}
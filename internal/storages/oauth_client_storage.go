@@ -0,0 +1,167 @@
+package storages
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/oauth"
+)
+
+type OAuthClientStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewOAuthClientStorage(db *sqlx.DB) *OAuthClientStorage {
+	return &OAuthClientStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+const oauthClientColumns = "id, name, redirect_uris, scopes, grant_types, token_endpoint_auth_method, secret_hash, jwks, " +
+	"exchange_audiences, created_at"
+
+func (s *OAuthClientStorage) Create(client *oauth.Client) error {
+	// lib/pq encodes []byte as bytea, so JSON is passed as a string
+	var jwks sql.NullString
+	if len(client.Keys) > 0 {
+		keys, err := json.Marshal(client.Keys)
+		if err != nil {
+			return errors.Wrap(err, "marshal keys")
+		}
+		jwks = sql.NullString{String: string(keys), Valid: true}
+	}
+
+	builder := s.builder.
+		Insert("oauth_clients").
+		Columns("id, name, redirect_uris, scopes, grant_types, token_endpoint_auth_method, secret_hash, jwks, "+
+			"exchange_audiences").
+		Values(client.ID, client.Name, pq.Array(client.RedirectURIs), pq.Array(client.Scopes),
+			pq.Array(client.GrantTypes), client.TokenEndpointAuthMethod, client.SecretHash, jwks,
+			pq.Array(client.ExchangeAudiences)).
+		Suffix("RETURNING created_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	err = s.db.QueryRow(query, args...).Scan(&client.CreatedAt)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *OAuthClientStorage) Get(id string) (*oauth.Client, error) {
+	builder := s.builder.
+		Select(oauthClientColumns).
+		From("oauth_clients").
+		Where(sq.Eq{"id": id})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	client, err := scanOAuthClient(s.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oauth.NewNotFoundError(fmt.Sprintf("client %s not found", id))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+
+	return client, nil
+}
+
+func (s *OAuthClientStorage) List() ([]oauth.Client, error) {
+	builder := s.builder.
+		Select(oauthClientColumns).
+		From("oauth_clients").
+		OrderBy("created_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var clients []oauth.Client
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		clients = append(clients, *client)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return clients, nil
+}
+
+func (s *OAuthClientStorage) Delete(id string) error {
+	builder := s.builder.
+		Delete("oauth_clients").
+		Where(sq.Eq{"id": id})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+	if affected == 0 {
+		return oauth.NewNotFoundError(fmt.Sprintf("client %s not found", id))
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOAuthClient(row rowScanner) (*oauth.Client, error) {
+	var client oauth.Client
+	var jwks []byte
+	err := row.Scan(
+		&client.ID, &client.Name, pq.Array(&client.RedirectURIs), pq.Array(&client.Scopes),
+		pq.Array(&client.GrantTypes), &client.TokenEndpointAuthMethod, &client.SecretHash, &jwks,
+		pq.Array(&client.ExchangeAudiences), &client.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if jwks != nil {
+		if err := json.Unmarshal(jwks, &client.Keys); err != nil {
+			return nil, errors.Wrap(err, "unmarshal keys")
+		}
+	}
+
+	return &client, nil
+}
+
+var _ oauth.ClientStorage = &OAuthClientStorage{}
@@ -0,0 +1,128 @@
+package storages
+
+import (
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/ratelimit"
+)
+
+// rateLimitPruneInterval is how often buckets that are full again are
+// deleted.
+const rateLimitPruneInterval = time.Minute
+
+// RateLimitStorage shares rate limit buckets between the instances of a
+// cluster.
+type RateLimitStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+func NewRateLimitStorage(db *sqlx.DB) *RateLimitStorage {
+	return &RateLimitStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// Take locks the row of the key, so that concurrent requests of the instances
+// are counted one after the other. Missing rows are created full first, as
+// there is no row to lock otherwise.
+func (s *RateLimitStorage) Take(key string, limit ratelimit.Limit, now time.Time) (time.Duration, error) {
+	if err := s.prune(now); err != nil {
+		return 0, errors.Wrap(err, "prune buckets")
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	insertBuilder := s.builder.
+		Insert("rate_limit_buckets").
+		Columns("key, tokens, updated_at, full_at").
+		Values(key, limit.Burst, now, now).
+		Suffix("ON CONFLICT (key) DO NOTHING")
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "build insert query")
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return 0, errors.Wrap(err, "insert bucket")
+	}
+
+	selectBuilder := s.builder.
+		Select("tokens, updated_at").
+		From("rate_limit_buckets").
+		Where(sq.Eq{"key": key}).
+		Suffix("FOR UPDATE")
+
+	query, args, err = selectBuilder.ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "build select query")
+	}
+	var bucket ratelimit.Bucket
+	if err := tx.QueryRow(query, args...).Scan(&bucket.Tokens, &bucket.UpdatedAt); err != nil {
+		return 0, errors.Wrap(err, "select bucket")
+	}
+
+	retryAfter := limit.Take(&bucket, now)
+
+	updateBuilder := s.builder.
+		Update("rate_limit_buckets").
+		Set("tokens", bucket.Tokens).
+		Set("updated_at", bucket.UpdatedAt).
+		Set("full_at", limit.FullAt(bucket)).
+		Where(sq.Eq{"key": key})
+
+	query, args, err = updateBuilder.ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "build update query")
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return 0, errors.Wrap(err, "update bucket")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "commit transaction")
+	}
+
+	return retryAfter, nil
+}
+
+// prune deletes the buckets that are full again, at most once per interval
+// and instance.
+func (s *RateLimitStorage) prune(now time.Time) error {
+	s.mu.Lock()
+	if now.Sub(s.lastPruned) < rateLimitPruneInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastPruned = now
+	s.mu.Unlock()
+
+	builder := s.builder.
+		Delete("rate_limit_buckets").
+		Where(sq.LtOrEq{"full_at": now})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+var _ ratelimit.Store = &RateLimitStorage{}
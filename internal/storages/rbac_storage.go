@@ -0,0 +1,398 @@
+package storages
+
+import (
+	"database/sql"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/auth"
+	"auth/internal/services/rbac"
+)
+
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+)
+
+type RBACStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRBACStorage(db *sqlx.DB) *RBACStorage {
+	return &RBACStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (s *RBACStorage) CreateRole(role *rbac.Role) error {
+	builder := s.builder.
+		Insert("roles").
+		Columns("name, description").
+		Values(role.Name, role.Description).
+		Suffix("RETURNING created_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	err = s.db.QueryRow(query, args...).Scan(&role.CreatedAt)
+	if isPQError(err, pqUniqueViolation) {
+		return rbac.NewAlreadyExistsError(fmt.Sprintf("role %s already exists", role.Name))
+	}
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *RBACStorage) GetRole(name string) (*rbac.Role, error) {
+	builder := s.builder.
+		Select("name, description, created_at").
+		From("roles").
+		Where(sq.Eq{"name": name})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	var role rbac.Role
+	err = s.db.QueryRow(query, args...).Scan(&role.Name, &role.Description, &role.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, rbac.NewNotFoundError(fmt.Sprintf("role %s not found", name))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+
+	permissions, err := s.listRolePermissions(sq.Eq{"role_name": name})
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = permissions[name]
+
+	return &role, nil
+}
+
+func (s *RBACStorage) ListRoles() ([]rbac.Role, error) {
+	builder := s.builder.
+		Select("name, description, created_at").
+		From("roles").
+		OrderBy("name")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var roles []rbac.Role
+	for rows.Next() {
+		var role rbac.Role
+		if err := rows.Scan(&role.Name, &role.Description, &role.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	permissions, err := s.listRolePermissions(nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		roles[i].Permissions = permissions[roles[i].Name]
+	}
+
+	return roles, nil
+}
+
+func (s *RBACStorage) DeleteRole(name string) error {
+	return s.deleteOne(
+		s.builder.Delete("roles").Where(sq.Eq{"name": name}),
+		fmt.Sprintf("role %s not found", name),
+	)
+}
+
+func (s *RBACStorage) CreatePermission(permission *rbac.Permission) error {
+	builder := s.builder.
+		Insert("permissions").
+		Columns("name, description").
+		Values(permission.Name, permission.Description).
+		Suffix("RETURNING created_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	err = s.db.QueryRow(query, args...).Scan(&permission.CreatedAt)
+	if isPQError(err, pqUniqueViolation) {
+		return rbac.NewAlreadyExistsError(fmt.Sprintf("permission %s already exists", permission.Name))
+	}
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *RBACStorage) ListPermissions() ([]rbac.Permission, error) {
+	builder := s.builder.
+		Select("name, description, created_at").
+		From("permissions").
+		OrderBy("name")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var permissions []rbac.Permission
+	for rows.Next() {
+		var permission rbac.Permission
+		if err := rows.Scan(&permission.Name, &permission.Description, &permission.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		permissions = append(permissions, permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return permissions, nil
+}
+
+func (s *RBACStorage) DeletePermission(name string) error {
+	return s.deleteOne(
+		s.builder.Delete("permissions").Where(sq.Eq{"name": name}),
+		fmt.Sprintf("permission %s not found", name),
+	)
+}
+
+func (s *RBACStorage) AddRolePermission(roleName, permissionName string) error {
+	builder := s.builder.
+		Insert("role_permissions").
+		Columns("role_name, permission_name").
+		Values(roleName, permissionName).
+		Suffix("ON CONFLICT DO NOTHING")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	_, err = s.db.Exec(query, args...)
+	if isPQError(err, pqForeignKeyViolation) {
+		return rbac.NewNotFoundError(fmt.Sprintf("role %s or permission %s not found", roleName, permissionName))
+	}
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *RBACStorage) RemoveRolePermission(roleName, permissionName string) error {
+	return s.deleteOne(
+		s.builder.Delete("role_permissions").Where(sq.Eq{"role_name": roleName, "permission_name": permissionName}),
+		fmt.Sprintf("role %s has no permission %s", roleName, permissionName),
+	)
+}
+
+func (s *RBACStorage) AssignUserRole(userID uuid.UUID, roleName string) error {
+	builder := s.builder.
+		Insert("user_roles").
+		Columns("user_id, role_name").
+		Values(userID, roleName).
+		Suffix("ON CONFLICT DO NOTHING")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	_, err = s.db.Exec(query, args...)
+	if isPQError(err, pqForeignKeyViolation) {
+		return rbac.NewNotFoundError(fmt.Sprintf("role %s not found", roleName))
+	}
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *RBACStorage) UnassignUserRole(userID uuid.UUID, roleName string) error {
+	return s.deleteOne(
+		s.builder.Delete("user_roles").Where(sq.Eq{"user_id": userID, "role_name": roleName}),
+		fmt.Sprintf("user has no role %s", roleName),
+	)
+}
+
+func (s *RBACStorage) ListUserRoles(userID uuid.UUID) ([]rbac.UserRole, error) {
+	builder := s.builder.
+		Select("user_id, role_name, created_at").
+		From("user_roles").
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("role_name")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var userRoles []rbac.UserRole
+	for rows.Next() {
+		var userRole rbac.UserRole
+		if err := rows.Scan(&userRole.UserID, &userRole.RoleName, &userRole.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		userRoles = append(userRoles, userRole)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return userRoles, nil
+}
+
+// GetUserRolesAndPermissions returns the current roles of the user and the
+// union of their permissions, as put into access tokens.
+func (s *RBACStorage) GetUserRolesAndPermissions(userID uuid.UUID) ([]string, []string, error) {
+	builder := s.builder.
+		Select("ur.role_name, rp.permission_name").
+		From("user_roles ur").
+		LeftJoin("role_permissions rp ON rp.role_name = ur.role_name").
+		Where(sq.Eq{"ur.user_id": userID}).
+		OrderBy("ur.role_name, rp.permission_name")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var roles, permissions []string
+	seenRoles := make(map[string]bool)
+	seenPermissions := make(map[string]bool)
+	for rows.Next() {
+		var role string
+		var permission sql.NullString
+		if err := rows.Scan(&role, &permission); err != nil {
+			return nil, nil, errors.Wrap(err, "scan row")
+		}
+		if !seenRoles[role] {
+			seenRoles[role] = true
+			roles = append(roles, role)
+		}
+		if permission.Valid && !seenPermissions[permission.String] {
+			seenPermissions[permission.String] = true
+			permissions = append(permissions, permission.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return roles, permissions, nil
+}
+
+func (s *RBACStorage) listRolePermissions(where sq.Sqlizer) (map[string][]string, error) {
+	builder := s.builder.
+		Select("role_name, permission_name").
+		From("role_permissions").
+		OrderBy("role_name, permission_name")
+	if where != nil {
+		builder = builder.Where(where)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	permissions := make(map[string][]string)
+	for rows.Next() {
+		var role, permission string
+		if err := rows.Scan(&role, &permission); err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		permissions[role] = append(permissions[role], permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return permissions, nil
+}
+
+func (s *RBACStorage) deleteOne(builder sq.DeleteBuilder, notFoundMessage string) error {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+	if affected == 0 {
+		return rbac.NewNotFoundError(notFoundMessage)
+	}
+
+	return nil
+}
+
+func isPQError(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+var (
+	_ rbac.Storage     = &RBACStorage{}
+	_ auth.RoleStorage = &RBACStorage{}
+)
@@ -1,12 +1,19 @@
 package storages
 
 import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 
 	"auth/internal/services/auth"
+	"auth/internal/services/notification"
+	"auth/internal/services/webhook"
 )
 
 type RefreshTokenStorage struct {
@@ -21,11 +28,96 @@ func NewRefreshTokenStorage(db *sqlx.DB) *RefreshTokenStorage {
 	}
 }
 
-func (s *RefreshTokenStorage) Create(token *auth.RefreshToken) (uuid.UUID, error) {
+// Create stores the token and the outbox event, if any, in one transaction.
+func (s *RefreshTokenStorage) Create(token *auth.RefreshToken, event *webhook.Event) (uuid.UUID, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return uuid.UUID{}, errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	id, err := s.insert(tx, token)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	if event != nil {
+		if err := insertOutboxEvent(tx, s.builder, event); err != nil {
+			return uuid.UUID{}, errors.Wrap(err, "insert outbox event")
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return uuid.UUID{}, errors.Wrap(err, "commit transaction")
+	}
+
+	return id, nil
+}
+
+// Rotate replaces the token previousID with token and stores the outbox
+// event, if any, in one transaction. The delete locks the previous token, so
+// of two concurrent rotations of it only one succeeds, the other returns
+// auth.NotFoundError.
+func (s *RefreshTokenStorage) Rotate(previousID uuid.UUID, token *auth.RefreshToken, event *webhook.Event) (uuid.UUID, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return uuid.UUID{}, errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	builder := s.builder.
+		Delete("refresh_tokens").
+		Where(sq.Eq{"id": previousID})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return uuid.UUID{}, errors.Wrap(err, "build query")
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return uuid.UUID{}, errors.Wrap(err, "execute query")
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return uuid.UUID{}, errors.Wrap(err, "get affected rows")
+	}
+	if deleted == 0 {
+		return uuid.UUID{}, auth.NewNotFoundError("refresh token not found")
+	}
+
+	id, err := s.insert(tx, token)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	if event != nil {
+		if err := insertOutboxEvent(tx, s.builder, event); err != nil {
+			return uuid.UUID{}, errors.Wrap(err, "insert outbox event")
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return uuid.UUID{}, errors.Wrap(err, "commit transaction")
+	}
+
+	return id, nil
+}
+
+func (s *RefreshTokenStorage) insert(tx *sqlx.Tx, token *auth.RefreshToken) (uuid.UUID, error) {
+	var location []byte
+	if token.Location != nil {
+		var err error
+		location, err = json.Marshal(token.Location)
+		if err != nil {
+			return uuid.UUID{}, errors.Wrap(err, "marshal location")
+		}
+	}
+
 	builder := s.builder.
 		Insert("refresh_tokens").
-		Columns(`hash, expires_at`).
-		Values(token.Hash, token.ExpiresAt).
+		Columns(refreshTokenColumns).
+		Values(token.ID, token.FamilyID, token.UserID, token.Hash, token.ExpiresAt, pq.Array(token.Scopes), pq.Array(token.Audience),
+			token.AuthTime, pq.Array(token.AuthMethods), token.AcceptLanguage, token.IP, token.UserAgent,
+			sql.NullString{String: string(location), Valid: location != nil}, token.CreatedAt).
 		Suffix("RETURNING \"id\"")
 
 	query, params, err := builder.ToSql()
@@ -34,17 +126,19 @@ func (s *RefreshTokenStorage) Create(token *auth.RefreshToken) (uuid.UUID, error
 	}
 
 	var id uuid.UUID
-	err = s.db.QueryRow(query, params...).Scan(&id)
-	if err != nil {
+	if err := tx.QueryRow(query, params...).Scan(&id); err != nil {
 		return uuid.UUID{}, errors.Wrap(err, "execute query")
 	}
 
 	return id, nil
 }
 
+const refreshTokenColumns = "id, family_id, user_id, hash, expires_at, scopes, audience, auth_time, amr, accept_language, " +
+	"ip, user_agent, location, created_at"
+
 func (s *RefreshTokenStorage) Get(id uuid.UUID) (*auth.RefreshToken, error) {
 	builder := s.builder.
-		Select("id, hash, expires_at").
+		Select(refreshTokenColumns).
 		From("refresh_tokens").
 		Where(sq.Eq{"id": id})
 
@@ -53,33 +147,173 @@ func (s *RefreshTokenStorage) Get(id uuid.UUID) (*auth.RefreshToken, error) {
 		return nil, errors.Wrap(err, "build query")
 	}
 
+	refreshToken, err := scanRefreshToken(s.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.NewNotFoundError("refresh token not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+
+	return refreshToken, nil
+}
+
+func (s *RefreshTokenStorage) ListByUser(userID uuid.UUID, now time.Time) ([]auth.RefreshToken, error) {
+	builder := s.builder.
+		Select(refreshTokenColumns).
+		From("refresh_tokens").
+		Where(sq.Eq{"user_id": userID}).
+		Where(sq.Gt{"expires_at": now}).
+		OrderBy("created_at DESC")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var refreshTokens []auth.RefreshToken
+	for rows.Next() {
+		refreshToken, err := scanRefreshToken(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		refreshTokens = append(refreshTokens, *refreshToken)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return refreshTokens, nil
+}
+
+func scanRefreshToken(row interface{ Scan(...any) error }) (*auth.RefreshToken, error) {
 	var refreshToken auth.RefreshToken
-	err = s.db.QueryRow(query, args...).Scan(
-		&refreshToken.ID, &refreshToken.Hash, &refreshToken.ExpiresAt,
+	var location []byte
+	err := row.Scan(
+		&refreshToken.ID, &refreshToken.FamilyID, &refreshToken.UserID, &refreshToken.Hash, &refreshToken.ExpiresAt,
+		pq.Array(&refreshToken.Scopes), pq.Array(&refreshToken.Audience),
+		&refreshToken.AuthTime, pq.Array(&refreshToken.AuthMethods), &refreshToken.AcceptLanguage,
+		&refreshToken.IP, &refreshToken.UserAgent, &location, &refreshToken.CreatedAt,
 	)
 	if err != nil {
-		return nil, errors.Wrap(err, "execute query")
+		return nil, err
+	}
+	if location != nil {
+		if err := json.Unmarshal(location, &refreshToken.Location); err != nil {
+			return nil, errors.Wrap(err, "unmarshal location")
+		}
 	}
 
 	return &refreshToken, nil
 }
 
-func (s *RefreshTokenStorage) Delete(id uuid.UUID) error {
+// Delete removes the token and stores the outbox event, if any, in one
+// transaction. It returns auth.NotFoundError when the token does not exist,
+// and stores no event then.
+func (s *RefreshTokenStorage) Delete(id uuid.UUID, event *webhook.Event) error {
+	deleted, err := s.delete(sq.Eq{"id": id}, event)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return auth.NewNotFoundError("refresh token not found")
+	}
+	return nil
+}
+
+// DeleteFamily removes the tokens of the session familyID and stores the
+// outbox event, if any, in one transaction. It returns the number of tokens
+// removed and stores no event when there were none.
+func (s *RefreshTokenStorage) DeleteFamily(familyID uuid.UUID, event *webhook.Event) (int64, error) {
+	return s.delete(sq.Eq{"family_id": familyID}, event)
+}
+
+func (s *RefreshTokenStorage) delete(where sq.Eq, event *webhook.Event) (int64, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
 	builder := s.builder.
 		Delete("refresh_tokens").
-		Where(sq.Eq{"id": id})
+		Where(where)
 
 	query, args, err := builder.ToSql()
 	if err != nil {
-		return errors.Wrap(err, "build query")
+		return 0, errors.Wrap(err, "build query")
 	}
 
-	err = s.db.QueryRow(query, args...).Err()
+	result, err := tx.Exec(query, args...)
 	if err != nil {
-		return errors.Wrap(err, "execute query")
+		return 0, errors.Wrap(err, "execute query")
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "get affected rows")
+	}
+	if deleted == 0 {
+		return 0, nil
 	}
 
-	return nil
+	if event != nil {
+		if err := insertOutboxEvent(tx, s.builder, event); err != nil {
+			return 0, errors.Wrap(err, "insert outbox event")
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "commit transaction")
+	}
+
+	return deleted, nil
 }
 
-var _ auth.RefreshTokenStorage = &RefreshTokenStorage{}
+// DeleteByUser removes the tokens of the user and stores the outbox events in
+// one transaction. It returns the number of tokens removed.
+func (s *RefreshTokenStorage) DeleteByUser(userID uuid.UUID, events ...*webhook.Event) (int64, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	builder := s.builder.
+		Delete("refresh_tokens").
+		Where(sq.Eq{"user_id": userID})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "build query")
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "execute query")
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "get affected rows")
+	}
+
+	for _, event := range events {
+		if err := insertOutboxEvent(tx, s.builder, event); err != nil {
+			return 0, errors.Wrap(err, "insert outbox event")
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "commit transaction")
+	}
+
+	return deleted, nil
+}
+
+var (
+	_ auth.RefreshTokenStorage    = &RefreshTokenStorage{}
+	_ notification.SessionStorage = &RefreshTokenStorage{}
+)
@@ -0,0 +1,182 @@
+package storages
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/risk"
+)
+
+type RiskStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewRiskStorage(db *sqlx.DB) *RiskStorage {
+	return &RiskStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+const riskAssessmentColumns = "id, user_id, session_id, event, ip, network, user_agent, location, score, decision, " +
+	"factors, confirmed_at, created_at"
+
+// acceptedAssessment selects the assessments later requests are compared to.
+var acceptedAssessment = sq.Or{
+	sq.Eq{"decision": []string{risk.DecisionAllow, risk.DecisionNotify}},
+	sq.NotEq{"confirmed_at": nil},
+}
+
+func (s *RiskStorage) Create(assessment *risk.Assessment) error {
+	var location []byte
+	if assessment.Location != nil {
+		var err error
+		location, err = json.Marshal(assessment.Location)
+		if err != nil {
+			return errors.Wrap(err, "marshal location")
+		}
+	}
+	factors, err := json.Marshal(assessment.Factors)
+	if err != nil {
+		return errors.Wrap(err, "marshal factors")
+	}
+
+	builder := s.builder.
+		Insert("risk_assessments").
+		Columns("id, user_id, session_id, event, ip, network, user_agent, location, score, decision, factors, "+
+			"created_at").
+		Values(assessment.ID, assessment.UserID, assessment.SessionID, assessment.Event, assessment.IP,
+			assessment.Network, assessment.UserAgent, sql.NullString{String: string(location), Valid: location != nil},
+			assessment.Score, assessment.Decision, string(factors), assessment.CreatedAt)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+// Previous prefers the assessments of the session over the newer ones of
+// other sessions.
+func (s *RiskStorage) Previous(userID, sessionID uuid.UUID) (*risk.Assessment, error) {
+	builder := s.builder.
+		Select(riskAssessmentColumns).
+		From("risk_assessments").
+		Where(sq.Eq{"user_id": userID}).
+		Where(acceptedAssessment).
+		OrderByClause("session_id = ? DESC", sessionID).
+		OrderBy("created_at DESC").
+		Limit(1)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	var assessment risk.Assessment
+	var location, factors []byte
+	var confirmedAt sql.NullTime
+	err = s.db.QueryRow(query, args...).Scan(&assessment.ID, &assessment.UserID, &assessment.SessionID,
+		&assessment.Event, &assessment.IP, &assessment.Network, &assessment.UserAgent, &location, &assessment.Score,
+		&assessment.Decision, &factors, &confirmedAt, &assessment.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, risk.NewNotFoundError("assessment not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	if location != nil {
+		if err := json.Unmarshal(location, &assessment.Location); err != nil {
+			return nil, errors.Wrap(err, "unmarshal location")
+		}
+	}
+	if err := json.Unmarshal(factors, &assessment.Factors); err != nil {
+		return nil, errors.Wrap(err, "unmarshal factors")
+	}
+	assessment.ConfirmedAt = confirmedAt.Time
+
+	return &assessment, nil
+}
+
+func (s *RiskStorage) CountSince(userID uuid.UUID, since time.Time) (int, error) {
+	builder := s.builder.
+		Select("count(*)").
+		From("risk_assessments").
+		Where(sq.Eq{"user_id": userID}).
+		Where(sq.GtOrEq{"created_at": since})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "build query")
+	}
+
+	var count int
+	if err := s.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, errors.Wrap(err, "execute query")
+	}
+
+	return count, nil
+}
+
+func (s *RiskStorage) IsKnownDevice(userID uuid.UUID, userAgent string) (bool, error) {
+	builder := s.builder.
+		Select("1").
+		From("risk_assessments").
+		Where(sq.Eq{"user_id": userID, "user_agent": userAgent}).
+		Where(acceptedAssessment).
+		Limit(1)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "build query")
+	}
+
+	var one int
+	err = s.db.QueryRow(query, args...).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "execute query")
+	}
+
+	return true, nil
+}
+
+func (s *RiskStorage) Confirm(id uuid.UUID, confirmedAt time.Time) error {
+	builder := s.builder.
+		Update("risk_assessments").
+		Set("confirmed_at", confirmedAt).
+		Where(sq.Eq{"id": id})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+	if affected == 0 {
+		return risk.NewNotFoundError("assessment not found")
+	}
+
+	return nil
+}
+
+var _ risk.Storage = &RiskStorage{}
@@ -0,0 +1,45 @@
+package storages
+
+import (
+	"testing"
+	"time"
+
+	"auth/internal/services/apikey"
+	"auth/internal/storages"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyStorage_RevokeByUser(t *testing.T) {
+	db := newDB(t)
+	storage := storages.NewAPIKeyStorage(db)
+	userID := uuid.New()
+	t.Cleanup(func() { db.Exec("DELETE FROM api_keys WHERE user_id = $1", userID) })
+	for i := 0; i < 2; i++ {
+		key := &apikey.APIKey{
+			Prefix:     "ak_test" + uuid.NewString(),
+			SecretHash: []byte("hash"),
+			Name:       "test",
+			UserID:     userID,
+			Scopes:     []string{"openid"},
+			ExpiresAt:  time.Now().Add(time.Hour),
+		}
+		require.NoError(t, storage.Create(key))
+	}
+
+	revoked, err := storage.RevokeByUser(userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), revoked)
+
+	keys, err := storage.List(apikey.Filter{UserID: userID})
+	require.NoError(t, err)
+	for _, key := range keys {
+		assert.False(t, key.RevokedAt.IsZero())
+	}
+
+	revoked, err = storage.RevokeByUser(userID)
+	require.NoError(t, err)
+	assert.Zero(t, revoked, "revoked keys are not revoked again")
+}
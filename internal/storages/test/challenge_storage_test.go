@@ -0,0 +1,45 @@
+package storages
+
+import (
+	"testing"
+	"time"
+
+	"auth/internal/services/auth"
+	"auth/internal/storages"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChallengeStorage_PendingSession(t *testing.T) {
+	db := newDB(t)
+	storage := storages.NewChallengeStorage(db)
+	now := time.Now().Truncate(time.Microsecond)
+	challenge := &auth.Challenge{
+		ID:     uuid.New(),
+		UserID: uuid.New(),
+		Session: &auth.PendingSession{
+			Scopes:         []string{},
+			AuthTime:       now,
+			AuthMethods:    []string{"pwd"},
+			AcceptLanguage: "en",
+		},
+		SessionID: uuid.New(),
+		CodeHash:  []byte("hash"),
+		IP:        "192.0.2.1",
+		ExpiresAt: now.Add(time.Minute),
+		CreatedAt: now,
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM step_up_challenges WHERE id = $1", challenge.ID) })
+	require.NoError(t, storage.Create(challenge))
+
+	stored, err := storage.UseAttempt(challenge.ID, 5, now)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Nil, stored.RefreshTokenID)
+	require.NotNil(t, stored.Session)
+	assert.Equal(t, []string{}, stored.Session.Scopes, "no scopes differ from all scopes")
+	assert.True(t, now.Equal(stored.Session.AuthTime))
+	assert.Equal(t, challenge.Session.AuthMethods, stored.Session.AuthMethods)
+	assert.Equal(t, "en", stored.Session.AcceptLanguage)
+}
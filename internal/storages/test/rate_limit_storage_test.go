@@ -0,0 +1,78 @@
+package storages
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"auth/internal/services/ratelimit"
+	"auth/internal/storages"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDB connects to the database of TEST_POSTGRES_DSN, which must have the
+// schema of deploy/migrations. Tests are skipped without it.
+func newDB(t *testing.T) *sqlx.DB {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sqlx.Connect("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestRateLimitStorage_Take(t *testing.T) {
+	db := newDB(t)
+	storage := storages.NewRateLimitStorage(db)
+	key := "test:" + uuid.NewString()
+	t.Cleanup(func() { db.Exec("DELETE FROM rate_limit_buckets WHERE key = $1", key) })
+	limit := ratelimit.Limit{Burst: 2, Interval: 10 * time.Second}
+	now := time.Now().Truncate(time.Microsecond)
+
+	for _, want := range []time.Duration{0, 0, 10 * time.Second} {
+		retryAfter, err := storage.Take(key, limit, now)
+		require.NoError(t, err)
+		assert.Equal(t, want, retryAfter)
+	}
+
+	retryAfter, err := storage.Take(key, limit, now.Add(10*time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, retryAfter, "the bucket refills")
+}
+
+func TestRateLimitStorage_TakeConcurrently(t *testing.T) {
+	db := newDB(t)
+	storage := storages.NewRateLimitStorage(db)
+	key := "test:" + uuid.NewString()
+	t.Cleanup(func() { db.Exec("DELETE FROM rate_limit_buckets WHERE key = $1", key) })
+	limit := ratelimit.Limit{Burst: 5, Interval: time.Hour}
+	now := time.Now()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			retryAfter, err := storage.Take(key, limit, now)
+			assert.NoError(t, err)
+			if err == nil && retryAfter == 0 {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, limit.Burst, allowed, "instances must share the bucket")
+}
@@ -0,0 +1,48 @@
+package storages
+
+import (
+	"testing"
+
+	"auth/internal/services/rbac"
+	"auth/internal/storages"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBACStorage_UserPermissions(t *testing.T) {
+	db := newDB(t)
+	storage := storages.NewRBACStorage(db)
+	userID := uuid.New()
+	suffix := uuid.NewString()[:8]
+	role, permission := "role-"+suffix, "permission-"+suffix
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM roles WHERE name = $1", role)
+		db.Exec("DELETE FROM permissions WHERE name = $1", permission)
+	})
+	require.NoError(t, storage.CreateRole(&rbac.Role{Name: role}))
+	require.NoError(t, storage.CreatePermission(&rbac.Permission{Name: permission}))
+	require.NoError(t, storage.AssignUserRole(userID, role))
+
+	roles, permissions, err := storage.GetUserRolesAndPermissions(userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{role}, roles)
+	assert.Empty(t, permissions, "a role without permissions grants none")
+
+	require.NoError(t, storage.AddRolePermission(role, permission))
+	require.NoError(t, storage.AddRolePermission(role, permission), "granting twice is a no-op")
+	_, permissions, err = storage.GetUserRolesAndPermissions(userID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{permission}, permissions)
+
+	require.NoError(t, storage.RemoveRolePermission(role, permission))
+	_, permissions, err = storage.GetUserRolesAndPermissions(userID)
+	require.NoError(t, err)
+	assert.Empty(t, permissions)
+
+	err = storage.RemoveRolePermission(role, permission)
+	assert.ErrorAs(t, err, &rbac.NotFoundError{})
+	err = storage.AddRolePermission(role, "missing-"+suffix)
+	assert.ErrorAs(t, err, &rbac.NotFoundError{})
+}
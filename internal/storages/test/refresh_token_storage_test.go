@@ -0,0 +1,58 @@
+package storages
+
+import (
+	"testing"
+	"time"
+
+	"auth/internal/services/auth"
+	"auth/internal/storages"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTokenStorage_Rotate(t *testing.T) {
+	db := newDB(t)
+	storage := storages.NewRefreshTokenStorage(db)
+	userID := uuid.New()
+	t.Cleanup(func() { db.Exec("DELETE FROM refresh_tokens WHERE user_id = $1", userID) })
+	familyID := uuid.New()
+	newToken := func() *auth.RefreshToken {
+		now := time.Now()
+		return &auth.RefreshToken{
+			ID:        uuid.New(),
+			FamilyID:  familyID,
+			UserID:    userID,
+			Hash:      []byte("hash"),
+			ExpiresAt: now.Add(time.Hour),
+			AuthTime:  now,
+			CreatedAt: now,
+		}
+	}
+
+	token := newToken()
+	_, err := storage.Create(token, nil)
+	require.NoError(t, err)
+
+	rotated := newToken()
+	id, err := storage.Rotate(token.ID, rotated, nil)
+	require.NoError(t, err)
+	assert.Equal(t, rotated.ID, id)
+
+	_, err = storage.Rotate(token.ID, newToken(), nil)
+	assert.ErrorAs(t, err, &auth.NotFoundError{}, "a token is rotated once")
+
+	sessions, err := storage.ListByUser(userID, time.Now())
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, rotated.ID, sessions[0].ID)
+	assert.Equal(t, familyID, sessions[0].FamilyID)
+
+	err = storage.Delete(token.ID, nil)
+	assert.ErrorAs(t, err, &auth.NotFoundError{}, "the rotated token is gone")
+
+	deleted, err := storage.DeleteFamily(familyID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted, "the session ends whichever token it has")
+}
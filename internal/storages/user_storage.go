@@ -1,13 +1,186 @@
 package storages
 
-import "github.com/google/uuid"
+import (
+	"database/sql"
+	"fmt"
 
-type MockUserStorage struct{}
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
 
-func (s *MockUserStorage) GetUserEmail(userID uuid.UUID) (string, error) {
-	return "user@gmail.com", nil
+	"auth/internal/services/email"
+	"auth/internal/services/user"
+)
+
+const userColumns = "id, email, email_verified, name, given_name, family_name, locale, created_at, updated_at"
+
+type UserStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewUserStorage(db *sqlx.DB) *UserStorage {
+	return &UserStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+func (s *UserStorage) Create(u *user.User) error {
+	builder := s.builder.
+		Insert("users").
+		Columns("id, email, email_verified, name, given_name, family_name, locale").
+		Values(u.ID, u.Email, u.EmailVerified, u.Name, u.GivenName, u.FamilyName, u.Locale).
+		Suffix("RETURNING created_at, updated_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	err = s.db.QueryRow(query, args...).Scan(&u.CreatedAt, &u.UpdatedAt)
+	if isPQError(err, pqUniqueViolation) {
+		return user.NewAlreadyExistsError(fmt.Sprintf("user with email %s already exists", u.Email))
+	}
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
 }
 
-func NewFakeUserStorage() *MockUserStorage {
-	return &MockUserStorage{}
+func (s *UserStorage) Get(id uuid.UUID) (*user.User, error) {
+	builder := s.builder.
+		Select(userColumns).
+		From("users").
+		Where(sq.Eq{"id": id})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	u, err := scanUser(s.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, user.NewNotFoundError(fmt.Sprintf("user %s not found", id))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+
+	return u, nil
+}
+
+func (s *UserStorage) List() ([]user.User, error) {
+	builder := s.builder.
+		Select(userColumns).
+		From("users").
+		OrderBy("created_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var users []user.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		users = append(users, *u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return users, nil
 }
+
+func (s *UserStorage) Update(u *user.User) error {
+	builder := s.builder.
+		Update("users").
+		Set("email", u.Email).
+		Set("email_verified", u.EmailVerified).
+		Set("name", u.Name).
+		Set("given_name", u.GivenName).
+		Set("family_name", u.FamilyName).
+		Set("locale", u.Locale).
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"id": u.ID}).
+		Suffix("RETURNING created_at, updated_at")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	err = s.db.QueryRow(query, args...).Scan(&u.CreatedAt, &u.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user.NewNotFoundError(fmt.Sprintf("user %s not found", u.ID))
+	}
+	if isPQError(err, pqUniqueViolation) {
+		return user.NewAlreadyExistsError(fmt.Sprintf("user with email %s already exists", u.Email))
+	}
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *UserStorage) Delete(id uuid.UUID) error {
+	builder := s.builder.
+		Delete("users").
+		Where(sq.Eq{"id": id})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+	if affected == 0 {
+		return user.NewNotFoundError(fmt.Sprintf("user %s not found", id))
+	}
+
+	return nil
+}
+
+func (s *UserStorage) GetUserContact(userID uuid.UUID) (*email.Contact, error) {
+	u, err := s.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &email.Contact{Email: u.Email, Locale: u.Locale}, nil
+}
+
+func scanUser(row rowScanner) (*user.User, error) {
+	var u user.User
+	err := row.Scan(&u.ID, &u.Email, &u.EmailVerified, &u.Name, &u.GivenName, &u.FamilyName, &u.Locale, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+var (
+	_ user.Storage      = &UserStorage{}
+	_ email.UserStorage = &UserStorage{}
+)
@@ -0,0 +1,282 @@
+package storages
+
+import (
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"auth/internal/services/webhook"
+)
+
+type WebhookStorage struct {
+	db      *sqlx.DB
+	builder sq.StatementBuilderType
+}
+
+func NewWebhookStorage(db *sqlx.DB) *WebhookStorage {
+	return &WebhookStorage{
+		db:      db,
+		builder: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+const deliveryColumns = "d.id, d.endpoint, d.status, d.attempts, d.next_attempt_at, d.last_error, d.delivered_at, " +
+	"d.created_at, e.id, e.type, e.payload, e.created_at"
+
+// insertOutboxEvent writes the event in the transaction of the change it
+// describes.
+func insertOutboxEvent(tx *sqlx.Tx, builder sq.StatementBuilderType, event *webhook.Event) error {
+	insertBuilder := builder.
+		Insert("outbox_events").
+		Columns("id, type, payload, created_at").
+		Values(event.ID, event.Type, string(event.Payload), event.CreatedAt)
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *WebhookStorage) CreateDeliveries(limit int, endpoints func(eventType string) []string) (int, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "begin transaction")
+	}
+	defer tx.Rollback()
+
+	selectBuilder := s.builder.
+		Select("id, type").
+		From("outbox_events").
+		Where(sq.Eq{"dispatched_at": nil}).
+		OrderBy("created_at").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "build select query")
+	}
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "select events")
+	}
+	var events []webhook.Event
+	for rows.Next() {
+		var event webhook.Event
+		if err := rows.Scan(&event.ID, &event.Type); err != nil {
+			rows.Close()
+			return 0, errors.Wrap(err, "scan row")
+		}
+		events = append(events, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, errors.Wrap(err, "iterate rows")
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	eventIDs := make([]uuid.UUID, 0, len(events))
+	insertBuilder := s.builder.
+		Insert("webhook_deliveries").
+		Columns("event_id, endpoint, status, next_attempt_at").
+		Suffix("ON CONFLICT (event_id, endpoint) DO NOTHING")
+	hasDeliveries := false
+	for _, event := range events {
+		eventIDs = append(eventIDs, event.ID)
+		for _, endpoint := range endpoints(event.Type) {
+			insertBuilder = insertBuilder.Values(event.ID, endpoint, webhook.DeliveryStatusPending, now)
+			hasDeliveries = true
+		}
+	}
+	if hasDeliveries {
+		query, args, err = insertBuilder.ToSql()
+		if err != nil {
+			return 0, errors.Wrap(err, "build insert query")
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return 0, errors.Wrap(err, "insert deliveries")
+		}
+	}
+
+	updateBuilder := s.builder.
+		Update("outbox_events").
+		Set("dispatched_at", now).
+		Where(sq.Eq{"id": eventIDs})
+
+	query, args, err = updateBuilder.ToSql()
+	if err != nil {
+		return 0, errors.Wrap(err, "build update query")
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return 0, errors.Wrap(err, "mark events dispatched")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "commit transaction")
+	}
+
+	return len(events), nil
+}
+
+func (s *WebhookStorage) ClaimDeliveries(now time.Time, limit int, lease time.Duration) ([]webhook.Delivery, error) {
+	due := s.builder.
+		Select("id").
+		From("webhook_deliveries").
+		Where(sq.Eq{"status": webhook.DeliveryStatusPending}).
+		Where(sq.LtOrEq{"next_attempt_at": now}).
+		OrderBy("next_attempt_at").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	builder := s.builder.
+		Update("webhook_deliveries d").
+		Set("next_attempt_at", now.Add(lease)).
+		FromSelect(due, "due").
+		Where("d.id = due.id").
+		Suffix("RETURNING d.id")
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, errors.Wrap(err, "scan row")
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	return s.listDeliveries(s.selectDeliveries().Where(sq.Eq{"d.id": ids}).OrderBy("d.created_at"))
+}
+
+func (s *WebhookStorage) UpdateDelivery(delivery *webhook.Delivery) error {
+	builder := s.builder.
+		Update("webhook_deliveries").
+		Set("status", delivery.Status).
+		Set("attempts", delivery.Attempts).
+		Set("next_attempt_at", delivery.NextAttemptAt).
+		Set("last_error", delivery.LastError).
+		Set("delivered_at", sql.NullTime{Time: delivery.DeliveredAt, Valid: !delivery.DeliveredAt.IsZero()}).
+		Where(sq.Eq{"id": delivery.ID})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+
+	return nil
+}
+
+func (s *WebhookStorage) ListDeliveries(filter webhook.DeliveryFilter) ([]webhook.Delivery, error) {
+	builder := s.selectDeliveries().
+		OrderBy("d.created_at DESC").
+		Limit(uint64(filter.Limit))
+	if filter.Status != "" {
+		builder = builder.Where(sq.Eq{"d.status": filter.Status})
+	}
+	if filter.Endpoint != "" {
+		builder = builder.Where(sq.Eq{"d.endpoint": filter.Endpoint})
+	}
+
+	return s.listDeliveries(builder)
+}
+
+func (s *WebhookStorage) Replay(id uuid.UUID, at time.Time) error {
+	builder := s.builder.
+		Update("webhook_deliveries").
+		Set("status", webhook.DeliveryStatusPending).
+		Set("attempts", 0).
+		Set("next_attempt_at", at).
+		Where(sq.Eq{"id": id, "status": webhook.DeliveryStatusDead})
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "build query")
+	}
+
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return errors.Wrap(err, "execute query")
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get affected rows")
+	}
+	if affected == 0 {
+		return webhook.NewNotFoundError("dead delivery not found")
+	}
+
+	return nil
+}
+
+func (s *WebhookStorage) selectDeliveries() sq.SelectBuilder {
+	return s.builder.
+		Select(deliveryColumns).
+		From("webhook_deliveries d").
+		Join("outbox_events e ON e.id = d.event_id")
+}
+
+func (s *WebhookStorage) listDeliveries(builder sq.SelectBuilder) ([]webhook.Delivery, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "build query")
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "execute query")
+	}
+	defer rows.Close()
+
+	var deliveries []webhook.Delivery
+	for rows.Next() {
+		var delivery webhook.Delivery
+		var deliveredAt sql.NullTime
+		var payload []byte
+		err := rows.Scan(&delivery.ID, &delivery.Endpoint, &delivery.Status, &delivery.Attempts,
+			&delivery.NextAttemptAt, &delivery.LastError, &deliveredAt, &delivery.CreatedAt,
+			&delivery.Event.ID, &delivery.Event.Type, &payload, &delivery.Event.CreatedAt)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan row")
+		}
+		delivery.DeliveredAt = deliveredAt.Time
+		delivery.Event.Payload = payload
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "iterate rows")
+	}
+
+	return deliveries, nil
+}
+
+var _ webhook.Storage = &WebhookStorage{}
@@ -0,0 +1,25 @@
+package iputils
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// Network returns the IPv4 /ipv4Prefix or IPv6 /ipv6Prefix network around
+// the address, the address itself when it does not parse.
+func Network(ip string, ipv4Prefix, ipv6Prefix int) string {
+	addr, err := netip.ParseAddr(strings.Trim(ip, "[]"))
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap().WithZone("")
+	bits := ipv6Prefix
+	if addr.Is4() {
+		bits = ipv4Prefix
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return addr.String()
+	}
+	return prefix.String()
+}
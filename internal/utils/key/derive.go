@@ -0,0 +1,20 @@
+package keyutils
+
+import (
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// Derive returns a 32-byte key for the purpose named by label, derived from
+// secret with HKDF-SHA256. Keys of different labels are independent, so one
+// configured secret may serve several purposes without reusing a key.
+func Derive(secret []byte, label string) []byte {
+	key := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte(label)), key); err != nil {
+		// HKDF only fails beyond 255 blocks of output
+		panic(err)
+	}
+	return key
+}
@@ -0,0 +1,158 @@
+// Package authmwtest mints access tokens for unit tests of services that
+// use authmw.
+package authmwtest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/pkg/authmw"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const defaultTTL = time.Hour
+
+// Token describes the claims of a minted token. Zero values get defaults:
+// a random subject and a one hour lifetime.
+type Token struct {
+	Subject        string
+	UserIP         string
+	RefreshTokenID string
+	Audience       []string
+	Scopes         []string
+	Roles          []string
+	TTL            time.Duration
+	// Extra claims are added as is and override the fields above.
+	Extra map[string]any
+}
+
+// Minter signs test tokens and doubles as the authmw.KeySource that
+// verifies them.
+type Minter struct {
+	method     jwt.SigningMethod
+	signingKey any
+	verifyKey  any
+	keyID      string
+}
+
+var _ authmw.KeySource = &Minter{}
+
+// NewHMACMinter mints HS512 tokens, the same way the auth service does with a
+// shared secret.
+func NewHMACMinter(secret []byte) *Minter {
+	return &Minter{
+		method:     jwt.SigningMethodHS512,
+		signingKey: secret,
+		verifyKey:  secret,
+	}
+}
+
+// NewRSAMinter mints RS256 tokens with a freshly generated key.
+func NewRSAMinter(t testing.TB) *Minter {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+
+	return &Minter{
+		method:     jwt.SigningMethodRS256,
+		signingKey: key,
+		verifyKey:  &key.PublicKey,
+		keyID:      uuid.NewString(),
+	}
+}
+
+// Mint signs a token with the given claims.
+func (m *Minter) Mint(t testing.TB, token Token) string {
+	t.Helper()
+
+	if token.Subject == "" {
+		token.Subject = uuid.NewString()
+	}
+	if token.TTL == 0 {
+		token.TTL = defaultTTL
+	}
+
+	claims := jwt.MapClaims{
+		authmw.SubjectClaim: token.Subject,
+		authmw.ExpTimeClaim: time.Now().Add(token.TTL).Unix(),
+	}
+	if token.UserIP != "" {
+		claims[authmw.UserIPClaim] = token.UserIP
+	}
+	if token.RefreshTokenID != "" {
+		claims[authmw.RefreshTokenIDClaim] = token.RefreshTokenID
+	}
+	if len(token.Audience) > 0 {
+		claims[authmw.AudienceClaim] = token.Audience
+	}
+	if len(token.Scopes) > 0 {
+		claims[authmw.ScopeClaim] = strings.Join(token.Scopes, " ")
+	}
+	if len(token.Roles) > 0 {
+		claims[authmw.RolesClaim] = token.Roles
+	}
+	for name, value := range token.Extra {
+		claims[name] = value
+	}
+
+	jwtToken := jwt.NewWithClaims(m.method, claims)
+	if m.keyID != "" {
+		jwtToken.Header["kid"] = m.keyID
+	}
+	signed, err := jwtToken.SignedString(m.signingKey)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	return signed
+}
+
+func (m *Minter) Key(context.Context, *jwt.Token) (any, error) {
+	return m.verifyKey, nil
+}
+
+func (m *Minter) Methods() []string {
+	return []string{m.method.Alg()}
+}
+
+// JWK returns the public key of the minter. It only works for RSA minters.
+func (m *Minter) JWK(t testing.TB) authmw.JWK {
+	t.Helper()
+
+	jwk, err := authmw.NewJWK(m.verifyKey, m.keyID, m.method.Alg())
+	if err != nil {
+		t.Fatalf("create jwk: %v", err)
+	}
+	return jwk
+}
+
+// ServeJWKS starts a server publishing the minter's public key and returns
+// its URL, for tests of authmw.NewJWKS. It only works for RSA minters.
+func (m *Minter) ServeJWKS(t testing.TB) string {
+	t.Helper()
+
+	body, err := json.Marshal(authmw.JWKSet{Keys: []authmw.JWK{m.JWK(t)}})
+	if err != nil {
+		t.Fatalf("marshal jwks: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
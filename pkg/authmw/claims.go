@@ -0,0 +1,149 @@
+package authmw
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claim names used in access tokens issued by the auth service.
+const (
+	SubjectClaim        = "sub"
+	UserIPClaim         = "sub_ip"
+	RefreshTokenIDClaim = "refresh_token_id"
+	AudienceClaim       = "aud"
+	ScopeClaim          = "scope"
+	RolesClaim          = "roles"
+	ExpTimeClaim        = "exp"
+	ClientIDClaim       = "client_id"
+	AuthTimeClaim       = "auth_time"
+	AuthMethodsClaim    = "amr"
+	ActorClaim          = "act"
+	APIKeyIDClaim       = "api_key_id"
+)
+
+// Claims are the typed claims of a verified access token.
+type Claims struct {
+	Subject        string
+	UserIP         string
+	RefreshTokenID string
+	Audience       []string
+	Scopes         []string
+	Roles          []string
+	// ClientID is set on tokens that represent an OAuth client itself (client
+	// credentials grant); Subject is the client ID then.
+	ClientID string
+	// AuthTime is when the user authenticated; zero when unknown.
+	AuthTime    time.Time
+	AuthMethods []string
+	// Actor is the "sub" of the RFC 8693 "act" claim: the service that
+	// presents a delegated token on behalf of Subject, or the ID of the admin
+	// who impersonates the user. Empty on tokens used by the subject itself.
+	Actor string
+	// APIKeyID is the key the token was exchanged for, empty on tokens of
+	// sessions.
+	APIKeyID  string
+	ExpiresAt time.Time
+	// Raw holds every claim of the token, including the ones not mapped above.
+	Raw jwt.MapClaims
+}
+
+// FirstParty reports whether the token belongs to a session the user opened
+// with the auth service itself: not one issued to an OAuth client or for
+// another audience, not an impersonation and not exchanged for an API key.
+// Only such sessions may manage credentials or grant access to others.
+func (c *Claims) FirstParty() bool {
+	return len(c.Audience) == 0 && c.ClientID == "" && c.Actor == "" && c.APIKeyID == ""
+}
+
+// HasScope reports whether the token was granted the scope.
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole reports whether the user had the role when the token was issued.
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func parseClaims(claimsMap jwt.MapClaims) (*Claims, error) {
+	claims := Claims{Raw: claimsMap}
+
+	var err error
+	claims.Subject, err = claimsMap.GetSubject()
+	if err != nil || claims.Subject == "" {
+		return nil, fmt.Errorf("claim %s missing", SubjectClaim)
+	}
+	claims.UserIP, _ = claimsMap[UserIPClaim].(string)
+	claims.RefreshTokenID, _ = claimsMap[RefreshTokenIDClaim].(string)
+	claims.ClientID, _ = claimsMap[ClientIDClaim].(string)
+	claims.APIKeyID, _ = claimsMap[APIKeyIDClaim].(string)
+
+	claims.Audience, err = claimsMap.GetAudience()
+	if err != nil {
+		return nil, fmt.Errorf("parse %s claim: %w", AudienceClaim, err)
+	}
+
+	exp, err := claimsMap.GetExpirationTime()
+	if err != nil || exp == nil {
+		return nil, fmt.Errorf("claim %s missing", ExpTimeClaim)
+	}
+	claims.ExpiresAt = exp.Time
+
+	if authTime, ok := claimsMap[AuthTimeClaim].(float64); ok {
+		claims.AuthTime = time.Unix(int64(authTime), 0)
+	}
+	if actor, ok := claimsMap[ActorClaim].(map[string]any); ok {
+		claims.Actor, _ = actor[SubjectClaim].(string)
+	}
+
+	claims.Scopes, err = stringsClaim(claimsMap, ScopeClaim)
+	if err != nil {
+		return nil, err
+	}
+	claims.Roles, err = stringsClaim(claimsMap, RolesClaim)
+	if err != nil {
+		return nil, err
+	}
+	claims.AuthMethods, err = stringsClaim(claimsMap, AuthMethodsClaim)
+	if err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// stringsClaim reads a claim that is either a space-delimited string
+// (RFC 9068 "scope") or a JSON array of strings.
+func stringsClaim(claimsMap jwt.MapClaims, claimName string) ([]string, error) {
+	switch v := claimsMap[claimName].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return strings.Fields(v), nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("claim %s is not a list of strings", claimName)
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("claim %s has unexpected type %T", claimName, v)
+	}
+}
@@ -0,0 +1,43 @@
+package authmw
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrMissingToken      = errors.New("missing access token")
+	ErrInvalidToken      = errors.New("invalid access token")
+	ErrInsufficientScope = errors.New("insufficient scope")
+	ErrIPMismatch        = errors.New("access token is bound to another ip")
+	ErrNotFirstParty     = errors.New("access token is not of a first-party session")
+)
+
+// ErrorHandler writes the response for a request that failed verification.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// DefaultErrorHandler responds as described in RFC 6750: 403 for missing
+// scopes and tokens that are not first-party, and 401 with a
+// WWW-Authenticate challenge for everything else.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusUnauthorized
+	challenge := `Bearer error="invalid_token"`
+	switch {
+	case errors.Is(err, ErrMissingToken):
+		challenge = "Bearer"
+	case errors.Is(err, ErrInsufficientScope):
+		status = http.StatusForbidden
+		challenge = `Bearer error="insufficient_scope"`
+	case errors.Is(err, ErrNotFirstParty):
+		status = http.StatusForbidden
+		challenge = "Bearer"
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Message string `json:"error"`
+	}{Message: err.Error()})
+}
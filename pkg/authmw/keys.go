@@ -0,0 +1,182 @@
+package authmw
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/sync/singleflight"
+)
+
+// KeySource provides the keys that verify access token signatures.
+type KeySource interface {
+	// Key returns the verification key for the token.
+	Key(ctx context.Context, token *jwt.Token) (any, error)
+	// Methods lists the signing algorithms accepted for this source.
+	Methods() []string
+}
+
+type sharedSecret struct {
+	secret []byte
+}
+
+// SharedSecret verifies HMAC-signed tokens with the secret the auth service
+// signs them with.
+func SharedSecret(secret []byte) KeySource {
+	return &sharedSecret{secret: secret}
+}
+
+func (s *sharedSecret) Key(context.Context, *jwt.Token) (any, error) {
+	return s.secret, nil
+}
+
+func (s *sharedSecret) Methods() []string {
+	return []string{
+		jwt.SigningMethodHS256.Alg(),
+		jwt.SigningMethodHS384.Alg(),
+		jwt.SigningMethodHS512.Alg(),
+	}
+}
+
+const (
+	defaultJWKSCacheTTL        = time.Hour
+	defaultJWKSRefreshInterval = time.Minute
+)
+
+// JWKS verifies asymmetrically signed tokens with keys fetched from a JWK set
+// URL. Keys are cached and refetched when the cache expires or when a token
+// refers to an unknown key ID, at most once per refresh interval.
+type JWKS struct {
+	url             string
+	client          *http.Client
+	cacheTTL        time.Duration
+	refreshInterval time.Duration
+
+	fetchGroup singleflight.Group
+
+	mu          sync.Mutex
+	keys        map[string]any
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// NewJWKS creates a key source backed by the JWK set at url. A nil client
+// means http.DefaultClient.
+func NewJWKS(url string, client *http.Client) *JWKS {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &JWKS{
+		url:             url,
+		client:          client,
+		cacheTTL:        defaultJWKSCacheTTL,
+		refreshInterval: defaultJWKSRefreshInterval,
+	}
+}
+
+func (s *JWKS) Methods() []string {
+	return []string{
+		jwt.SigningMethodRS256.Alg(), jwt.SigningMethodRS384.Alg(), jwt.SigningMethodRS512.Alg(),
+		jwt.SigningMethodPS256.Alg(), jwt.SigningMethodPS384.Alg(), jwt.SigningMethodPS512.Alg(),
+		jwt.SigningMethodES256.Alg(), jwt.SigningMethodES384.Alg(), jwt.SigningMethodES512.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+	}
+}
+
+func (s *JWKS) Key(ctx context.Context, token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	s.mu.Lock()
+	key, ok := s.keys[kid]
+	stale := time.Since(s.fetchedAt) > s.cacheTTL
+	s.mu.Unlock()
+	if ok && !stale {
+		return key, nil
+	}
+
+	// the fetch runs without the lock, so that cached keys keep being served
+	// while it waits for the network, and concurrent misses share one fetch
+	_, err, _ := s.fetchGroup.Do("", func() (any, error) {
+		return nil, s.refresh(ctx)
+	})
+
+	s.mu.Lock()
+	key, ok = s.keys[kid]
+	s.mu.Unlock()
+	if ok {
+		// keep serving the cached key while the JWKS endpoint is unavailable
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// refresh replaces the cached keys, unless they were fetched less than a
+// refresh interval ago.
+func (s *JWKS) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	if time.Since(s.attemptedAt) <= s.refreshInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.attemptedAt = time.Now()
+	s.mu.Unlock()
+
+	keys, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *JWKS) fetch(ctx context.Context) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create jwks request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var set JWKSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			// skip keys of unsupported types instead of failing the whole set
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks contains no usable keys")
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,217 @@
+// Package authmw verifies access tokens issued by the auth service in
+// net/http and chi handlers.
+package authmw
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type Options struct {
+	// Keys verify token signatures. Required.
+	Keys KeySource
+	// Audience, when set, must be present in the token "aud" claim.
+	Audience string
+	// RequiredScopes must all be granted to the token.
+	RequiredScopes []string
+	// FirstParty rejects tokens that are not of the user's own session, see
+	// Claims.FirstParty, e.g. for administration routes.
+	FirstParty bool
+	// EnforceIPBinding rejects tokens whose sub_ip claim differs from the
+	// client IP. Tokens without the claim are rejected as well.
+	EnforceIPBinding bool
+	// ClientIP extracts the client IP for EnforceIPBinding. Defaults to the
+	// host part of RemoteAddr, the same value the auth service puts in sub_ip.
+	ClientIP func(r *http.Request) string
+	// TokenFromRequest extracts the raw token. Defaults to the bearer token of
+	// the Authorization header.
+	TokenFromRequest func(r *http.Request) string
+	// Leeway tolerates clock skew when checking expiration.
+	Leeway time.Duration
+	// ErrorHandler writes rejected responses. Defaults to DefaultErrorHandler.
+	ErrorHandler ErrorHandler
+	// Optional lets requests without a token through without claims in the
+	// context. Invalid tokens are still rejected.
+	Optional bool
+}
+
+// Verifier checks raw access tokens outside of HTTP middleware.
+type Verifier struct {
+	keys     KeySource
+	audience string
+	leeway   time.Duration
+}
+
+func NewVerifier(keys KeySource, audience string, leeway time.Duration) *Verifier {
+	return &Verifier{
+		keys:     keys,
+		audience: audience,
+		leeway:   leeway,
+	}
+}
+
+// Verify checks the signature, expiration and audience of the token and
+// returns its claims.
+func (v *Verifier) Verify(ctx context.Context, tokenString string) (*Claims, error) {
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(v.keys.Methods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.leeway),
+	}
+	if v.audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(v.audience))
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
+		return v.keys.Key(ctx, token)
+	}, parserOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	claimsMap, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	claims, err := parseClaims(claimsMap)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	return claims, nil
+}
+
+// New returns middleware that rejects requests without a valid access token
+// and stores the claims of valid ones in the request context.
+func New(opts Options) func(http.Handler) http.Handler {
+	if opts.Keys == nil {
+		panic("authmw: Options.Keys is required")
+	}
+	if opts.ClientIP == nil {
+		opts.ClientIP = RemoteIP
+	}
+	if opts.TokenFromRequest == nil {
+		opts.TokenFromRequest = BearerToken
+	}
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = DefaultErrorHandler
+	}
+	verifier := NewVerifier(opts.Keys, opts.Audience, opts.Leeway)
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			tokenString := opts.TokenFromRequest(r)
+			if tokenString == "" && opts.Optional {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if tokenString == "" {
+				opts.ErrorHandler(w, r, ErrMissingToken)
+				return
+			}
+
+			claims, err := verifier.Verify(r.Context(), tokenString)
+			if err != nil {
+				opts.ErrorHandler(w, r, err)
+				return
+			}
+			if opts.EnforceIPBinding && claims.UserIP != opts.ClientIP(r) {
+				opts.ErrorHandler(w, r, ErrIPMismatch)
+				return
+			}
+			if err := checkScopes(claims, opts.RequiredScopes); err != nil {
+				opts.ErrorHandler(w, r, err)
+				return
+			}
+			if opts.FirstParty && !claims.FirstParty() {
+				opts.ErrorHandler(w, r, ErrNotFirstParty)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), claims)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RequireScopes returns middleware for routes mounted behind New that need
+// scopes in addition to the ones required by the outer middleware.
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := FromContext(r.Context())
+			if !ok {
+				DefaultErrorHandler(w, r, ErrMissingToken)
+				return
+			}
+			if err := checkScopes(claims, scopes); err != nil {
+				DefaultErrorHandler(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func checkScopes(claims *Claims, scopes []string) error {
+	for _, scope := range scopes {
+		if !claims.HasScope(scope) {
+			return fmt.Errorf("%w: %s required", ErrInsufficientScope, scope)
+		}
+	}
+	return nil
+}
+
+// BearerToken returns the bearer token of the Authorization header.
+func BearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// BearerTokenOrCookie returns the bearer token of the Authorization header
+// and falls back to the value of the named cookie, for browser redirects that
+// cannot set headers.
+func BearerTokenOrCookie(cookieName string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		if token := BearerToken(r); token != "" {
+			return token
+		}
+		cookie, err := r.Cookie(cookieName)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}
+}
+
+// RemoteIP returns RemoteAddr without the port, matching the sub_ip claim
+// written by the auth service.
+func RemoteIP(r *http.Request) string {
+	split := strings.Split(r.RemoteAddr, ":")
+	return strings.Join(split[:len(split)-1], ":")
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the claims.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// FromContext returns the claims stored by the middleware.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
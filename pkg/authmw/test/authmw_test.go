@@ -0,0 +1,216 @@
+package authmw
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"auth/pkg/authmw"
+	"auth/pkg/authmw/authmwtest"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	secret   = []byte("private-key")
+	clientIP = "127.0.0.1"
+)
+
+func TestMiddleware_SharedSecret(t *testing.T) {
+	minter := authmwtest.NewHMACMinter(secret)
+	handler, subject := newHandler(authmw.Options{Keys: authmw.SharedSecret(secret)})
+
+	token := minter.Mint(t, authmwtest.Token{Subject: "user-1"})
+	resp := serve(handler, token)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "user-1", *subject)
+}
+
+func TestMiddleware_MissingToken(t *testing.T) {
+	handler, _ := newHandler(authmw.Options{Keys: authmw.SharedSecret(secret)})
+
+	resp := serve(handler, "")
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(t, "Bearer", resp.Header().Get("WWW-Authenticate"))
+}
+
+func TestMiddleware_WrongSecret(t *testing.T) {
+	minter := authmwtest.NewHMACMinter([]byte("another-key"))
+	handler, _ := newHandler(authmw.Options{Keys: authmw.SharedSecret(secret)})
+
+	resp := serve(handler, minter.Mint(t, authmwtest.Token{}))
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestMiddleware_Expired(t *testing.T) {
+	minter := authmwtest.NewHMACMinter(secret)
+	handler, _ := newHandler(authmw.Options{Keys: authmw.SharedSecret(secret)})
+
+	resp := serve(handler, minter.Mint(t, authmwtest.Token{TTL: -time.Minute}))
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestMiddleware_JWKS(t *testing.T) {
+	minter := authmwtest.NewRSAMinter(t)
+	handler, subject := newHandler(authmw.Options{Keys: authmw.NewJWKS(minter.ServeJWKS(t), nil)})
+
+	resp := serve(handler, minter.Mint(t, authmwtest.Token{Subject: "user-1"}))
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "user-1", *subject)
+}
+
+func TestMiddleware_JWKSRejectsSharedSecretTokens(t *testing.T) {
+	rsaMinter := authmwtest.NewRSAMinter(t)
+	hmacMinter := authmwtest.NewHMACMinter(secret)
+	handler, _ := newHandler(authmw.Options{Keys: authmw.NewJWKS(rsaMinter.ServeJWKS(t), nil)})
+
+	resp := serve(handler, hmacMinter.Mint(t, authmwtest.Token{}))
+
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestJWKS_ConcurrentMissesShareOneFetch(t *testing.T) {
+	minter := authmwtest.NewRSAMinter(t)
+	transport := &gatedTransport{gate: make(chan struct{})}
+	verifier := authmw.NewVerifier(authmw.NewJWKS(minter.ServeJWKS(t), &http.Client{Transport: transport}), "", 0)
+	token := minter.Mint(t, authmwtest.Token{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := verifier.Verify(context.Background(), token)
+			assert.NoError(t, err)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond) // let the goroutines wait for the fetch
+	close(transport.gate)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), transport.fetches.Load())
+}
+
+// gatedTransport holds requests until gate is closed and counts them.
+type gatedTransport struct {
+	gate    chan struct{}
+	fetches atomic.Int32
+}
+
+func (t *gatedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.fetches.Add(1)
+	<-t.gate
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestMiddleware_Audience(t *testing.T) {
+	minter := authmwtest.NewHMACMinter(secret)
+	handler, _ := newHandler(authmw.Options{Keys: minter, Audience: "billing"})
+
+	assert.Equal(t, http.StatusOK, serve(handler, minter.Mint(t, authmwtest.Token{Audience: []string{"billing"}})).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, minter.Mint(t, authmwtest.Token{Audience: []string{"crm"}})).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, minter.Mint(t, authmwtest.Token{})).Code)
+}
+
+func TestMiddleware_Scopes(t *testing.T) {
+	minter := authmwtest.NewHMACMinter(secret)
+	handler, _ := newHandler(authmw.Options{Keys: minter, RequiredScopes: []string{"invoices:read"}})
+
+	ok := serve(handler, minter.Mint(t, authmwtest.Token{Scopes: []string{"profile", "invoices:read"}}))
+	assert.Equal(t, http.StatusOK, ok.Code)
+
+	forbidden := serve(handler, minter.Mint(t, authmwtest.Token{Scopes: []string{"profile"}}))
+	assert.Equal(t, http.StatusForbidden, forbidden.Code)
+	assert.Equal(t, `Bearer error="insufficient_scope"`, forbidden.Header().Get("WWW-Authenticate"))
+}
+
+func TestMiddleware_RequireScopes(t *testing.T) {
+	minter := authmwtest.NewHMACMinter(secret)
+	router := chi.NewRouter()
+	router.Use(authmw.New(authmw.Options{Keys: minter}))
+	router.With(authmw.RequireScopes("admin")).Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	assert.Equal(t, http.StatusOK, serve(router, minter.Mint(t, authmwtest.Token{Scopes: []string{"admin"}})).Code)
+	assert.Equal(t, http.StatusForbidden, serve(router, minter.Mint(t, authmwtest.Token{})).Code)
+}
+
+func TestMiddleware_FirstParty(t *testing.T) {
+	minter := authmwtest.NewHMACMinter(secret)
+	handler, _ := newHandler(authmw.Options{Keys: minter, RequiredScopes: []string{"admin"}, FirstParty: true})
+
+	ok := serve(handler, minter.Mint(t, authmwtest.Token{Scopes: []string{"admin"}}))
+	assert.Equal(t, http.StatusOK, ok.Code)
+
+	for _, token := range []authmwtest.Token{
+		{Scopes: []string{"admin"}, Audience: []string{"crm"}},
+		{Scopes: []string{"admin"}, Extra: map[string]any{authmw.ClientIDClaim: "billing"}},
+		{Scopes: []string{"admin"}, Extra: map[string]any{authmw.ActorClaim: map[string]any{"sub": "admin"}}},
+		{Scopes: []string{"admin"}, Extra: map[string]any{authmw.APIKeyIDClaim: "key"}},
+	} {
+		assert.Equal(t, http.StatusForbidden, serve(handler, minter.Mint(t, token)).Code)
+	}
+}
+
+func TestMiddleware_IPBinding(t *testing.T) {
+	minter := authmwtest.NewHMACMinter(secret)
+	handler, _ := newHandler(authmw.Options{Keys: minter, EnforceIPBinding: true})
+
+	assert.Equal(t, http.StatusOK, serve(handler, minter.Mint(t, authmwtest.Token{UserIP: clientIP})).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, minter.Mint(t, authmwtest.Token{UserIP: "10.0.0.1"})).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(handler, minter.Mint(t, authmwtest.Token{})).Code)
+}
+
+func TestClaims_FirstParty(t *testing.T) {
+	minter := authmwtest.NewHMACMinter(secret)
+	verifier := authmw.NewVerifier(minter, "", 0)
+
+	for name, token := range map[string]authmwtest.Token{
+		"client":        {Extra: map[string]any{authmw.ClientIDClaim: "billing"}},
+		"audience":      {Audience: []string{"crm"}},
+		"impersonation": {Extra: map[string]any{authmw.ActorClaim: map[string]any{"sub": "admin"}}},
+		"api key":       {Extra: map[string]any{authmw.APIKeyIDClaim: "3e02eeb9-de9a-4e0a-857b-1293c25bd776"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			claims, err := verifier.Verify(context.Background(), minter.Mint(t, token))
+			require.NoError(t, err)
+			assert.False(t, claims.FirstParty())
+		})
+	}
+
+	claims, err := verifier.Verify(context.Background(), minter.Mint(t, authmwtest.Token{}))
+	require.NoError(t, err)
+	assert.True(t, claims.FirstParty())
+}
+
+func newHandler(opts authmw.Options) (http.Handler, *string) {
+	var subject string
+	handler := authmw.New(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := authmw.FromContext(r.Context())
+		subject = claims.Subject
+	}))
+
+	return handler, &subject
+}
+
+func serve(handler http.Handler, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = clientIP + ":51234"
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	return resp
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey describes a key without its secret. UserID is nil for keys of OAuth
+// clients.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Prefix     string     `json:"prefix"`
+	Name       string     `json:"name"`
+	UserID     *uuid.UUID `json:"userId,omitempty"`
+	ClientID   string     `json:"clientId,omitempty"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string    `json:"name"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CreatedAPIKey is the only response that contains the key, it cannot be
+// read again.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyToken is an access token exchanged for a key. It cannot be refreshed,
+// the key is exchanged again instead.
+type APIKeyToken struct {
+	AccessToken string `json:"accessToken"`
+	TokenType   string `json:"tokenType"`
+	ExpiresIn   int    `json:"expiresIn"`
+}
+
+// ListAPIKeys calls GET /api-keys and returns the keys of the authenticated
+// user, revoked ones included.
+func (c *Client) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var keys []APIKey
+	if err := c.do(ctx, http.MethodGet, "/api-keys", nil, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// CreateAPIKey calls POST /api-keys. The key gets no scopes beyond those of
+// the session that creates it.
+func (c *Client) CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	var key CreatedAPIKey
+	if err := c.do(ctx, http.MethodPost, "/api-keys", req, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey calls DELETE /api-keys/{id}.
+func (c *Client) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, http.MethodDelete, "/api-keys/"+id.String(), nil, nil)
+}
+
+// ExchangeAPIKey calls POST /api-keys/token. It needs no session.
+func (c *Client) ExchangeAPIKey(ctx context.Context, key string) (*APIKeyToken, error) {
+	req := struct {
+		APIKey string `json:"apiKey"`
+	}{key}
+	var token APIKeyToken
+	if err := c.do(ctx, http.MethodPost, "/api-keys/token", req, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
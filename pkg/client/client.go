@@ -0,0 +1,217 @@
+// Package client is a Go SDK for the auth service HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Session is the token pair returned by the session endpoints. RefreshToken
+// is kept in the encoding the server uses on the wire and must be sent back
+// unchanged; callers should treat it as opaque.
+type Session struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// AccessTokenExpiry returns the "exp" claim of the access token. The token
+// signature is not verified, the client only needs the expiry to schedule
+// refreshes.
+func (s *Session) AccessTokenExpiry() (time.Time, error) {
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(s.AccessToken, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("parse access token: %w", err)
+	}
+	if claims.ExpiresAt == nil {
+		return time.Time{}, fmt.Errorf("access token has no exp claim")
+	}
+	return claims.ExpiresAt.Time, nil
+}
+
+// APIError is returned for non-2xx responses.
+type APIError struct {
+	StatusCode int
+	Message    string `json:"error"`
+}
+
+func (err *APIError) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("auth api: %s", http.StatusText(err.StatusCode))
+	}
+	return fmt.Sprintf("auth api: %s: %s", http.StatusText(err.StatusCode), err.Message)
+}
+
+// ChallengeError is returned when the server holds a session until the code
+// it emailed to the user is confirmed with ConfirmRefresh. No session is
+// issued until then, and the current one stays valid.
+type ChallengeError struct {
+	ChallengeID uuid.UUID `json:"challengeId"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+func (err *ChallengeError) Error() string {
+	return fmt.Sprintf("auth api: confirmation required for challenge %s", err.ChallengeID)
+}
+
+// Client calls the auth service. It must not use a Transport that itself
+// authenticates through this client. The calls that act on the user's session,
+// such as ListSessions and the API key calls, are authenticated by the
+// httpClient: use a second Client whose httpClient sends through a Transport.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a client for the service at baseURL. A nil httpClient means
+// http.DefaultClient.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// CreateSession calls GET /session. It returns *ChallengeError when the
+// sign-in must be confirmed.
+func (c *Client) CreateSession(ctx context.Context, userID uuid.UUID) (*Session, error) {
+	query := url.Values{"userID": {userID.String()}}
+	var session Session
+	if err := c.do(ctx, http.MethodGet, "/session?"+query.Encode(), nil, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RefreshSession calls POST /session/refresh and returns the rotated pair.
+// The old pair is no longer valid afterwards. It returns *ChallengeError when
+// the refresh must be confirmed, the old pair stays valid then.
+func (c *Client) RefreshSession(ctx context.Context, session *Session) (*Session, error) {
+	var refreshed Session
+	if err := c.do(ctx, http.MethodPost, "/session/refresh", session, &refreshed); err != nil {
+		return nil, err
+	}
+	return &refreshed, nil
+}
+
+// ConfirmRefresh calls POST /session/refresh/confirm with the code emailed
+// for the challenge and returns the session it held. It must be called from
+// the address the challenge was issued to.
+func (c *Client) ConfirmRefresh(ctx context.Context, challengeID uuid.UUID, code string) (*Session, error) {
+	req := struct {
+		ChallengeID uuid.UUID `json:"challengeId"`
+		Code        string    `json:"code"`
+	}{challengeID, code}
+	var session Session
+	if err := c.do(ctx, http.MethodPost, "/session/refresh/confirm", req, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RevokeSession calls POST /session/revoke. Both tokens of the session stop
+// working.
+func (c *Client) RevokeSession(ctx context.Context, session *Session) error {
+	return c.do(ctx, http.MethodPost, "/session/revoke", session, nil)
+}
+
+// SessionInfo describes a session of the user without its tokens. Current is
+// set for the session that made the request.
+type SessionInfo struct {
+	ID        uuid.UUID `json:"id"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	Location  *Location `json:"location,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	AuthTime  time.Time `json:"authTime"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Current   bool      `json:"current"`
+}
+
+// Location is where the server located the address of a session.
+type Location struct {
+	Country      string       `json:"country,omitempty"`
+	City         string       `json:"city,omitempty"`
+	ASN          uint         `json:"asn,omitempty"`
+	Organization string       `json:"organization,omitempty"`
+	Coordinates  *Coordinates `json:"coordinates,omitempty"`
+}
+
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// ListSessions calls GET /sessions and returns the active sessions of the
+// authenticated user.
+func (c *Client) ListSessions(ctx context.Context) ([]SessionInfo, error) {
+	var sessions []SessionInfo
+	if err := c.do(ctx, http.MethodGet, "/sessions", nil, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	return decodeResponse(resp, out)
+}
+
+func decodeResponse(resp *http.Response, out any) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		_ = json.NewDecoder(resp.Body).Decode(apiErr)
+		return apiErr
+	}
+	// the session endpoints accept a sign-in or refresh that waits for a
+	// confirmation, the response is a challenge and not the session
+	if resp.StatusCode == http.StatusAccepted {
+		challengeErr := &ChallengeError{}
+		if err := json.NewDecoder(resp.Body).Decode(challengeErr); err != nil {
+			return fmt.Errorf("decode challenge: %w", err)
+		}
+		return challengeErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
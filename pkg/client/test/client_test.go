@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auth/pkg/client"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAPIServer serves the session list and the API keys to session's access
+// token only.
+func newAPIServer(t *testing.T, session client.Session, keyID uuid.UUID) *httptest.Server {
+	authorized := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer "+session.AccessToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next(w, r)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sessions", authorized(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]any{{
+			"id": uuid.New(), "ip": "192.0.2.1", "current": true,
+			"location": map[string]any{"country": "NL"},
+		}})
+	}))
+	mux.HandleFunc("POST /session/revoke", func(w http.ResponseWriter, r *http.Request) {
+		var req client.Session
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.AccessToken != session.AccessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "session not found"})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /api-keys", authorized(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]any{{"id": keyID, "name": "ci"}})
+	}))
+	mux.HandleFunc("POST /api-keys", authorized(func(w http.ResponseWriter, r *http.Request) {
+		var req client.CreateAPIKeyRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": keyID, "name": req.Name, "scopes": req.Scopes, "key": "ak_secret"})
+	}))
+	mux.HandleFunc("DELETE /api-keys/{keyID}", authorized(func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("keyID") != keyID.String() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.HandleFunc("POST /api-keys/token", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			APIKey string `json:"apiKey"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.APIKey != "ak_secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"accessToken": "token", "tokenType": "Bearer", "expiresIn": 300})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestClient_AuthenticatedCalls(t *testing.T) {
+	ctx := context.Background()
+	session := newSession(t, time.Hour)
+	keyID := uuid.New()
+	srv := newAPIServer(t, session, keyID)
+	api := client.New(srv.URL, newHTTPClient(srv.URL, session))
+
+	sessions, err := api.ListSessions(ctx)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.True(t, sessions[0].Current)
+	assert.Equal(t, "NL", sessions[0].Location.Country)
+
+	created, err := api.CreateAPIKey(ctx, client.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"openid"}})
+	require.NoError(t, err)
+	assert.Equal(t, keyID, created.ID)
+	assert.Equal(t, []string{"openid"}, created.Scopes)
+	assert.Equal(t, "ak_secret", created.Key)
+
+	keys, err := api.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, keyID, keys[0].ID)
+
+	require.NoError(t, api.RevokeAPIKey(ctx, keyID))
+	var apiErr *client.APIError
+	require.ErrorAs(t, api.RevokeAPIKey(ctx, uuid.New()), &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+
+	_, err = client.New(srv.URL, nil).ListAPIKeys(ctx)
+	require.ErrorAs(t, err, &apiErr, "the calls are authenticated by the transport")
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+}
+
+func TestClient_UnauthenticatedCalls(t *testing.T) {
+	ctx := context.Background()
+	session := newSession(t, time.Hour)
+	srv := newAPIServer(t, session, uuid.New())
+	authClient := client.New(srv.URL, nil)
+
+	token, err := authClient.ExchangeAPIKey(ctx, "ak_secret")
+	require.NoError(t, err)
+	assert.Equal(t, "token", token.AccessToken)
+	assert.Equal(t, 300, token.ExpiresIn)
+
+	require.NoError(t, authClient.RevokeSession(ctx, &session))
+	var apiErr *client.APIError
+	require.ErrorAs(t, authClient.RevokeSession(ctx, &client.Session{AccessToken: "other"}), &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, "session not found", apiErr.Message)
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"auth/pkg/authmw/authmwtest"
+	"auth/pkg/client"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer accepts apiToken on /api and rotates refreshable on
+// /session/refresh, or holds the refresh for challengeID when it is set.
+type fakeServer struct {
+	t           *testing.T
+	mu          sync.Mutex
+	apiToken    string
+	refreshable client.Session
+	challengeID uuid.UUID
+	refreshes   atomic.Int32
+}
+
+func newFakeServer(t *testing.T, session client.Session) (*fakeServer, *httptest.Server) {
+	fake := &fakeServer{
+		t:           t,
+		apiToken:    session.AccessToken,
+		refreshable: session,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/session/refresh", fake.refresh)
+	mux.HandleFunc("/session/refresh/confirm", fake.confirm)
+	mux.HandleFunc("/api", fake.api)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return fake, srv
+}
+
+var minter = authmwtest.NewHMACMinter([]byte("private-key"))
+
+func newSession(t *testing.T, accessTTL time.Duration) client.Session {
+	return client.Session{
+		AccessToken:  minter.Mint(t, authmwtest.Token{TTL: accessTTL}),
+		RefreshToken: "9W0/xxXxSSSprySP/JRTRQ==",
+	}
+}
+
+func (f *fakeServer) refresh(w http.ResponseWriter, r *http.Request) {
+	f.refreshes.Add(1)
+	time.Sleep(10 * time.Millisecond) // widen the race window
+
+	var session client.Session
+	_ = json.NewDecoder(r.Body).Decode(&session)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if session != f.refreshable {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if f.challengeID != uuid.Nil {
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(map[string]any{"challengeId": f.challengeID, "expiresAt": time.Now().Add(time.Minute)})
+		return
+	}
+	f.rotate(w)
+}
+
+func (f *fakeServer) confirm(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChallengeID uuid.UUID `json:"challengeId"`
+		Code        string    `json:"code"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.challengeID == uuid.Nil || req.ChallengeID != f.challengeID || req.Code != "123456" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	f.challengeID = uuid.Nil
+	f.rotate(w)
+}
+
+func (f *fakeServer) rotate(w http.ResponseWriter) {
+	f.refreshable = newSession(f.t, time.Hour)
+	f.apiToken = f.refreshable.AccessToken
+	_ = json.NewEncoder(w).Encode(f.refreshable)
+}
+
+func (f *fakeServer) api(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.Method == http.MethodPost && string(body) != "body" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer "+f.apiToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestTransport_RefreshesExpiringTokenOnce(t *testing.T) {
+	session := newSession(t, 5*time.Second)
+	fake, srv := newFakeServer(t, session)
+	httpClient := newHTTPClient(srv.URL, session)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := httpClient.Get(srv.URL + "/api")
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), fake.refreshes.Load())
+}
+
+func TestTransport_RetriesOnceOnUnauthorized(t *testing.T) {
+	session := newSession(t, time.Hour)
+	fake, srv := newFakeServer(t, session)
+	httpClient := newHTTPClient(srv.URL, session)
+	fake.apiToken = "revoked"
+
+	resp, err := httpClient.Post(srv.URL+"/api", "text/plain", strings.NewReader("body"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(1), fake.refreshes.Load())
+}
+
+func TestTransport_ReturnsUnauthorizedWhenRefreshFails(t *testing.T) {
+	session := newSession(t, time.Hour)
+	fake, srv := newFakeServer(t, session)
+	httpClient := newHTTPClient(srv.URL, session)
+	fake.apiToken = "revoked"
+	fake.refreshable = newSession(t, time.Hour)
+
+	resp, err := httpClient.Get(srv.URL + "/api")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, int32(1), fake.refreshes.Load())
+}
+
+func TestTransport_KeepsSessionOnChallenge(t *testing.T) {
+	session := newSession(t, 5*time.Second)
+	fake, srv := newFakeServer(t, session)
+	fake.challengeID = uuid.New()
+	authClient := client.New(srv.URL, nil)
+	store := client.NewMemoryTokenStore(&session)
+	httpClient := &http.Client{Transport: &client.Transport{Client: authClient, Store: store}}
+
+	_, err := httpClient.Get(srv.URL + "/api")
+
+	var challengeErr *client.ChallengeError
+	require.ErrorAs(t, err, &challengeErr)
+	assert.Equal(t, fake.challengeID, challengeErr.ChallengeID)
+	stored, err := store.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, session, *stored, "the challenge does not replace the session")
+
+	confirmed, err := authClient.ConfirmRefresh(context.Background(), challengeErr.ChallengeID, "123456")
+	require.NoError(t, err)
+	require.NoError(t, store.Save(context.Background(), confirmed))
+
+	resp, err := httpClient.Get(srv.URL + "/api")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func newHTTPClient(baseURL string, session client.Session) *http.Client {
+	return &http.Client{
+		Transport: &client.Transport{
+			Client: client.New(baseURL, nil),
+			Store:  client.NewMemoryTokenStore(&session),
+		},
+	}
+}
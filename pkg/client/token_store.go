@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrNoSession = errors.New("no session in token store")
+
+// TokenStore persists the current session of a Transport. Implementations
+// must be safe for concurrent use; Load returns ErrNoSession when empty.
+type TokenStore interface {
+	Load(ctx context.Context) (*Session, error)
+	Save(ctx context.Context, session *Session) error
+}
+
+// MemoryTokenStore keeps the session in memory.
+type MemoryTokenStore struct {
+	mu      sync.RWMutex
+	session *Session
+}
+
+var _ TokenStore = &MemoryTokenStore{}
+
+func NewMemoryTokenStore(session *Session) *MemoryTokenStore {
+	return &MemoryTokenStore{session: session}
+}
+
+func (s *MemoryTokenStore) Load(context.Context) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.session == nil {
+		return nil, ErrNoSession
+	}
+	session := *s.session
+	return &session, nil
+}
+
+func (s *MemoryTokenStore) Save(_ context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := *session
+	s.session = &saved
+	return nil
+}
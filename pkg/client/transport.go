@@ -0,0 +1,143 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const defaultRefreshBefore = 30 * time.Second
+
+// Transport is an http.RoundTripper that authenticates requests with the
+// session from Store. It refreshes the session shortly before the access
+// token expires and once more when a request is rejected with 401.
+// Concurrent refreshes of the same session are collapsed into one call, so
+// the rotated refresh token is never used twice. A refresh that must be
+// confirmed fails with *ChallengeError and keeps the stored session; the
+// caller confirms it with Client.ConfirmRefresh and saves the result.
+type Transport struct {
+	// Base sends the authenticated requests. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+	// Client refreshes sessions. Required.
+	Client *Client
+	// Store holds the current session. Required.
+	Store TokenStore
+	// RefreshBefore is how long before expiry the access token is refreshed.
+	// Defaults to 30 seconds.
+	RefreshBefore time.Duration
+
+	refreshGroup singleflight.Group
+}
+
+var _ http.RoundTripper = &Transport{}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	session, err := t.Store.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load session: %w", err)
+	}
+	if t.expiresSoon(session) {
+		session, err = t.refresh(req, session)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := t.base().RoundTrip(authorize(req, session))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	retry, ok := rewind(req)
+	if !ok {
+		return resp, nil
+	}
+	session, err = t.refresh(req, session)
+	if err != nil {
+		// the original 401 is more useful to the caller than the refresh error
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	return t.base().RoundTrip(authorize(retry, session))
+}
+
+// refresh rotates stale and returns the new session. Goroutines that refresh
+// the same session wait for a single call; a goroutine that loaded the
+// session before another one already rotated it gets the stored result.
+func (t *Transport) refresh(req *http.Request, stale *Session) (*Session, error) {
+	ctx := req.Context()
+
+	result, err, _ := t.refreshGroup.Do(stale.AccessToken, func() (any, error) {
+		current, err := t.Store.Load(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("load session: %w", err)
+		}
+		if current.AccessToken != stale.AccessToken {
+			return current, nil
+		}
+
+		refreshed, err := t.Client.RefreshSession(ctx, current)
+		if err != nil {
+			return nil, fmt.Errorf("refresh session: %w", err)
+		}
+		if err := t.Store.Save(ctx, refreshed); err != nil {
+			return nil, fmt.Errorf("save session: %w", err)
+		}
+
+		return refreshed, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(*Session), nil
+}
+
+func (t *Transport) expiresSoon(session *Session) bool {
+	refreshBefore := t.RefreshBefore
+	if refreshBefore == 0 {
+		refreshBefore = defaultRefreshBefore
+	}
+	expiresAt, err := session.AccessTokenExpiry()
+	if err != nil {
+		// let the server decide, a 401 triggers a refresh anyway
+		return false
+	}
+	return time.Until(expiresAt) < refreshBefore
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func authorize(req *http.Request, session *Session) *http.Request {
+	authorized := req.Clone(req.Context())
+	authorized.Header.Set("Authorization", "Bearer "+session.AccessToken)
+	return authorized
+}
+
+// rewind returns a copy of req that can be sent again, or false when the
+// body cannot be replayed.
+func rewind(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, true
+}